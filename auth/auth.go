@@ -0,0 +1,182 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/quintilesims/iqvbot/db"
+	"github.com/quintilesims/iqvbot/models"
+)
+
+// actions that require authorization
+const (
+	ActionCandidateAdd      = "candidate add"
+	ActionCandidateExport   = "candidate export"
+	ActionCandidateImport   = "candidate import"
+	ActionCandidateNote     = "candidate note"
+	ActionCandidateRemove   = "candidate rm"
+	ActionCandidateUpdate   = "candidate update"
+	ActionConfigSet         = "config set"
+	ActionHireAdd           = "hire add"
+	ActionHireExport        = "hire export"
+	ActionHireRemove        = "hire rm"
+	ActionHireStep          = "hire step"
+	ActionCalendarTeam      = "interview calendar team"
+	ActionCalendarTeamReset = "interview calendar --reset-team"
+	ActionInterviewAdd      = "interview add"
+	ActionInterviewEdit     = "interview edit"
+	ActionInterviewRemove   = "interview rm"
+	ActionKarmaSeason       = "karma season start"
+	ActionReminderRemove    = "remind rm"
+	ActionRoleGrantRevoke   = "role grant/revoke"
+)
+
+// A Rule describes which users are allowed to perform an action.
+// Users with any of the Roles are allowed, as are the owners of the resource being acted on
+// if Owners is set; Owners describes who they are, e.g. "the candidate's manager".
+// Admins are allowed to perform every action.
+type Rule struct {
+	Roles  []string
+	Owners string
+}
+
+// Rules maps each action to the users that are allowed to perform it
+var Rules = map[string]Rule{
+	ActionCandidateAdd: {
+		Roles: []string{models.RoleRecruiter, models.RoleHiringManager},
+	},
+	ActionCandidateExport: {
+		Roles: []string{models.RoleRecruiter},
+	},
+	ActionCandidateImport: {
+		Roles: []string{models.RoleRecruiter},
+	},
+	ActionCandidateNote: {
+		Roles:  []string{models.RoleRecruiter, models.RoleInterviewer},
+		Owners: "the candidate's manager",
+	},
+	ActionCandidateRemove: {
+		Roles:  []string{models.RoleRecruiter},
+		Owners: "the candidate's manager",
+	},
+	ActionCandidateUpdate: {
+		Roles:  []string{models.RoleRecruiter},
+		Owners: "the candidate's manager",
+	},
+	ActionConfigSet: {},
+	ActionHireAdd: {
+		Roles:  []string{models.RoleRecruiter},
+		Owners: "the candidate's manager",
+	},
+	ActionHireExport: {
+		Roles: []string{models.RoleRecruiter},
+	},
+	ActionHireRemove: {
+		Roles:  []string{models.RoleRecruiter},
+		Owners: "the candidate's manager",
+	},
+	ActionHireStep: {
+		Owners: "the candidate's manager",
+	},
+	ActionCalendarTeam: {
+		Roles: []string{models.RoleRecruiter, models.RoleHiringManager},
+	},
+	ActionCalendarTeamReset: {},
+	ActionInterviewAdd: {
+		Roles: []string{models.RoleRecruiter, models.RoleHiringManager, models.RoleInterviewer},
+	},
+	ActionInterviewEdit: {
+		Roles:  []string{models.RoleRecruiter},
+		Owners: "the interview's interviewers",
+	},
+	ActionInterviewRemove: {
+		Roles:  []string{models.RoleRecruiter},
+		Owners: "the interview's interviewers",
+	},
+	ActionKarmaSeason: {},
+	ActionReminderRemove: {
+		Owners: "the reminder's creator",
+	},
+	ActionRoleGrantRevoke: {},
+}
+
+// PermissionDeniedError occurs when a user is not allowed to perform an action
+type PermissionDeniedError struct {
+	message string
+}
+
+// NewPermissionDeniedError creates a new PermissionDeniedError object
+func NewPermissionDeniedError(action string, rule Rule) *PermissionDeniedError {
+	roles := []string{}
+	for _, role := range append([]string{models.RoleAdmin}, rule.Roles...) {
+		roles = append(roles, fmt.Sprintf("*%s*", role))
+	}
+
+	list := roles[0]
+	if n := len(roles); n > 1 {
+		list = fmt.Sprintf("%s or %s", strings.Join(roles[:n-1], ", "), roles[n-1])
+	}
+
+	allowed := fmt.Sprintf("users with the %s role", list)
+	if rule.Owners != "" {
+		allowed = fmt.Sprintf("%s or %s", rule.Owners, allowed)
+	}
+
+	return &PermissionDeniedError{
+		message: fmt.Sprintf("Permission denied: `%s` can only be used by %s", action, allowed),
+	}
+}
+
+func (e *PermissionDeniedError) Error() string {
+	return e.message
+}
+
+// Authorize returns a *PermissionDeniedError if the user is not allowed to perform the action.
+// The ownerIDs are the users that own the resource being acted on, such as a candidate's manager
+// or an interview's interviewers.
+func Authorize(store db.Store, userID, action string, ownerIDs ...string) error {
+	rule, ok := Rules[action]
+	if !ok {
+		return fmt.Errorf("No authorization rule exists for action '%s'", action)
+	}
+
+	if rule.Owners != "" {
+		for _, ownerID := range ownerIDs {
+			if ownerID != "" && ownerID == userID {
+				return nil
+			}
+		}
+	}
+
+	roles := models.Roles{}
+	if err := store.Read(db.RolesKey, &roles); err != nil {
+		return err
+	}
+
+	for _, role := range append([]string{models.RoleAdmin}, rule.Roles...) {
+		if roles.Has(userID, role) {
+			return nil
+		}
+	}
+
+	return NewPermissionDeniedError(action, rule)
+}
+
+// GrantAdmins gives the admin role to each of the users.
+// This allows the first admins to be configured before anyone can run '!role grant'.
+func GrantAdmins(store db.Store, userIDs []string) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	roles := models.Roles{}
+	if err := store.Read(db.RolesKey, &roles); err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		roles.Grant(userID, models.RoleAdmin)
+	}
+
+	return store.Write(db.RolesKey, roles)
+}
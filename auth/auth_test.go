@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/quintilesims/iqvbot/db"
+	"github.com/quintilesims/iqvbot/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorize(t *testing.T) {
+	store := newMemoryStore(t)
+	roles := models.Roles{
+		"admin":     {models.RoleAdmin},
+		"recruiter": {models.RoleRecruiter},
+	}
+
+	if err := store.Write(db.RolesKey, roles); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		UserID   string
+		Action   string
+		OwnerIDs []string
+		Allowed  bool
+	}{
+		{"admin", ActionHireStep, nil, true},
+		{"admin", ActionRoleGrantRevoke, nil, true},
+		{"manager", ActionHireStep, []string{"manager"}, true},
+		{"manager", ActionHireStep, []string{"other"}, false},
+		{"manager", ActionHireStep, nil, false},
+		{"recruiter", ActionHireStep, []string{"manager"}, false},
+		{"recruiter", ActionHireAdd, []string{"manager"}, true},
+		{"recruiter", ActionRoleGrantRevoke, nil, false},
+		{"admin", ActionKarmaSeason, nil, true},
+		{"recruiter", ActionKarmaSeason, nil, false},
+		{"nobody", ActionKarmaSeason, nil, false},
+		{"admin", ActionCalendarTeamReset, nil, true},
+		{"recruiter", ActionCalendarTeam, nil, true},
+		{"recruiter", ActionCalendarTeamReset, nil, false},
+		{"nobody", ActionCalendarTeam, nil, false},
+		{"interviewer", ActionInterviewEdit, []string{"other", "interviewer"}, true},
+		{"nobody", ActionCandidateAdd, []string{"nobody"}, false},
+		{"", ActionHireStep, []string{""}, false},
+	}
+
+	for _, c := range cases {
+		err := Authorize(store, c.UserID, c.Action, c.OwnerIDs...)
+		if c.Allowed {
+			assert.NoError(t, err, "%s: %s", c.UserID, c.Action)
+			continue
+		}
+
+		if _, ok := err.(*PermissionDeniedError); !ok {
+			t.Errorf("%s: %s: error was not a PermissionDeniedError: %#v", c.UserID, c.Action, err)
+		}
+	}
+}
+
+func TestAuthorizeUnknownAction(t *testing.T) {
+	if err := Authorize(newMemoryStore(t), "uid", "some action"); err == nil {
+		t.Fatal("Error was nil!")
+	}
+}
+
+func TestPermissionDeniedError(t *testing.T) {
+	err := NewPermissionDeniedError(ActionHireStep, Rules[ActionHireStep])
+	expected := "Permission denied: `hire step` can only be used by the candidate's manager or users with the *admin* role"
+	assert.Equal(t, expected, err.Error())
+
+	err = NewPermissionDeniedError(ActionCandidateAdd, Rules[ActionCandidateAdd])
+	expected = "Permission denied: `candidate add` can only be used by users with the *admin*, *recruiter* or *hiring-manager* role"
+	assert.Equal(t, expected, err.Error())
+}
+
+func TestGrantAdmins(t *testing.T) {
+	store := newMemoryStore(t)
+	if err := store.Write(db.RolesKey, models.Roles{"uid1": {models.RoleRecruiter}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := GrantAdmins(store, []string{"uid1", "uid2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	result := models.Roles{}
+	if err := store.Read(db.RolesKey, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := models.Roles{
+		"uid1": {models.RoleAdmin, models.RoleRecruiter},
+		"uid2": {models.RoleAdmin},
+	}
+
+	assert.Equal(t, expected, result)
+}
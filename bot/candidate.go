@@ -4,15 +4,24 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
+	"github.com/nlopes/slack"
+	"github.com/quintilesims/iqvbot/auth"
 	"github.com/quintilesims/iqvbot/db"
 	"github.com/quintilesims/iqvbot/models"
+	"github.com/quintilesims/iqvbot/utils"
 	"github.com/urfave/cli"
 	"github.com/zpatrick/slackbot"
 )
 
-// NewCandidateCommand create a cli.Command that allows users to add, update, list, and remove candidates
-func NewCandidateCommand(store db.Store, w io.Writer) cli.Command {
+// The number of events displayed on each page of a candidate's timeline
+const candidateTimelinePageSize = 10
+
+// NewCandidateCommand create a cli.Command that allows users to add, update, list, and remove candidates.
+// The msg is the slack message that invoked the command; its user is used to attribute notes and events.
+func NewCandidateCommand(store db.Store, client utils.SlackClient, msg slack.Msg, w io.Writer) cli.Command {
+	userID := msg.User
 	return cli.Command{
 		Name:  "candidate",
 		Usage: "manage candidates",
@@ -28,6 +37,10 @@ func NewCandidateCommand(store db.Store, w io.Writer) cli.Command {
 					},
 				},
 				Action: func(c *cli.Context) error {
+					if err := auth.Authorize(store, userID, auth.ActionCandidateAdd); err != nil {
+						return err
+					}
+
 					args := c.Args()
 					name := args.Get(0)
 					if name == "" {
@@ -62,6 +75,9 @@ func NewCandidateCommand(store db.Store, w io.Writer) cli.Command {
 						Name:      name,
 						ManagerID: managerID,
 						Meta:      meta,
+						Events: models.CandidateEvents{
+							newCandidateEvent(models.CandidateEventStage, userID, "Added as a candidate"),
+						},
 					}
 
 					candidates = append(candidates, candidate)
@@ -72,14 +88,188 @@ func NewCandidateCommand(store db.Store, w io.Writer) cli.Command {
 					return slackbot.WriteStringf(w, "Ok, I've added a new candidate named *%s*", name)
 				},
 			},
+			{
+				Name:  "import",
+				Usage: "add or update candidates from CSV or JSON in a ```code block``` or an uploaded file",
+				Description: "The CSV header (or JSON keys) must include a 'name' column; a 'manager' column is required for new candidates.\n" +
+					"All other columns are added as metadata. Changes are previewed unless --commit is set.",
+				Flags: []cli.Flag{
+					cli.BoolFlag{
+						Name:  "commit",
+						Usage: "Apply the changes instead of previewing them",
+					},
+					cli.BoolFlag{
+						Name:  "overwrite",
+						Usage: "Allow the import to change the manager of existing candidates",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					if err := auth.Authorize(store, userID, auth.ActionCandidateImport); err != nil {
+						return err
+					}
+
+					input, err := readCandidateImport(client, msg)
+					if err != nil {
+						return err
+					}
+
+					rows, err := parseCandidateImport(input)
+					if err != nil {
+						return err
+					}
+
+					if len(rows) == 0 {
+						return slackbot.NewUserInputError("I couldn't find any candidates to import")
+					}
+
+					candidates := models.Candidates{}
+					if err := store.Read(db.CandidatesKey, &candidates); err != nil {
+						return err
+					}
+
+					plan := planCandidateImport(candidates, rows, c.Bool("overwrite"), newSlackUserResolver(client))
+					if !c.Bool("commit") {
+						text := "Here's what this import would do (run it again with `--commit` to apply these changes): \n"
+						text += formatCandidateImportPlan(plan)
+						return slackbot.WriteString(w, text)
+					}
+
+					for _, candidate := range plan.Creates {
+						candidate.Events = models.CandidateEvents{
+							newCandidateEvent(models.CandidateEventStage, userID, "Imported as a candidate"),
+						}
+
+						candidates = append(candidates, candidate)
+					}
+
+					for _, update := range plan.Updates {
+						if update.Candidate.Meta == nil {
+							update.Candidate.Meta = map[string]string{}
+						}
+
+						for key, val := range update.Meta {
+							update.Candidate.Meta[key] = val
+						}
+
+						if update.ManagerID != "" && update.ManagerID != update.Candidate.ManagerID {
+							update.Candidate.ManagerID = update.ManagerID
+							update.Candidate.Events = append(update.Candidate.Events, newCandidateEvent(
+								models.CandidateEventStage,
+								userID,
+								"Manager changed to %s by import", slackbot.EscapeUserID(update.ManagerID)))
+						}
+					}
+
+					if err := store.Write(db.CandidatesKey, candidates); err != nil {
+						return err
+					}
+
+					text := fmt.Sprintf("Ok, I've added %d and updated %d candidates. \n", len(plan.Creates), len(plan.Updates))
+					if len(plan.Conflicts)+len(plan.Errors) > 0 {
+						text += "The following rows were skipped: \n"
+						for _, line := range append(plan.Conflicts, plan.Errors...) {
+							text += fmt.Sprintf("• %s\n", line)
+						}
+					}
+
+					return slackbot.WriteString(w, text)
+				},
+			},
+			{
+				Name:      "note",
+				Usage:     "add a note to a candidate's timeline",
+				ArgsUsage: "NAME TEXT",
+				Action: func(c *cli.Context) error {
+					args := c.Args()
+					name := args.Get(0)
+					if name == "" {
+						return slackbot.NewUserInputError("Argument NAME is required")
+					}
+
+					text := strings.Join(args.Tail(), " ")
+					if text == "" {
+						return slackbot.NewUserInputError("Argument TEXT is required")
+					}
+
+					candidates := models.Candidates{}
+					if err := store.Read(db.CandidatesKey, &candidates); err != nil {
+						return err
+					}
+
+					candidate, ok := candidates.Get(name)
+					if !ok {
+						return candidateDoesNotExist(name)
+					}
+
+					if err := auth.Authorize(store, userID, auth.ActionCandidateNote, candidate.ManagerID); err != nil {
+						return err
+					}
+
+					candidate.Events = append(candidate.Events, newCandidateEvent(models.CandidateEventNote, userID, "%s", text))
+					if err := store.Write(db.CandidatesKey, candidates); err != nil {
+						return err
+					}
+
+					return slackbot.WriteStringf(w, "Ok, I've added a note to *%s's* timeline", candidate.Name)
+				},
+			},
+			{
+				Name:  "export",
+				Usage: "upload candidates to the channel as a file",
+				Flags: []cli.Flag{
+					newExportFormatFlag(),
+					cli.IntFlag{
+						Name:  "limit",
+						Usage: fmt.Sprintf("The maximum number of candidates to export (default: the %s setting)", models.SettingListLimit),
+					},
+					cli.BoolFlag{
+						Name:  "ascending",
+						Usage: "Show results in reverse-alphabetical order",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					if err := auth.Authorize(store, userID, auth.ActionCandidateExport); err != nil {
+						return err
+					}
+
+					candidates := models.Candidates{}
+					if err := store.Read(db.CandidatesKey, &candidates); err != nil {
+						return err
+					}
+
+					if len(candidates) == 0 {
+						return slackbot.WriteString(w, "I don't have any candidates at the moment")
+					}
+
+					candidates.Sort(!c.Bool("ascending"))
+					limit, err := listLimit(store, c)
+					if err != nil {
+						return err
+					}
+
+					if limit >= 0 && limit < len(candidates) {
+						candidates = candidates[:limit]
+					}
+
+					content, err := renderCandidates(c.String("format"), candidates)
+					if err != nil {
+						return err
+					}
+
+					if err := uploadExport(client, msg.Channel, "candidates", c.String("format"), content); err != nil {
+						return err
+					}
+
+					return slackbot.WriteStringf(w, "Ok, I've exported %d candidate(s)", len(candidates))
+				},
+			},
 			{
 				Name:  "ls",
 				Usage: "list candidates",
 				Flags: []cli.Flag{
 					cli.IntFlag{
 						Name:  "limit",
-						Value: 50,
-						Usage: "The maximum number of candidates to display",
+						Usage: fmt.Sprintf("The maximum number of candidates to display (default: the %s setting)", models.SettingListLimit),
 					},
 					cli.BoolFlag{
 						Name:  "ascending",
@@ -98,8 +288,13 @@ func NewCandidateCommand(store db.Store, w io.Writer) cli.Command {
 
 					candidates.Sort(!c.Bool("ascending"))
 
+					limit, err := listLimit(store, c)
+					if err != nil {
+						return err
+					}
+
 					text := "Here are the candidates I have: \n"
-					for i := 0; i < c.Int("limit") && i < len(candidates); i++ {
+					for i := 0; i < limit && i < len(candidates); i++ {
 						text += fmt.Sprintf("*%s* (manager: %s)\n",
 							candidates[i].Name,
 							slackbot.EscapeUserID(candidates[i].ManagerID))
@@ -123,10 +318,17 @@ func NewCandidateCommand(store db.Store, w io.Writer) cli.Command {
 						return err
 					}
 
-					if ok := candidates.Delete(name); !ok {
+					candidate, ok := candidates.Get(name)
+					if !ok {
 						return candidateDoesNotExist(name)
 					}
 
+					if err := auth.Authorize(store, userID, auth.ActionCandidateRemove, candidate.ManagerID); err != nil {
+						return err
+					}
+
+					candidates.Delete(name)
+
 					if err := store.Write(db.CandidatesKey, candidates); err != nil {
 						return err
 					}
@@ -162,6 +364,63 @@ func NewCandidateCommand(store db.Store, w io.Writer) cli.Command {
 					return slackbot.WriteString(w, text)
 				},
 			},
+			{
+				Name:      "timeline",
+				Usage:     "show the notes, stage changes, interviews, and pipeline steps of a candidate",
+				ArgsUsage: "NAME",
+				Flags: []cli.Flag{
+					cli.IntFlag{
+						Name:  "page",
+						Value: 1,
+						Usage: "The page of the timeline to display",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					name := strings.Join(c.Args(), " ")
+					if name == "" {
+						return slackbot.NewUserInputError("Argument NAME is required")
+					}
+
+					candidates := models.Candidates{}
+					if err := store.Read(db.CandidatesKey, &candidates); err != nil {
+						return err
+					}
+
+					candidate, ok := candidates.Get(name)
+					if !ok {
+						return candidateDoesNotExist(name)
+					}
+
+					// interviews record their own events, since expired interviews are removed
+					events := append(models.CandidateEvents{}, candidate.Events...)
+					if len(events) == 0 {
+						return slackbot.WriteStringf(w, "I don't have anything on *%s's* timeline at the moment", candidate.Name)
+					}
+
+					events.Sort()
+					page := c.Int("page")
+					events, pages := events.Page(page, candidateTimelinePageSize)
+					if len(events) == 0 {
+						return slackbot.NewUserInputErrorf("Page must be between 1 and %d", pages)
+					}
+
+					text := fmt.Sprintf("Here is the timeline for *%s* (page %d of %d): \n", candidate.Name, page, pages)
+					for _, event := range events {
+						text += fmt.Sprintf("`%s` *%s* ", event.Time.Format(candidateEventTimeFormat), event.Type)
+						if event.UserID != "" {
+							text += fmt.Sprintf("%s: ", slackbot.EscapeUserID(event.UserID))
+						}
+
+						text += event.Text + "\n"
+					}
+
+					if page < pages {
+						text += fmt.Sprintf("Run `!candidate timeline --page %d \"%s\"` to see more", page+1, candidate.Name)
+					}
+
+					return slackbot.WriteString(w, text)
+				},
+			},
 			{
 				Name:      "update",
 				Usage:     "add/update a candidate's metadata",
@@ -202,6 +461,10 @@ func NewCandidateCommand(store db.Store, w io.Writer) cli.Command {
 						update = func(candidate *models.Candidate) {
 							candidate.Meta[key] = val
 							candidate.ManagerID = managerID
+							candidate.Events = append(candidate.Events, newCandidateEvent(
+								models.CandidateEventStage,
+								userID,
+								"Manager changed to %s", slackbot.EscapeUserID(managerID)))
 						}
 					}
 
@@ -215,6 +478,10 @@ func NewCandidateCommand(store db.Store, w io.Writer) cli.Command {
 						return candidateDoesNotExist(name)
 					}
 
+					if err := auth.Authorize(store, userID, auth.ActionCandidateUpdate, candidate.ManagerID); err != nil {
+						return err
+					}
+
 					update(candidate)
 					if err := store.Write(db.CandidatesKey, candidates); err != nil {
 						return err
@@ -227,6 +494,36 @@ func NewCandidateCommand(store db.Store, w io.Writer) cli.Command {
 	}
 }
 
+func formatCandidateImportPlan(plan candidateImportPlan) string {
+	names := func(candidates []*models.Candidate) string {
+		n := make([]string, len(candidates))
+		for i, candidate := range candidates {
+			n[i] = candidate.Name
+		}
+
+		return strings.Join(n, ", ")
+	}
+
+	updated := make([]*models.Candidate, len(plan.Updates))
+	for i, update := range plan.Updates {
+		updated[i] = update.Candidate
+	}
+
+	text := fmt.Sprintf("*Creates* (%d): %s\n", len(plan.Creates), names(plan.Creates))
+	text += fmt.Sprintf("*Updates* (%d): %s\n", len(plan.Updates), names(updated))
+	text += fmt.Sprintf("*Conflicts* (%d): \n", len(plan.Conflicts))
+	for _, conflict := range plan.Conflicts {
+		text += fmt.Sprintf("• %s\n", conflict)
+	}
+
+	text += fmt.Sprintf("*Errors* (%d): \n", len(plan.Errors))
+	for _, err := range plan.Errors {
+		text += fmt.Sprintf("• %s\n", err)
+	}
+
+	return text
+}
+
 func parseMetaFlag(inputs []string) (map[string]string, error) {
 	meta := map[string]string{}
 	for _, input := range inputs {
@@ -241,6 +538,17 @@ func parseMetaFlag(inputs []string) (map[string]string, error) {
 	return meta, nil
 }
 
+const candidateEventTimeFormat = "Jan 2, 2006 3:04 PM MST"
+
+func newCandidateEvent(eventType, userID, format string, tokens ...interface{}) *models.CandidateEvent {
+	return &models.CandidateEvent{
+		Type:   eventType,
+		Time:   time.Now().UTC(),
+		UserID: userID,
+		Text:   fmt.Sprintf(format, tokens...),
+	}
+}
+
 func candidateDoesNotExist(name string) *slackbot.UserInputError {
 	return slackbot.NewUserInputErrorf("I don't have any candidates by the name *%s*", name)
 }
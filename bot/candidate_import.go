@@ -0,0 +1,287 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/nlopes/slack"
+	"github.com/quintilesims/iqvbot/models"
+	"github.com/quintilesims/iqvbot/utils"
+	"github.com/zpatrick/slackbot"
+)
+
+// The maximum size of a file that can be imported
+const maxCandidateImportSize = 1 << 20
+
+var (
+	codeBlockRegex = regexp.MustCompile("(?s)```(.*?)```")
+	userIDRegex    = regexp.MustCompile(`^[UW][A-Z0-9]+$`)
+)
+
+// A candidateImportRow holds a single candidate parsed from an import
+type candidateImportRow struct {
+	Row     int
+	Name    string
+	Manager string
+	Meta    map[string]string
+	Error   string
+}
+
+// A candidateImportUpdate holds the changes an import makes to an existing candidate
+type candidateImportUpdate struct {
+	Candidate *models.Candidate
+	ManagerID string
+	Meta      map[string]string
+}
+
+// A candidateImportPlan holds the changes an import would make to the current candidates
+type candidateImportPlan struct {
+	Creates   []*models.Candidate
+	Updates   []candidateImportUpdate
+	Conflicts []string
+	Errors    []string
+}
+
+// readCandidateImport returns the contents of the first file shared with msg.
+// If no files were shared, the contents of the first code block in the message's text is returned.
+func readCandidateImport(client utils.SlackClient, msg slack.Msg) (string, error) {
+	if len(msg.Files) > 0 {
+		file := msg.Files[0]
+		if file.Size > maxCandidateImportSize {
+			return "", slackbot.NewUserInputErrorf("File *%s* is too large to import", file.Name)
+		}
+
+		w := bytes.NewBuffer(nil)
+		if err := client.GetFile(file.URLPrivateDownload, w); err != nil {
+			return "", err
+		}
+
+		return w.String(), nil
+	}
+
+	match := codeBlockRegex.FindStringSubmatch(msg.Text)
+	if match == nil {
+		return "", slackbot.NewUserInputError("Please paste the candidates in a ```code block``` or upload them as a file")
+	}
+
+	// slack escapes '&', '<', and '>' in message text
+	return html.UnescapeString(match[1]), nil
+}
+
+// parseCandidateImport parses candidates from text in CSV or JSON format.
+// CSV input must have a header row; the 'name' and 'manager' columns are mapped to
+// the candidate's name and manager, and all other columns are added as metadata.
+// JSON input must be an object or a list of objects using the same keys as the CSV header,
+// and may also contain a 'meta' object.
+func parseCandidateImport(text string) ([]candidateImportRow, error) {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "[") || strings.HasPrefix(text, "{") {
+		return parseCandidateImportJSON(text)
+	}
+
+	return parseCandidateImportCSV(text)
+}
+
+func parseCandidateImportCSV(text string) ([]candidateImportRow, error) {
+	r := csv.NewReader(strings.NewReader(text))
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, slackbot.NewUserInputErrorf("Failed to read CSV header: %v", err)
+	}
+
+	rows := []candidateImportRow{}
+	for i := 1; ; i++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			if e, ok := err.(*csv.ParseError); ok && e.Err == csv.ErrFieldCount {
+				rows = append(rows, candidateImportRow{Row: i, Error: fmt.Sprintf("expected %d columns, got %d", len(header), len(record))})
+				continue
+			}
+
+			return nil, slackbot.NewUserInputErrorf("Failed to read CSV: %v", err)
+		}
+
+		fields := map[string]interface{}{}
+		for j, column := range header {
+			fields[column] = record[j]
+		}
+
+		rows = append(rows, newCandidateImportRow(i, fields))
+	}
+
+	return rows, nil
+}
+
+func parseCandidateImportJSON(text string) ([]candidateImportRow, error) {
+	entries := []map[string]interface{}{}
+	if strings.HasPrefix(text, "{") {
+		text = fmt.Sprintf("[%s]", text)
+	}
+
+	if err := json.Unmarshal([]byte(text), &entries); err != nil {
+		return nil, slackbot.NewUserInputErrorf("Failed to read JSON: %v", err)
+	}
+
+	rows := make([]candidateImportRow, len(entries))
+	for i, entry := range entries {
+		rows[i] = newCandidateImportRow(i+1, entry)
+	}
+
+	return rows, nil
+}
+
+func newCandidateImportRow(index int, fields map[string]interface{}) candidateImportRow {
+	row := candidateImportRow{
+		Row:  index,
+		Meta: map[string]string{},
+	}
+
+	for key, val := range fields {
+		key = strings.TrimSpace(key)
+		switch strings.ToLower(key) {
+		case "name", "candidate":
+			row.Name = strings.TrimSpace(fmt.Sprint(val))
+		case "manager":
+			row.Manager = strings.TrimSpace(fmt.Sprint(val))
+		case "meta":
+			meta, ok := val.(map[string]interface{})
+			if !ok {
+				row.Error = "'meta' must be an object"
+				continue
+			}
+
+			for k, v := range meta {
+				row.Meta[k] = fmt.Sprint(v)
+			}
+		default:
+			if val == nil || fmt.Sprint(val) == "" {
+				continue
+			}
+
+			row.Meta[key] = fmt.Sprint(val)
+		}
+	}
+
+	return row
+}
+
+// planCandidateImport determines which of the rows create new candidates, and which update existing candidates.
+// Rows that would change an existing candidate's manager are conflicts unless overwrite is true.
+// The resolve function converts a row's manager into a slack user id.
+func planCandidateImport(
+	candidates models.Candidates,
+	rows []candidateImportRow,
+	overwrite bool,
+	resolve func(string) (string, error),
+) candidateImportPlan {
+	plan := candidateImportPlan{}
+	seen := map[string]bool{}
+	for _, row := range rows {
+		if row.Error != "" {
+			plan.Errors = append(plan.Errors, fmt.Sprintf("row %d: %s", row.Row, row.Error))
+			continue
+		}
+
+		if row.Name == "" {
+			plan.Errors = append(plan.Errors, fmt.Sprintf("row %d: name is required", row.Row))
+			continue
+		}
+
+		if seen[strings.ToLower(row.Name)] {
+			plan.Errors = append(plan.Errors, fmt.Sprintf("row %d: *%s* appears more than once", row.Row, row.Name))
+			continue
+		}
+
+		seen[strings.ToLower(row.Name)] = true
+
+		var managerID string
+		if row.Manager != "" {
+			id, err := resolve(row.Manager)
+			if err != nil {
+				plan.Errors = append(plan.Errors, fmt.Sprintf("row %d: %v", row.Row, err))
+				continue
+			}
+
+			managerID = id
+		}
+
+		candidate, ok := candidates.Get(row.Name)
+		if !ok {
+			if managerID == "" {
+				plan.Errors = append(plan.Errors, fmt.Sprintf("row %d: manager is required for new candidate *%s*", row.Row, row.Name))
+				continue
+			}
+
+			plan.Creates = append(plan.Creates, &models.Candidate{
+				Name:      row.Name,
+				ManagerID: managerID,
+				Meta:      row.Meta,
+			})
+
+			continue
+		}
+
+		if managerID != "" && managerID != candidate.ManagerID && !overwrite {
+			text := fmt.Sprintf("row %d: *%s* is managed by %s, not %s",
+				row.Row,
+				candidate.Name,
+				slackbot.EscapeUserID(candidate.ManagerID),
+				slackbot.EscapeUserID(managerID))
+			plan.Conflicts = append(plan.Conflicts, text)
+			continue
+		}
+
+		plan.Updates = append(plan.Updates, candidateImportUpdate{
+			Candidate: candidate,
+			ManagerID: managerID,
+			Meta:      row.Meta,
+		})
+	}
+
+	return plan
+}
+
+// newSlackUserResolver returns a function that converts '<@USERID>', 'USERID', or '@username' into a slack user id.
+// Usernames are looked up lazily, since code blocks and files do not contain escaped user ids.
+func newSlackUserResolver(client utils.SlackClient) func(string) (string, error) {
+	var users []slack.User
+	return func(input string) (string, error) {
+		if strings.HasPrefix(input, "<@") {
+			return slackbot.ParseUserID(input)
+		}
+
+		if userIDRegex.MatchString(input) {
+			return input, nil
+		}
+
+		if users == nil {
+			u, err := client.GetUsers()
+			if err != nil {
+				return "", err
+			}
+
+			users = u
+		}
+
+		name := strings.TrimPrefix(input, "@")
+		for _, user := range users {
+			if strings.EqualFold(user.Name, name) || strings.EqualFold(user.Profile.DisplayName, name) {
+				return user.ID, nil
+			}
+		}
+
+		return "", fmt.Errorf("could not find a user named '%s'", input)
+	}
+}
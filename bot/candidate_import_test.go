@@ -0,0 +1,204 @@
+package bot
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/nlopes/slack"
+	"github.com/quintilesims/iqvbot/mock"
+	"github.com/quintilesims/iqvbot/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCandidateImportCSV(t *testing.T) {
+	input := `
+Name, Manager, Team, Start Date
+John Doe, <@uid1>, platform, 2026-11-01
+"O'Brien, Jane", @jane.manager, , 2026-12-01
+Too, Few
+`
+
+	rows, err := parseCandidateImport(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []candidateImportRow{
+		{
+			Row:     1,
+			Name:    "John Doe",
+			Manager: "<@uid1>",
+			Meta:    map[string]string{"Team": "platform", "Start Date": "2026-11-01"},
+		},
+		{
+			Row:     2,
+			Name:    "O'Brien, Jane",
+			Manager: "@jane.manager",
+			Meta:    map[string]string{"Start Date": "2026-12-01"},
+		},
+		{
+			Row:   3,
+			Error: "expected 4 columns, got 2",
+		},
+	}
+
+	assert.Equal(t, expected, rows)
+}
+
+func TestParseCandidateImportJSON(t *testing.T) {
+	input := `[
+		{"name": "John Doe", "manager": "<@uid1>", "team": "platform", "level": 2},
+		{"name": "Jane Doe", "meta": {"team": "web"}},
+		{"name": "Bad Meta", "meta": "team=web"}
+	]`
+
+	rows, err := parseCandidateImport(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []candidateImportRow{
+		{
+			Row:     1,
+			Name:    "John Doe",
+			Manager: "<@uid1>",
+			Meta:    map[string]string{"team": "platform", "level": "2"},
+		},
+		{
+			Row:  2,
+			Name: "Jane Doe",
+			Meta: map[string]string{"team": "web"},
+		},
+		{
+			Row:   3,
+			Name:  "Bad Meta",
+			Meta:  map[string]string{},
+			Error: "'meta' must be an object",
+		},
+	}
+
+	assert.Equal(t, expected, rows)
+}
+
+func TestParseCandidateImportErrors(t *testing.T) {
+	inputs := []string{
+		"",
+		"[{\"name\": ",
+		"name,manager\n\"unterminated,<@uid>",
+	}
+
+	for _, input := range inputs {
+		if _, err := parseCandidateImport(input); err == nil {
+			t.Errorf("%s: error was nil!", input)
+		}
+	}
+}
+
+func TestPlanCandidateImport(t *testing.T) {
+	candidates := models.Candidates{
+		{Name: "John Doe", ManagerID: "uid1", Meta: map[string]string{}},
+		{Name: "Jane Doe", ManagerID: "uid1", Meta: map[string]string{}},
+	}
+
+	rows := []candidateImportRow{
+		{Row: 1, Name: "New Person", Manager: "uid2"},
+		{Row: 2, Name: "john doe", Meta: map[string]string{"team": "web"}},
+		{Row: 3, Name: "Jane Doe", Manager: "uid2"},
+		{Row: 4, Name: "No Manager"},
+		{Row: 5, Name: "Bad Manager", Manager: "bad"},
+		{Row: 6, Manager: "uid1"},
+		{Row: 7, Name: "new person", Manager: "uid2"},
+		{Row: 8, Error: "some error"},
+	}
+
+	resolve := func(input string) (string, error) {
+		if input == "bad" {
+			return "", fmt.Errorf("could not find a user named '%s'", input)
+		}
+
+		return input, nil
+	}
+
+	plan := planCandidateImport(candidates, rows, false, resolve)
+	assert.Equal(t, []*models.Candidate{{Name: "New Person", ManagerID: "uid2"}}, plan.Creates)
+	assert.Equal(t, []candidateImportUpdate{{Candidate: candidates[0], Meta: map[string]string{"team": "web"}}}, plan.Updates)
+	assert.Len(t, plan.Conflicts, 1)
+	assert.Contains(t, plan.Conflicts[0], "row 3")
+	assert.Len(t, plan.Errors, 5)
+
+	plan = planCandidateImport(candidates, rows[2:3], true, resolve)
+	assert.Len(t, plan.Conflicts, 0)
+	assert.Equal(t, []candidateImportUpdate{{Candidate: candidates[1], ManagerID: "uid2"}}, plan.Updates)
+}
+
+func TestReadCandidateImport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockSlackClient := mock.NewMockSlackClient(ctrl)
+
+	mockSlackClient.EXPECT().
+		GetFile("https://files.slack.com/candidates.csv", gomock.Any()).
+		Do(func(url string, w io.Writer) {
+			io.WriteString(w, "name,manager")
+		}).
+		Return(nil)
+
+	msg := slack.Msg{Files: []slack.File{{URLPrivateDownload: "https://files.slack.com/candidates.csv"}}}
+	result, err := readCandidateImport(mockSlackClient, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "name,manager", result)
+
+	msg = slack.Msg{Text: "iqvbot candidate import ```name,manager\nA &amp; B,&lt;@uid&gt;```"}
+	result, err = readCandidateImport(mockSlackClient, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "name,manager\nA & B,<@uid>", result)
+
+	if _, err := readCandidateImport(mockSlackClient, slack.Msg{Text: "iqvbot candidate import"}); err == nil {
+		t.Fatal("Error was nil!")
+	}
+}
+
+func TestSlackUserResolver(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockSlackClient := mock.NewMockSlackClient(ctrl)
+
+	users := []slack.User{
+		{ID: "U1", Name: "jane"},
+		{ID: "U2", Name: "bob", Profile: slack.UserProfile{DisplayName: "Bobby"}},
+	}
+
+	// users should only be listed once
+	mockSlackClient.EXPECT().
+		GetUsers().
+		Return(users, nil)
+
+	resolve := newSlackUserResolver(mockSlackClient)
+	cases := map[string]string{
+		"U123ABC": "U123ABC",
+		"@jane":   "U1",
+		"@bobby":  "U2",
+		"bob":     "U2",
+	}
+
+	for input, expected := range cases {
+		result, err := resolve(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, expected, result)
+	}
+
+	if _, err := resolve("@nobody"); err == nil {
+		t.Fatal("Error was nil!")
+	}
+}
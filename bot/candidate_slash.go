@@ -0,0 +1,376 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nlopes/slack"
+	"github.com/quintilesims/iqvbot/auth"
+	"github.com/quintilesims/iqvbot/db"
+	"github.com/quintilesims/iqvbot/models"
+	"github.com/quintilesims/iqvbot/slash"
+	"github.com/zpatrick/slackbot"
+)
+
+// action names of the buttons and menus in /candidate views
+const (
+	ActionCandidateFilter  = "candidate_filter"
+	ActionCandidateShow    = "candidate_show"
+	ActionCandidateBack    = "candidate_back"
+	ActionCandidateManager = "candidate_manager"
+	ActionCandidateHire    = "candidate_hire"
+	ActionCandidateRemove  = "candidate_remove"
+)
+
+// CallbackCandidateList is the callback id of the attachments in the /candidate list view.
+// Detail cards use the candidate's name, prefixed with CallbackCandidateDetailPrefix,
+// since the manager menu can't carry the name in its value.
+const (
+	CallbackCandidateList         = "candidate_list"
+	CallbackCandidateDetailPrefix = "candidate:"
+)
+
+// CandidateSlashCommand manages candidates with the /candidate slash command.
+// It uses the same store data as the !candidate command.
+type CandidateSlashCommand struct {
+	store db.Store
+}
+
+func NewCandidateSlashCommand(store db.Store) *CandidateSlashCommand {
+	return &CandidateSlashCommand{
+		store: store,
+	}
+}
+
+func (cmd *CandidateSlashCommand) Schema() *slash.CommandSchema {
+	root := &slash.Command{
+		Name:  "/candidate",
+		Usage: "view candidates, or view/manage a single candidate",
+		Args: []slash.Arg{
+			{Name: "NAME", Usage: "The name of the candidate to view", Optional: true, Variadic: true},
+		},
+		Action: func(c *slash.Context) (*slack.Message, error) {
+			if !c.IsSet("NAME") {
+				return cmd.list("")
+			}
+
+			return cmd.show(c.String("NAME"))
+		},
+	}
+
+	schema := root.Schema()
+	schema.Callback = cmd.callback
+	return schema
+}
+
+func (cmd *CandidateSlashCommand) callback(req slack.AttachmentActionCallback) (*slack.Message, error) {
+	action := req.Actions[0]
+	name := strings.TrimPrefix(req.CallbackID, CallbackCandidateDetailPrefix)
+
+	switch action.Name {
+	case ActionCandidateFilter:
+		return cmd.list(attachmentActionValue(action))
+	case ActionCandidateShow:
+		return cmd.show(action.Value)
+	case ActionCandidateBack:
+		return cmd.list("")
+	case ActionCandidateManager:
+		return cmd.changeManager(req.User.ID, name, attachmentActionValue(action))
+	case ActionCandidateHire:
+		if _, err := startHiringPipeline(cmd.store, req.User.ID, name); err != nil {
+			return nil, slashCommandError(err)
+		}
+
+		return cmd.show(name)
+	case ActionCandidateRemove:
+		return cmd.remove(req.User.ID, name)
+	default:
+		return nil, fmt.Errorf("Unexpected callback action name '%s'", action.Name)
+	}
+}
+
+// list displays the candidates, only including candidates managed by managerID if it is set
+func (cmd *CandidateSlashCommand) list(managerID string) (*slack.Message, error) {
+	candidates := models.Candidates{}
+	if err := cmd.store.Read(db.CandidatesKey, &candidates); err != nil {
+		return nil, err
+	}
+
+	limit, err := readListLimit(cmd.store)
+	if err != nil {
+		return nil, err
+	}
+
+	return newCandidateListView(candidates, managerID, limit), nil
+}
+
+// show displays the detail card of a candidate
+func (cmd *CandidateSlashCommand) show(name string) (*slack.Message, error) {
+	candidates := models.Candidates{}
+	if err := cmd.store.Read(db.CandidatesKey, &candidates); err != nil {
+		return nil, err
+	}
+
+	candidate, ok := candidates.Get(name)
+	if !ok {
+		return nil, slashCommandError(candidateDoesNotExist(name))
+	}
+
+	pipelines := models.Pipelines{}
+	if err := cmd.store.Read(db.PipelinesKey, &pipelines); err != nil {
+		return nil, err
+	}
+
+	pipelines.FilterByType(models.HiringPipelineType)
+	pipeline, _ := pipelines.Get(candidate.Name)
+	return newCandidateDetailView(candidate, pipeline), nil
+}
+
+func (cmd *CandidateSlashCommand) changeManager(userID, name, managerID string) (*slack.Message, error) {
+	candidates := models.Candidates{}
+	if err := cmd.store.Read(db.CandidatesKey, &candidates); err != nil {
+		return nil, err
+	}
+
+	candidate, ok := candidates.Get(name)
+	if !ok {
+		return nil, slashCommandError(candidateDoesNotExist(name))
+	}
+
+	if err := auth.Authorize(cmd.store, userID, auth.ActionCandidateUpdate, candidate.ManagerID); err != nil {
+		return nil, slashCommandError(err)
+	}
+
+	if managerID != candidate.ManagerID {
+		candidate.ManagerID = managerID
+		candidate.Events = append(candidate.Events, newCandidateEvent(
+			models.CandidateEventStage,
+			userID,
+			"Manager changed to %s", slackbot.EscapeUserID(managerID)))
+
+		if err := cmd.store.Write(db.CandidatesKey, candidates); err != nil {
+			return nil, err
+		}
+	}
+
+	return cmd.show(name)
+}
+
+func (cmd *CandidateSlashCommand) remove(userID, name string) (*slack.Message, error) {
+	candidates := models.Candidates{}
+	if err := cmd.store.Read(db.CandidatesKey, &candidates); err != nil {
+		return nil, err
+	}
+
+	candidate, ok := candidates.Get(name)
+	if !ok {
+		return nil, slashCommandError(candidateDoesNotExist(name))
+	}
+
+	if err := auth.Authorize(cmd.store, userID, auth.ActionCandidateRemove, candidate.ManagerID); err != nil {
+		return nil, slashCommandError(err)
+	}
+
+	candidates.Delete(name)
+	if err := cmd.store.Write(db.CandidatesKey, candidates); err != nil {
+		return nil, err
+	}
+
+	msg, err := cmd.list("")
+	if err != nil {
+		return nil, err
+	}
+
+	msg.Text = fmt.Sprintf("Ok, I've deleted candidate *%s*\n%s", candidate.Name, msg.Text)
+	return msg, nil
+}
+
+// newCandidateListView renders a filter menu followed by a card for each candidate.
+// If managerID is set, only candidates managed by that user are shown.
+func newCandidateListView(candidates models.Candidates, managerID string, limit int) *slack.Message {
+	filter := slack.AttachmentAction{
+		Name:       ActionCandidateFilter,
+		Text:       "Filter by manager",
+		Type:       "select",
+		DataSource: "users",
+	}
+
+	text := "Here are the candidates I'm currently tracking: "
+	if managerID != "" {
+		filter.SelectedOptions = []slack.AttachmentActionOption{
+			{Text: slackbot.EscapeUserID(managerID), Value: managerID},
+		}
+
+		text = fmt.Sprintf("Here are the candidates managed by %s: ", slackbot.EscapeUserID(managerID))
+	}
+
+	matches := models.Candidates{}
+	for _, candidate := range candidates {
+		if managerID == "" || candidate.ManagerID == managerID {
+			matches = append(matches, candidate)
+		}
+	}
+
+	matches.Sort(true)
+	if len(matches) == 0 {
+		text = "There aren't any candidates at the moment"
+		if managerID != "" {
+			text = fmt.Sprintf("%s doesn't manage any candidates at the moment", slackbot.EscapeUserID(managerID))
+		}
+	}
+
+	header := slack.Attachment{
+		Fallback:   "You are currently unable to filter candidates. Please try again later.",
+		CallbackID: CallbackCandidateList,
+		Actions: []slack.AttachmentAction{
+			filter,
+			{
+				Name: ActionCandidateFilter,
+				Text: "Show all",
+				Type: "button",
+			},
+		},
+	}
+
+	if len(matches) > limit {
+		header.Footer = fmt.Sprintf("Showing %d of %d candidates", limit, len(matches))
+		matches = matches[:limit]
+	}
+
+	attachments := []slack.Attachment{header}
+	for _, candidate := range matches {
+		attachments = append(attachments, slack.Attachment{
+			Title:      candidate.Name,
+			Fallback:   "You are currently unable to view this candidate. Please try again later.",
+			CallbackID: CallbackCandidateList,
+			Fields: []slack.AttachmentField{
+				{
+					Title: "Manager",
+					Value: slackbot.EscapeUserID(candidate.ManagerID),
+					Short: true,
+				},
+				{
+					Title: "Stage",
+					Value: candidateStage(candidate),
+					Short: true,
+				},
+			},
+			Actions: []slack.AttachmentAction{
+				{
+					Name:  ActionCandidateShow,
+					Text:  "View",
+					Type:  "button",
+					Value: candidate.Name,
+				},
+			},
+		})
+	}
+
+	return &slack.Message{Msg: slack.Msg{Text: text, Attachments: attachments}}
+}
+
+// newCandidateDetailView renders a card with the candidate's information and buttons to edit it.
+// The pipeline is the candidate's hiring pipeline, or nil if the candidate isn't in one.
+func newCandidateDetailView(candidate *models.Candidate, pipeline *models.Pipeline) *slack.Message {
+	hiring := "Not started"
+	if pipeline != nil {
+		hiring = formatPipelineProgress(pipeline)
+	}
+
+	fields := []slack.AttachmentField{
+		{
+			Title: "Manager",
+			Value: slackbot.EscapeUserID(candidate.ManagerID),
+			Short: true,
+		},
+		{
+			Title: "Stage",
+			Value: candidateStage(candidate),
+			Short: true,
+		},
+		{
+			Title: "Hiring pipeline",
+			Value: hiring,
+		},
+	}
+
+	keys := make([]string, 0, len(candidate.Meta))
+	for key := range candidate.Meta {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		fields = append(fields, slack.AttachmentField{
+			Title: key,
+			Value: candidate.Meta[key],
+			Short: true,
+		})
+	}
+
+	actions := []slack.AttachmentAction{
+		{
+			Name:       ActionCandidateManager,
+			Text:       "Change manager",
+			Type:       "select",
+			DataSource: "users",
+		},
+	}
+
+	footer := fmt.Sprintf("Use `/hire %s` to manage the hiring pipeline", candidate.Name)
+	if pipeline == nil {
+		footer = ""
+		actions = append(actions, slack.AttachmentAction{
+			Name:  ActionCandidateHire,
+			Text:  "Start hiring pipeline",
+			Type:  "button",
+			Style: "primary",
+		})
+	}
+
+	actions = append(actions,
+		slack.AttachmentAction{
+			Name:  ActionCandidateRemove,
+			Text:  "Remove",
+			Type:  "button",
+			Style: "danger",
+			Confirm: &slack.ConfirmationField{
+				Title: "Are you sure?",
+				Text:  fmt.Sprintf("%s will be removed as a candidate.", candidate.Name),
+			},
+		},
+		slack.AttachmentAction{
+			Name: ActionCandidateBack,
+			Text: "Back",
+			Type: "button",
+		})
+
+	attachment := slack.Attachment{
+		Title:      candidate.Name,
+		Fallback:   "You are currently unable to view this candidate. Please try again later.",
+		Color:      "good",
+		CallbackID: CallbackCandidateDetailPrefix + candidate.Name,
+		Fields:     fields,
+		Actions:    actions,
+		Footer:     footer,
+	}
+
+	return &slack.Message{Msg: slack.Msg{Attachments: []slack.Attachment{attachment}}}
+}
+
+// candidateStage returns the text of the candidate's most recent stage event
+func candidateStage(candidate *models.Candidate) string {
+	var latest *models.CandidateEvent
+	for _, event := range candidate.Events {
+		if event.Type == models.CandidateEventStage && (latest == nil || !event.Time.Before(latest.Time)) {
+			latest = event
+		}
+	}
+
+	if latest == nil {
+		return "Unknown"
+	}
+
+	return latest.Text
+}
@@ -0,0 +1,140 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/quintilesims/iqvbot/db"
+	"github.com/quintilesims/iqvbot/models"
+	"github.com/quintilesims/iqvbot/slash"
+	"github.com/stretchr/testify/assert"
+)
+
+func attachmentTitles(msg *slack.Message) []string {
+	titles := []string{}
+	for _, attachment := range msg.Attachments {
+		if attachment.Title != "" {
+			titles = append(titles, attachment.Title)
+		}
+	}
+
+	return titles
+}
+
+func newCandidateSlashCallback(userID, callbackID string, action slack.AttachmentAction) slack.AttachmentActionCallback {
+	return slack.AttachmentActionCallback{
+		CallbackID: callbackID,
+		User:       slack.User{ID: userID},
+		Actions:    []slack.AttachmentAction{action},
+	}
+}
+
+func TestCandidateListView(t *testing.T) {
+	_, candidates := newHireStatusTestData(time.Now())
+
+	msg := newCandidateListView(candidates, "", 50)
+	assert.Equal(t, []string{"Alice", "Bob", "Carol", "Dave"}, attachmentTitles(msg))
+	assert.Equal(t, ActionCandidateFilter, msg.Attachments[0].Actions[0].Name)
+
+	msg = newCandidateListView(candidates, "m1", 2)
+	assert.Equal(t, []string{"Alice", "Carol"}, attachmentTitles(msg))
+	assert.Equal(t, "m1", msg.Attachments[0].Actions[0].SelectedOptions[0].Value)
+	assert.Equal(t, "Showing 2 of 3 candidates", msg.Attachments[0].Footer)
+
+	msg = newCandidateListView(candidates, "m3", 50)
+	assert.Equal(t, "<@m3> doesn't manage any candidates at the moment", msg.Text)
+}
+
+func TestCandidateDetailView(t *testing.T) {
+	candidate := &models.Candidate{
+		Name:      "Alice",
+		ManagerID: "m1",
+		Meta:      map[string]string{"role": "engineer", "email": "alice@example.com"},
+		Events: models.CandidateEvents{
+			newCandidateEvent(models.CandidateEventStage, "m1", "Added as a candidate"),
+			newCandidateEvent(models.CandidateEventNote, "m1", "Great interview"),
+		},
+	}
+
+	msg := newCandidateDetailView(candidate, nil)
+	attachment := msg.Attachments[0]
+	assert.Equal(t, CallbackCandidateDetailPrefix+"Alice", attachment.CallbackID)
+
+	fields := map[string]string{}
+	for _, field := range attachment.Fields {
+		fields[field.Title] = field.Value
+	}
+
+	expected := map[string]string{
+		"Manager":         "<@m1>",
+		"Stage":           "Added as a candidate",
+		"Hiring pipeline": "Not started",
+		"email":           "alice@example.com",
+		"role":            "engineer",
+	}
+
+	assert.Equal(t, expected, fields)
+	assert.Equal(t, "email", attachment.Fields[3].Title)
+	assert.Equal(t, ActionCandidateHire, attachment.Actions[1].Name)
+
+	pipeline := &models.Pipeline{Name: "alice", Steps: []string{"a", "b"}, CurrentStep: 1}
+	msg = newCandidateDetailView(candidate, pipeline)
+	assert.Equal(t, "Step 2 of 2 (50% complete): `b`", msg.Attachments[0].Fields[2].Value)
+	for _, action := range msg.Attachments[0].Actions {
+		assert.NotEqual(t, ActionCandidateHire, action.Name)
+	}
+}
+
+func TestCandidateSlashCommandCallback(t *testing.T) {
+	store := newMemoryStore(t)
+	_, candidates := newHireStatusTestData(time.Now())
+	if err := store.Write(db.CandidatesKey, candidates); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := NewCandidateSlashCommand(store)
+
+	msg, err := cmd.callback(newCandidateSlashCallback("m1", CallbackCandidateList, slack.AttachmentAction{
+		Name:            ActionCandidateFilter,
+		SelectedOptions: []slack.AttachmentActionOption{{Value: "m2"}},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{"Bob"}, attachmentTitles(msg))
+
+	msg, err = cmd.callback(newCandidateSlashCallback("m1", CallbackCandidateList, slack.AttachmentAction{
+		Name:  ActionCandidateShow,
+		Value: "Alice",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	callbackID := msg.Attachments[0].CallbackID
+	msg, err = cmd.callback(newCandidateSlashCallback("m1", callbackID, slack.AttachmentAction{
+		Name:            ActionCandidateManager,
+		SelectedOptions: []slack.AttachmentActionOption{{Value: "m2"}},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "<@m2>", msg.Attachments[0].Fields[0].Value)
+	assert.Equal(t, "Manager changed to <@m2>", msg.Attachments[0].Fields[1].Value)
+
+	// m1 no longer manages alice
+	_, err = cmd.callback(newCandidateSlashCallback("m1", callbackID, slack.AttachmentAction{Name: ActionCandidateRemove}))
+	if _, ok := err.(*slash.SlackMessageError); !ok {
+		t.Fatalf("Error was not SlackMessageError: %#v", err)
+	}
+
+	msg, err = cmd.callback(newCandidateSlashCallback("m2", callbackID, slack.AttachmentAction{Name: ActionCandidateRemove}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{"Bob", "Carol", "Dave"}, attachmentTitles(msg))
+}
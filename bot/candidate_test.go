@@ -1,43 +1,69 @@
 package bot
 
-// todo: update tests
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
 
-/*
-func TestCandidateAdd(t *testing.T) {
+	"github.com/nlopes/slack"
+	"github.com/quintilesims/iqvbot/auth"
+	"github.com/quintilesims/iqvbot/db"
+	"github.com/quintilesims/iqvbot/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/zpatrick/slackbot"
+)
+
+func newCandidateTestStore(t *testing.T, roles models.Roles, candidates models.Candidates) *db.MemoryStore {
 	store := newMemoryStore(t)
-	cmd := NewCandidateCommand(store, ioutil.Discard)
-	if err := slackbot.NewTestApp(cmd, "!candidate add --meta k1=v1 --meta k2=v2 \"John Doe\" <@uid>"); err != nil {
+	if err := store.Write(db.RolesKey, roles); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Write(db.CandidatesKey, candidates); err != nil {
 		t.Fatal(err)
 	}
+
+	return store
+}
+
+func TestCandidateAdd(t *testing.T) {
+	store := newCandidateTestStore(t, models.Roles{"uid": {models.RoleRecruiter}}, models.Candidates{})
+	cmd := NewCandidateCommand(store, nil, slack.Msg{User: "uid"}, ioutil.Discard)
+	if err := slackbot.NewTestApp(cmd, "!candidate add --meta k1=v1 --meta k2=v2 \"John Doe\" <@manager>"); err != nil {
+		t.Fatal(err)
+	}
+
 	result := models.Candidates{}
 	if err := store.Read(db.CandidatesKey, &result); err != nil {
 		t.Fatal(err)
 	}
-	expected := models.Candidates{
-		{
-			Name:      "John Doe",
-			ManagerID: "uid",
-			Meta:      map[string]string{"k1": "v1", "k2": "v2"},
-		},
+
+	if assert.Len(t, result, 1) {
+		assert.Equal(t, "John Doe", result[0].Name)
+		assert.Equal(t, "manager", result[0].ManagerID)
+		assert.Equal(t, map[string]string{"k1": "v1", "k2": "v2"}, result[0].Meta)
+		if assert.Len(t, result[0].Events, 1) {
+			assert.Equal(t, models.CandidateEventStage, result[0].Events[0].Type)
+			assert.Equal(t, "uid", result[0].Events[0].UserID)
+		}
 	}
-	assert.Equal(t, expected, result)
 }
+
 func TestCandidateAddErrors(t *testing.T) {
-	store := newMemoryStore(t)
-	if err := store.Write(db.CandidatesKey, models.Candidates{{Name: "John"}}); err != nil {
-		t.Fatal(err)
-	}
+	store := newCandidateTestStore(t, models.Roles{"uid": {models.RoleRecruiter}}, models.Candidates{{Name: "John"}})
 	inputs := []string{
 		"!candidate add",
 		"!candidate add NAME",
 		"!candidate add NAME MANAGER",
 		"!candidate add NAME @MANAGER",
-		"!candidate add --meta NAME <@MANAGER>",
 		"!candidate add --meta key NAME <@MANAGER>",
-		"!candidate add --meta key:val NAME",
+		"!candidate add --meta key:val NAME <@MANAGER>",
 		"!candidate add John <@MANAGER>",
 	}
-	cmd := NewCandidateCommand(store, ioutil.Discard)
+
+	cmd := NewCandidateCommand(store, nil, slack.Msg{User: "uid"}, ioutil.Discard)
 	for _, input := range inputs {
 		t.Run(input, func(t *testing.T) {
 			if err := slackbot.NewTestApp(cmd, input); err == nil {
@@ -46,59 +72,144 @@ func TestCandidateAddErrors(t *testing.T) {
 		})
 	}
 }
-// todo: test --count and --ascending flag
+
+func TestCandidateAddPermissionDenied(t *testing.T) {
+	store := newCandidateTestStore(t, models.Roles{"uid": {models.RoleInterviewer}}, models.Candidates{})
+	cmd := NewCandidateCommand(store, nil, slack.Msg{User: "uid"}, ioutil.Discard)
+	err := slackbot.NewTestApp(cmd, "!candidate add \"John Doe\" <@manager>")
+	if _, ok := err.(*auth.PermissionDeniedError); !ok {
+		t.Fatalf("Error was not PermissionDeniedError: %#v", err)
+	}
+}
+
 func TestCandidateList(t *testing.T) {
 	candidates := models.Candidates{
 		{Name: "John Doe"},
 		{Name: "Jane Doe"},
 	}
-	store := newMemoryStore(t)
-	if err := store.Write(db.CandidatesKey, candidates); err != nil {
-		t.Fatal(err)
-	}
+
+	store := newCandidateTestStore(t, models.Roles{}, candidates)
 	w := bytes.NewBuffer(nil)
-	cmd := NewCandidateCommand(store, w)
+	cmd := NewCandidateCommand(store, nil, slack.Msg{User: "uid"}, w)
 	if err := slackbot.NewTestApp(cmd, "!candidate ls"); err != nil {
 		t.Fatal(err)
 	}
+
 	for _, candidate := range candidates {
 		assert.Contains(t, w.String(), candidate.Name)
 	}
 }
-func TestCandidateListErrors(t *testing.T) {
-	cmd := NewCandidateCommand(newMemoryStore(t), ioutil.Discard)
-	if err := slackbot.NewTestApp(cmd, "!candidate ls"); err == nil {
-		t.Fatal("Error was nil!")
+
+func TestCandidateListEmpty(t *testing.T) {
+	w := bytes.NewBuffer(nil)
+	cmd := NewCandidateCommand(newMemoryStore(t), nil, slack.Msg{User: "uid"}, w)
+	if err := slackbot.NewTestApp(cmd, "!candidate ls"); err != nil {
+		t.Fatal(err)
 	}
+
+	assert.Equal(t, "I don't have any candidates at the moment", w.String())
 }
-func TestCandidateRemove(t *testing.T) {
-	candidates := models.Candidates{
-		{Name: "John Doe"},
-		{Name: "Jane Doe"},
+
+func TestCandidateNote(t *testing.T) {
+	cases := map[string]models.Roles{
+		"manager":     {},
+		"interviewer": {"uid": {models.RoleInterviewer}},
+		"recruiter":   {"uid": {models.RoleRecruiter}},
 	}
-	store := newMemoryStore(t)
-	if err := store.Write(db.CandidatesKey, candidates); err != nil {
+
+	for name, roles := range cases {
+		t.Run(name, func(t *testing.T) {
+			managerID := "manager"
+			if name == "manager" {
+				managerID = "uid"
+			}
+
+			store := newCandidateTestStore(t, roles, models.Candidates{{Name: "John Doe", ManagerID: managerID}})
+			cmd := NewCandidateCommand(store, nil, slack.Msg{User: "uid"}, ioutil.Discard)
+			if err := slackbot.NewTestApp(cmd, "!candidate note \"john doe\" great phone screen"); err != nil {
+				t.Fatal(err)
+			}
+
+			result := models.Candidates{}
+			if err := store.Read(db.CandidatesKey, &result); err != nil {
+				t.Fatal(err)
+			}
+
+			if assert.Len(t, result[0].Events, 1) {
+				assert.Equal(t, models.CandidateEventNote, result[0].Events[0].Type)
+				assert.Equal(t, "uid", result[0].Events[0].UserID)
+				assert.Equal(t, "great phone screen", result[0].Events[0].Text)
+			}
+		})
+	}
+}
+
+func TestCandidateNoteErrors(t *testing.T) {
+	store := newCandidateTestStore(t, models.Roles{"uid": {models.RoleRecruiter}}, models.Candidates{{Name: "John Doe"}})
+	inputs := []string{
+		"!candidate note",
+		"!candidate note \"John Doe\"",
+		"!candidate note \"Jane Doe\" text",
+	}
+
+	cmd := NewCandidateCommand(store, nil, slack.Msg{User: "uid"}, ioutil.Discard)
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			if err := slackbot.NewTestApp(cmd, input); err == nil {
+				t.Fatal("Error was nil!")
+			}
+		})
+	}
+}
+
+func TestCandidateNotePermissionDenied(t *testing.T) {
+	roles := models.Roles{"uid": {models.RoleHiringManager}}
+	store := newCandidateTestStore(t, roles, models.Candidates{{Name: "John Doe", ManagerID: "manager"}})
+	cmd := NewCandidateCommand(store, nil, slack.Msg{User: "uid"}, ioutil.Discard)
+	err := slackbot.NewTestApp(cmd, "!candidate note \"John Doe\" text")
+	if _, ok := err.(*auth.PermissionDeniedError); !ok {
+		t.Fatalf("Error was not PermissionDeniedError: %#v", err)
+	}
+
+	result := models.Candidates{}
+	if err := store.Read(db.CandidatesKey, &result); err != nil {
 		t.Fatal(err)
 	}
-	cmd := NewCandidateCommand(store, ioutil.Discard)
+
+	assert.Len(t, result[0].Events, 0)
+}
+
+func TestCandidateRemove(t *testing.T) {
+	candidates := models.Candidates{
+		{Name: "John Doe", ManagerID: "uid"},
+		{Name: "Jane Doe", ManagerID: "uid"},
+	}
+
+	store := newCandidateTestStore(t, models.Roles{}, candidates)
+	cmd := NewCandidateCommand(store, nil, slack.Msg{User: "uid"}, ioutil.Discard)
 	if err := slackbot.NewTestApp(cmd, "!candidate rm John Doe"); err != nil {
 		t.Fatal(err)
 	}
-	resultCandidates := models.Candidates{}
-	if err := store.Read(db.CandidatesKey, &resultCandidates); err != nil {
+
+	result := models.Candidates{}
+	if err := store.Read(db.CandidatesKey, &result); err != nil {
 		t.Fatal(err)
 	}
-	expectedCandidates := models.Candidates{
-		{Name: "Jane Doe"},
+
+	expected := models.Candidates{
+		{Name: "Jane Doe", ManagerID: "uid"},
 	}
-	assert.Equal(t, expectedCandidates, resultCandidates)
+
+	assert.Equal(t, expected, result)
 }
+
 func TestCandidateRemoveErrors(t *testing.T) {
 	inputs := []string{
 		"!candidate rm",
 		"!candidate rm John Doe",
 	}
-	cmd := NewCandidateCommand(newMemoryStore(t), ioutil.Discard)
+
+	cmd := NewCandidateCommand(newMemoryStore(t), nil, slack.Msg{User: "uid"}, ioutil.Discard)
 	for _, input := range inputs {
 		t.Run(input, func(t *testing.T) {
 			if err := slackbot.NewTestApp(cmd, input); err == nil {
@@ -107,27 +218,39 @@ func TestCandidateRemoveErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestCandidateRemovePermissionDenied(t *testing.T) {
+	roles := models.Roles{"uid": {models.RoleInterviewer}}
+	store := newCandidateTestStore(t, roles, models.Candidates{{Name: "John Doe", ManagerID: "manager"}})
+	cmd := NewCandidateCommand(store, nil, slack.Msg{User: "uid"}, ioutil.Discard)
+	err := slackbot.NewTestApp(cmd, "!candidate rm John Doe")
+	if _, ok := err.(*auth.PermissionDeniedError); !ok {
+		t.Fatalf("Error was not PermissionDeniedError: %#v", err)
+	}
+}
+
 func TestCandidateShow(t *testing.T) {
 	candidates := models.Candidates{
-		{Name: "John Doe"},
-	}
-	store := newMemoryStore(t)
-	if err := store.Write(db.CandidatesKey, candidates); err != nil {
-		t.Fatal(err)
+		{Name: "John Doe", ManagerID: "manager", Meta: map[string]string{"k1": "v1"}},
 	}
+
+	store := newCandidateTestStore(t, models.Roles{}, candidates)
 	w := bytes.NewBuffer(nil)
-	cmd := NewCandidateCommand(store, w)
+	cmd := NewCandidateCommand(store, nil, slack.Msg{User: "uid"}, w)
 	if err := slackbot.NewTestApp(cmd, "!candidate show john doe"); err != nil {
 		t.Fatal(err)
 	}
-	assert.Contains(t, w.String(), "John Doe")
+
+	assert.Equal(t, "*John Doe* (manager: <@manager>)\n*k1*: v1\n", w.String())
 }
+
 func TestCandidateShowErrors(t *testing.T) {
 	inputs := []string{
 		"!candidate show",
 		"!candidate show John Doe",
 	}
-	cmd := NewCandidateCommand(newMemoryStore(t), ioutil.Discard)
+
+	cmd := NewCandidateCommand(newMemoryStore(t), nil, slack.Msg{User: "uid"}, ioutil.Discard)
 	for _, input := range inputs {
 		t.Run(input, func(t *testing.T) {
 			if err := slackbot.NewTestApp(cmd, input); err == nil {
@@ -136,47 +259,135 @@ func TestCandidateShowErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestCandidateTimeline(t *testing.T) {
+	now := time.Date(2026, 10, 20, 12, 0, 0, 0, time.UTC)
+	events := models.CandidateEvents{}
+	for i := candidateTimelinePageSize + 1; i >= 0; i-- {
+		events = append(events, &models.CandidateEvent{
+			Type:   models.CandidateEventNote,
+			Time:   now.Add(time.Minute * time.Duration(i)),
+			UserID: "uid",
+			Text:   fmt.Sprintf("note %d", i),
+		})
+	}
+
+	store := newCandidateTestStore(t, models.Roles{}, models.Candidates{{Name: "John Doe", Events: events}})
+
+	w := bytes.NewBuffer(nil)
+	cmd := NewCandidateCommand(store, nil, slack.Msg{User: "uid"}, w)
+	if err := slackbot.NewTestApp(cmd, "!candidate timeline john doe"); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Contains(t, w.String(), "(page 1 of 2)")
+	assert.Contains(t, w.String(), "`Oct 20, 2026 12:00 PM UTC` *note* <@uid>: note 0\n")
+	assert.NotContains(t, w.String(), "note 10")
+	assert.Contains(t, w.String(), "Run `!candidate timeline --page 2 \"John Doe\"` to see more")
+
+	w.Reset()
+	if err := slackbot.NewTestApp(cmd, "!candidate timeline --page 2 john doe"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "Here is the timeline for *John Doe* (page 2 of 2): \n" +
+		"`Oct 20, 2026 12:10 PM UTC` *note* <@uid>: note 10\n" +
+		"`Oct 20, 2026 12:11 PM UTC` *note* <@uid>: note 11\n"
+	assert.Equal(t, expected, w.String())
+}
+
+func TestCandidateTimelineErrors(t *testing.T) {
+	candidates := models.Candidates{
+		{Name: "John Doe", Events: models.CandidateEvents{newCandidateEvent(models.CandidateEventNote, "uid", "note")}},
+	}
+
+	inputs := []string{
+		"!candidate timeline",
+		"!candidate timeline Jane Doe",
+		"!candidate timeline --page 0 John Doe",
+		"!candidate timeline --page 2 John Doe",
+	}
+
+	cmd := NewCandidateCommand(newCandidateTestStore(t, models.Roles{}, candidates), nil, slack.Msg{User: "uid"}, ioutil.Discard)
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			if err := slackbot.NewTestApp(cmd, input); err == nil {
+				t.Fatal("Error was nil!")
+			}
+		})
+	}
+}
+
 func TestCandidateUpdate(t *testing.T) {
 	candidates := models.Candidates{
 		{
-			Name: "John Doe",
+			Name:      "John Doe",
+			ManagerID: "manager",
 			Meta: map[string]string{
 				"k1": "v1",
 				"k2": "v2",
 			},
 		},
 	}
-	store := newMemoryStore(t)
-	if err := store.Write(db.CandidatesKey, candidates); err != nil {
-		t.Fatal(err)
-	}
-	cmd := NewCandidateCommand(store, ioutil.Discard)
+
+	store := newCandidateTestStore(t, models.Roles{"uid": {models.RoleRecruiter}}, candidates)
+	cmd := NewCandidateCommand(store, nil, slack.Msg{User: "uid"}, ioutil.Discard)
 	if err := slackbot.NewTestApp(cmd, "!candidate update \"John Doe\" k1 updated"); err != nil {
 		t.Fatal(err)
 	}
+
 	result := models.Candidates{}
 	if err := store.Read(db.CandidatesKey, &result); err != nil {
 		t.Fatal(err)
 	}
+
 	expected := models.Candidates{
 		{
-			Name: "John Doe",
+			Name:      "John Doe",
+			ManagerID: "manager",
 			Meta: map[string]string{
 				"k1": "updated",
 				"k2": "v2",
 			},
 		},
 	}
+
 	assert.Equal(t, expected, result)
 }
+
+func TestCandidateUpdateManager(t *testing.T) {
+	candidates := models.Candidates{
+		{Name: "John Doe", ManagerID: "uid", Meta: map[string]string{}},
+	}
+
+	store := newCandidateTestStore(t, models.Roles{}, candidates)
+	cmd := NewCandidateCommand(store, nil, slack.Msg{User: "uid"}, ioutil.Discard)
+	if err := slackbot.NewTestApp(cmd, "!candidate update --manager <@other> \"John Doe\" k1 v1"); err != nil {
+		t.Fatal(err)
+	}
+
+	result := models.Candidates{}
+	if err := store.Read(db.CandidatesKey, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "other", result[0].ManagerID)
+	assert.Equal(t, map[string]string{"k1": "v1"}, result[0].Meta)
+	if assert.Len(t, result[0].Events, 1) {
+		assert.Equal(t, "Manager changed to <@other>", result[0].Events[0].Text)
+	}
+}
+
 func TestCandidateUpdateErrors(t *testing.T) {
 	inputs := []string{
 		"!candidate update",
 		"!candidate update NAME",
 		"!candidate update NAME KEY",
 		"!candidate update NAME KEY VAL",
+		"!candidate update --manager MANAGER NAME KEY VAL",
 	}
-	cmd := NewCandidateCommand(newMemoryStore(t), ioutil.Discard)
+
+	cmd := NewCandidateCommand(newMemoryStore(t), nil, slack.Msg{User: "uid"}, ioutil.Discard)
 	for _, input := range inputs {
 		t.Run(input, func(t *testing.T) {
 			if err := slackbot.NewTestApp(cmd, input); err == nil {
@@ -185,4 +396,13 @@ func TestCandidateUpdateErrors(t *testing.T) {
 		})
 	}
 }
-*/
+
+func TestCandidateUpdatePermissionDenied(t *testing.T) {
+	roles := models.Roles{"uid": {models.RoleInterviewer}}
+	store := newCandidateTestStore(t, roles, models.Candidates{{Name: "John Doe", ManagerID: "manager"}})
+	cmd := NewCandidateCommand(store, nil, slack.Msg{User: "uid"}, ioutil.Discard)
+	err := slackbot.NewTestApp(cmd, "!candidate update \"John Doe\" k1 v1")
+	if _, ok := err.(*auth.PermissionDeniedError); !ok {
+		t.Fatalf("Error was not PermissionDeniedError: %#v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package bot
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/quintilesims/iqvbot/auth"
+	"github.com/quintilesims/iqvbot/db"
+	"github.com/quintilesims/iqvbot/models"
+	"github.com/quintilesims/iqvbot/utils"
+	"github.com/urfave/cli"
+	"github.com/zpatrick/slackbot"
+)
+
+// NewConfigCommand create a cli.Command that allows users to view the bot's runtime settings,
+// and allows admins to change them.
+// The msg is the slack message that invoked the command; only admins may change settings.
+func NewConfigCommand(store db.Store, msg slack.Msg, w io.Writer) cli.Command {
+	userID := msg.User
+	return cli.Command{
+		Name:  "config",
+		Usage: "view and change the bot's settings",
+		Subcommands: []cli.Command{
+			{
+				Name:      "get",
+				Usage:     "show the current value of a setting",
+				ArgsUsage: "NAME",
+				Action: func(c *cli.Context) error {
+					setting, err := parseSettingName(c.Args().Get(0))
+					if err != nil {
+						return err
+					}
+
+					config, err := readConfig(store)
+					if err != nil {
+						return err
+					}
+
+					text := fmt.Sprintf("*%s*: `%s`\n", setting.Name, config.Get(setting.Name))
+					text += fmt.Sprintf("%s (default: `%s`)", setting.Usage, setting.Default)
+					return slackbot.WriteString(w, text)
+				},
+			},
+			{
+				Name:  "ls",
+				Usage: "list all settings and their current values",
+				Action: func(c *cli.Context) error {
+					config, err := readConfig(store)
+					if err != nil {
+						return err
+					}
+
+					text := "Here are the current settings: \n"
+					for _, setting := range models.Settings {
+						text += fmt.Sprintf("*%s*: `%s`", setting.Name, config.Get(setting.Name))
+						if _, ok := config[setting.Name]; ok {
+							text += fmt.Sprintf(" (default: `%s`)", setting.Default)
+						}
+
+						text += "\n"
+					}
+
+					return slackbot.WriteString(w, text)
+				},
+			},
+			{
+				Name:      "set",
+				Usage:     "change the value of a setting",
+				ArgsUsage: "NAME VALUE",
+				Action: func(c *cli.Context) error {
+					if err := auth.Authorize(store, userID, auth.ActionConfigSet); err != nil {
+						return err
+					}
+
+					setting, err := parseSettingName(c.Args().Get(0))
+					if err != nil {
+						return err
+					}
+
+					value := strings.Join(c.Args().Tail(), " ")
+					if value == "" {
+						return slackbot.NewUserInputError("Argument VALUE is required")
+					}
+
+					config, err := readConfig(store)
+					if err != nil {
+						return err
+					}
+
+					if err := config.Set(setting.Name, value); err != nil {
+						return slackbot.NewUserInputError(err.Error())
+					}
+
+					if err := store.Write(db.ConfigKey, config); err != nil {
+						return err
+					}
+
+					return slackbot.WriteStringf(w, "Ok, I've set *%s* to `%s`", setting.Name, value)
+				},
+			},
+		},
+	}
+}
+
+// readConfig reads the runtime settings from the store
+func readConfig(store db.Store) (models.Config, error) {
+	config := models.Config{}
+	if err := store.Read(db.ConfigKey, &config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// userLocation returns the time zone from the user's slack profile, or the time-zone setting if it is unknown
+func userLocation(client utils.SlackClient, userID string, config models.Config) *time.Location {
+	return utils.UserLocation(client, userID, config.Location(models.SettingTimeZone))
+}
+
+// listLimit returns the --limit flag of list commands, or the list-limit setting if the flag isn't set
+func listLimit(store db.Store, c *cli.Context) (int, error) {
+	if c.IsSet("limit") {
+		return c.Int("limit"), nil
+	}
+
+	return readListLimit(store)
+}
+
+// readListLimit returns the list-limit setting from the store
+func readListLimit(store db.Store) (int, error) {
+	config, err := readConfig(store)
+	if err != nil {
+		return 0, err
+	}
+
+	return config.Int(models.SettingListLimit), nil
+}
+
+func parseSettingName(name string) (models.Setting, error) {
+	if name == "" {
+		return models.Setting{}, slackbot.NewUserInputError("Argument NAME is required")
+	}
+
+	setting, ok := models.GetSetting(strings.ToLower(name))
+	if !ok {
+		names := make([]string, len(models.Settings))
+		for i, s := range models.Settings {
+			names[i] = s.Name
+		}
+
+		return models.Setting{}, slackbot.NewUserInputErrorf("Invalid setting '%s': must be one of %s", name, strings.Join(names, ", "))
+	}
+
+	return setting, nil
+}
@@ -0,0 +1,120 @@
+package bot
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/nlopes/slack"
+	"github.com/quintilesims/iqvbot/auth"
+	"github.com/quintilesims/iqvbot/db"
+	"github.com/quintilesims/iqvbot/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/zpatrick/slackbot"
+)
+
+func TestReadConfig(t *testing.T) {
+	store := newMemoryStore(t)
+	if err := store.Write(db.ConfigKey, models.Config{models.SettingListLimit: "5"}); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := readConfig(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 5, config.Int(models.SettingListLimit))
+}
+
+func TestParseSettingName(t *testing.T) {
+	setting, err := parseSettingName("List-Limit")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, models.SettingListLimit, setting.Name)
+
+	for _, name := range []string{"", "limit"} {
+		if _, err := parseSettingName(name); err == nil {
+			t.Errorf("%s: error was nil!", name)
+		}
+	}
+}
+
+func newConfigTestStore(t *testing.T) *db.MemoryStore {
+	store := newMemoryStore(t)
+	roles := models.Roles{
+		"admin":     {models.RoleAdmin},
+		"recruiter": {models.RoleRecruiter},
+	}
+
+	if err := store.Write(db.RolesKey, roles); err != nil {
+		t.Fatal(err)
+	}
+
+	return store
+}
+
+func TestConfigSet(t *testing.T) {
+	store := newConfigTestStore(t)
+	w := bytes.NewBuffer(nil)
+	cmd := NewConfigCommand(store, slack.Msg{User: "admin"}, w)
+	if err := slackbot.NewTestApp(cmd, "!config set List-Limit 5"); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "Ok, I've set *list-limit* to `5`", w.String())
+
+	limit, err := readListLimit(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 5, limit)
+}
+
+func TestConfigSetErrors(t *testing.T) {
+	inputs := []string{
+		"!config set",
+		"!config set limit 5",
+		"!config set list-limit",
+		"!config set list-limit 0",
+		"!config set list-limit five",
+		"!config set time-zone Mars/Olympus_Mons",
+	}
+
+	store := newConfigTestStore(t)
+	cmd := NewConfigCommand(store, slack.Msg{User: "admin"}, ioutil.Discard)
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			err := slackbot.NewTestApp(cmd, input)
+			if _, ok := err.(*slackbot.UserInputError); !ok {
+				t.Fatalf("Error was not UserInputError: %#v", err)
+			}
+		})
+	}
+
+	config, err := readConfig(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, models.Config{}, config)
+}
+
+func TestConfigSetPermissionDenied(t *testing.T) {
+	store := newConfigTestStore(t)
+	cmd := NewConfigCommand(store, slack.Msg{User: "recruiter"}, ioutil.Discard)
+	err := slackbot.NewTestApp(cmd, "!config set list-limit 5")
+	if _, ok := err.(*auth.PermissionDeniedError); !ok {
+		t.Fatalf("Error was not PermissionDeniedError: %#v", err)
+	}
+
+	config, err := readConfig(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, models.Config{}, config)
+}
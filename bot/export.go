@@ -0,0 +1,211 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/quintilesims/iqvbot/models"
+	"github.com/quintilesims/iqvbot/utils"
+	"github.com/urfave/cli"
+	"github.com/zpatrick/slackbot"
+)
+
+// supported export formats
+const (
+	exportFormatCSV      = "csv"
+	exportFormatJSON     = "json"
+	exportFormatMarkdown = "markdown"
+)
+
+// newExportFormatFlag returns the --format flag used by export commands
+func newExportFormatFlag() cli.StringFlag {
+	return cli.StringFlag{
+		Name:  "format",
+		Value: exportFormatCSV,
+		Usage: "The format of the export: 'csv', 'json', or 'markdown'",
+	}
+}
+
+// renderTable renders the header and rows in the specified format.
+// JSON output is a list of objects keyed by the header; empty values are omitted.
+func renderTable(format string, header []string, rows [][]string) (string, error) {
+	switch format {
+	case exportFormatCSV:
+		buf := bytes.NewBuffer(nil)
+		writer := csv.NewWriter(buf)
+		writer.Write(header)
+		writer.WriteAll(rows)
+		if err := writer.Error(); err != nil {
+			return "", err
+		}
+
+		return buf.String(), nil
+	case exportFormatJSON:
+		entries := make([]map[string]string, len(rows))
+		for i, row := range rows {
+			entries[i] = map[string]string{}
+			for j, column := range header {
+				if row[j] != "" {
+					entries[i][column] = row[j]
+				}
+			}
+		}
+
+		b, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return "", err
+		}
+
+		return string(b) + "\n", nil
+	case exportFormatMarkdown:
+		escape := func(s string) string {
+			s = strings.Replace(s, "|", "\\|", -1)
+			return strings.Replace(s, "\n", " ", -1)
+		}
+
+		line := func(values []string) string {
+			escaped := make([]string, len(values))
+			for i, v := range values {
+				escaped[i] = escape(v)
+			}
+
+			return fmt.Sprintf("| %s |\n", strings.Join(escaped, " | "))
+		}
+
+		separator := make([]string, len(header))
+		for i := range separator {
+			separator[i] = "---"
+		}
+
+		text := line(header) + line(separator)
+		for _, row := range rows {
+			text += line(row)
+		}
+
+		return text, nil
+	default:
+		return "", slackbot.NewUserInputErrorf("Invalid format '%s': must be 'csv', 'json', or 'markdown'", format)
+	}
+}
+
+// renderCandidates renders the candidates in the specified format.
+// Each meta key is exported as its own column, and JSON output nests the meta in an object,
+// so the result can be re-imported with '!candidate import'.
+func renderCandidates(format string, candidates models.Candidates) (string, error) {
+	if format == exportFormatJSON {
+		return renderCandidatesJSON(candidates)
+	}
+
+	keys := map[string]bool{}
+	for _, candidate := range candidates {
+		for key := range candidate.Meta {
+			keys[key] = true
+		}
+	}
+
+	metaKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		metaKeys = append(metaKeys, key)
+	}
+
+	sort.Strings(metaKeys)
+
+	header := append([]string{"name", "manager"}, metaKeys...)
+	rows := make([][]string, len(candidates))
+	for i, candidate := range candidates {
+		rows[i] = []string{candidate.Name, candidate.ManagerID}
+		for _, key := range metaKeys {
+			rows[i] = append(rows[i], candidate.Meta[key])
+		}
+	}
+
+	return renderTable(format, header, rows)
+}
+
+func renderCandidatesJSON(candidates models.Candidates) (string, error) {
+	type entry struct {
+		Name    string            `json:"name"`
+		Manager string            `json:"manager"`
+		Meta    map[string]string `json:"meta,omitempty"`
+	}
+
+	entries := make([]entry, len(candidates))
+	for i, candidate := range candidates {
+		entries[i] = entry{
+			Name:    candidate.Name,
+			Manager: candidate.ManagerID,
+			Meta:    candidate.Meta,
+		}
+	}
+
+	b, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(b) + "\n", nil
+}
+
+// renderPipelines renders the pipelines in the specified format.
+// The candidates are used to look up the manager for each pipeline.
+func renderPipelines(format string, pipelines models.Pipelines, candidates models.Candidates) (string, error) {
+	header := []string{"name", "manager", "completed_steps", "total_steps", "current_step"}
+	rows := make([][]string, len(pipelines))
+	for i, pipeline := range pipelines {
+		var managerID string
+		if candidate, ok := candidates.Get(pipeline.Name); ok {
+			managerID = candidate.ManagerID
+		}
+
+		currentStep := "Completed"
+		if pipeline.CurrentStep < len(pipeline.Steps) {
+			currentStep = pipeline.Steps[pipeline.CurrentStep]
+		}
+
+		rows[i] = []string{
+			pipeline.Name,
+			managerID,
+			fmt.Sprintf("%d", pipeline.CompletedSteps()),
+			fmt.Sprintf("%d", len(pipeline.Steps)),
+			currentStep,
+		}
+	}
+
+	return renderTable(format, header, rows)
+}
+
+// uploadExport uploads the content of an export as a file to the specified channel
+func uploadExport(client utils.SlackClient, channelID, name, format, content string) error {
+	filetypes := map[string]string{
+		exportFormatCSV:      "csv",
+		exportFormatJSON:     "javascript",
+		exportFormatMarkdown: "markdown",
+	}
+
+	extensions := map[string]string{
+		exportFormatCSV:      "csv",
+		exportFormatJSON:     "json",
+		exportFormatMarkdown: "md",
+	}
+
+	date := time.Now().Format("2006-01-02")
+	params := slack.FileUploadParameters{
+		Content:  content,
+		Filetype: filetypes[format],
+		Filename: fmt.Sprintf("%s-%s.%s", name, date, extensions[format]),
+		Title:    fmt.Sprintf("%s export (%s)", strings.Title(name), date),
+		Channels: []string{channelID},
+	}
+
+	if _, err := client.UploadFile(params); err != nil {
+		return err
+	}
+
+	return nil
+}
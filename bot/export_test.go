@@ -0,0 +1,139 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/nlopes/slack"
+	"github.com/quintilesims/iqvbot/mock"
+	"github.com/quintilesims/iqvbot/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderCandidates(t *testing.T) {
+	candidates := models.Candidates{
+		{Name: "John Doe", ManagerID: "uid1", Meta: map[string]string{"team": "platform"}},
+		{Name: "Jane, Jr.", ManagerID: "uid2", Meta: map[string]string{"level": "2|3"}},
+	}
+
+	cases := map[string]string{
+		exportFormatCSV: "name,manager,level,team\n" +
+			"John Doe,uid1,,platform\n" +
+			"\"Jane, Jr.\",uid2,2|3,\n",
+		exportFormatMarkdown: "| name | manager | level | team |\n" +
+			"| --- | --- | --- | --- |\n" +
+			"| John Doe | uid1 |  | platform |\n" +
+			"| Jane, Jr. | uid2 | 2\\|3 |  |\n",
+		exportFormatJSON: `[
+  {
+    "name": "John Doe",
+    "manager": "uid1",
+    "meta": {
+      "team": "platform"
+    }
+  },
+  {
+    "name": "Jane, Jr.",
+    "manager": "uid2",
+    "meta": {
+      "level": "2|3"
+    }
+  }
+]
+`,
+	}
+
+	for format, expected := range cases {
+		result, err := renderCandidates(format, candidates)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, expected, result, format)
+	}
+
+	if _, err := renderCandidates("xml", candidates); err == nil {
+		t.Fatal("Error was nil!")
+	}
+}
+
+func TestRenderCandidatesRoundTrip(t *testing.T) {
+	candidates := models.Candidates{
+		{Name: "John Doe", ManagerID: "uid1", Meta: map[string]string{"team": "platform"}},
+	}
+
+	for _, format := range []string{exportFormatCSV, exportFormatJSON} {
+		content, err := renderCandidates(format, candidates)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rows, err := parseCandidateImport(content)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []candidateImportRow{
+			{Row: 1, Name: "John Doe", Manager: "uid1", Meta: map[string]string{"team": "platform"}},
+		}
+
+		assert.Equal(t, expected, rows, format)
+	}
+}
+
+func TestRenderPipelines(t *testing.T) {
+	pipelines := models.Pipelines{
+		{Name: "John Doe", CurrentStep: 1, Steps: []string{"step 1", "step 2"}},
+		{Name: "Jane Doe", CurrentStep: 2, Steps: []string{"step 1", "step 2"}},
+	}
+
+	candidates := models.Candidates{
+		{Name: "john doe", ManagerID: "uid1"},
+	}
+
+	result, err := renderPipelines(exportFormatCSV, pipelines, candidates)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "name,manager,completed_steps,total_steps,current_step\n" +
+		"John Doe,uid1,1,2,step 2\n" +
+		"Jane Doe,,2,2,Completed\n"
+	assert.Equal(t, expected, result)
+
+	result, err = renderPipelines(exportFormatJSON, pipelines[1:], candidates)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `[
+  {
+    "completed_steps": "2",
+    "current_step": "Completed",
+    "name": "Jane Doe",
+    "total_steps": "2"
+  }
+]
+`
+	assert.Equal(t, expected, result)
+}
+
+func TestUploadExport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockSlackClient := mock.NewMockSlackClient(ctrl)
+
+	mockSlackClient.EXPECT().
+		UploadFile(gomock.Any()).
+		Do(func(params slack.FileUploadParameters) {
+			assert.Equal(t, []string{"cid"}, params.Channels)
+			assert.Equal(t, "markdown", params.Filetype)
+			assert.Regexp(t, `^hiring-pipelines-\d{4}-\d{2}-\d{2}\.md$`, params.Filename)
+			assert.Equal(t, "content", params.Content)
+		}).
+		Return(&slack.File{}, nil)
+
+	if err := uploadExport(mockSlackClient, "cid", "hiring-pipelines", exportFormatMarkdown, "content"); err != nil {
+		t.Fatal(err)
+	}
+}
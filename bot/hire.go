@@ -3,19 +3,25 @@ package bot
 import (
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/nlopes/slack"
+	"github.com/quintilesims/iqvbot/auth"
 	"github.com/quintilesims/iqvbot/db"
 	"github.com/quintilesims/iqvbot/models"
+	"github.com/quintilesims/iqvbot/utils"
 	"github.com/urfave/cli"
 	"github.com/zpatrick/slackbot"
 )
 
-// todo: ensure only the candidate's manager can do the step command?
 // todo: make step a subcommand? !hire step next, !hire step prev
 
-// NewHireCommand create a cli.Command that allows users to ...
-func NewHireCommand(store db.Store, w io.Writer) cli.Command {
+// NewHireCommand create a cli.Command that allows users to manage hiring pipelines.
+// The msg is the slack message that invoked the command; its user is used to attribute candidate events.
+func NewHireCommand(store db.Store, client utils.SlackClient, msg slack.Msg, w io.Writer) cli.Command {
+	userID := msg.User
 	return cli.Command{
 		Name:  "hire",
 		Usage: "manage hiring pipelines",
@@ -30,29 +36,8 @@ func NewHireCommand(store db.Store, w io.Writer) cli.Command {
 						return slackbot.NewUserInputError("Argument CANDIDATE is required")
 					}
 
-					candidates := models.Candidates{}
-					if err := store.Read(db.CandidatesKey, &candidates); err != nil {
-						return err
-					}
-
-					candidate, ok := candidates.Get(candidateName)
-					if !ok {
-						return candidateDoesNotExist(candidateName)
-					}
-
-					pipelines := models.Pipelines{}
-					if err := store.Read(db.PipelinesKey, &pipelines); err != nil {
-						return err
-					}
-
-					if _, ok := pipelines.Get(candidateName); ok {
-						text := fmt.Sprintf("A hiring pipeline for *%s* already exists", candidateName)
-						return slackbot.NewUserInputError(text)
-					}
-
-					pipeline := newHiringPipeline(candidate.Name)
-					pipelines = append(pipelines, &pipeline)
-					if err := store.Write(db.PipelinesKey, pipelines); err != nil {
+					candidate, err := startHiringPipeline(store, userID, candidateName)
+					if err != nil {
 						return err
 					}
 
@@ -74,6 +59,16 @@ func NewHireCommand(store db.Store, w io.Writer) cli.Command {
 						return slackbot.NewUserInputError("Argument CANDIDATE is required")
 					}
 
+					candidates := models.Candidates{}
+					if err := store.Read(db.CandidatesKey, &candidates); err != nil {
+						return err
+					}
+
+					candidate, ok := candidates.Get(candidateName)
+					if err := auth.Authorize(store, userID, auth.ActionHireRemove, candidateManagerID(candidate)); err != nil {
+						return err
+					}
+
 					pipelines := models.Pipelines{}
 					if err := store.Read(db.PipelinesKey, &pipelines); err != nil {
 						return err
@@ -87,17 +82,79 @@ func NewHireCommand(store db.Store, w io.Writer) cli.Command {
 						return err
 					}
 
+					if ok {
+						candidate.Events = append(candidate.Events, newCandidateEvent(models.CandidateEventStage, userID, "Removed from hiring pipeline"))
+						if err := store.Write(db.CandidatesKey, candidates); err != nil {
+							return err
+						}
+					}
+
 					return slackbot.WriteStringf(w, "Ok, I've deleted *%s's* hiring pipeline", candidateName)
 				},
 			},
+			{
+				Name:  "export",
+				Usage: "upload hiring pipelines to the channel as a file",
+				Flags: []cli.Flag{
+					newExportFormatFlag(),
+					cli.IntFlag{
+						Name:  "limit",
+						Usage: fmt.Sprintf("The maximum number of hires to export (default: the %s setting)", models.SettingListLimit),
+					},
+					cli.BoolFlag{
+						Name:  "ascending",
+						Usage: "Show results in reverse-alphabetical order",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					if err := auth.Authorize(store, userID, auth.ActionHireExport); err != nil {
+						return err
+					}
+
+					pipelines := models.Pipelines{}
+					if err := store.Read(db.PipelinesKey, &pipelines); err != nil {
+						return err
+					}
+
+					pipelines.FilterByType(models.HiringPipelineType)
+					if len(pipelines) == 0 {
+						return slackbot.WriteString(w, "There aren't any candidates in hiring pipelines at the moment")
+					}
+
+					pipelines.Sort(!c.Bool("ascending"))
+					limit, err := listLimit(store, c)
+					if err != nil {
+						return err
+					}
+
+					if limit >= 0 && limit < len(pipelines) {
+						pipelines = pipelines[:limit]
+					}
+
+					candidates := models.Candidates{}
+					if err := store.Read(db.CandidatesKey, &candidates); err != nil {
+						return err
+					}
+
+					content, err := renderPipelines(c.String("format"), pipelines, candidates)
+					if err != nil {
+						return err
+					}
+
+					if err := uploadExport(client, msg.Channel, "hiring-pipelines", c.String("format"), content); err != nil {
+						return err
+					}
+
+					return slackbot.WriteStringf(w, "Ok, I've exported %d hiring pipeline(s)", len(pipelines))
+				},
+			},
 			{
 				Name:  "ls",
 				Usage: "list candidates currently in a hiring pipeling",
 				Flags: []cli.Flag{
 					cli.IntFlag{
 						Name:  "limit",
-						Value: 50,
-						Usage: "The maximum number of hires to display",
+						Usage: fmt.Sprintf("The maximum number of hires to display (default: the %s setting)", models.SettingListLimit),
 					},
 					cli.BoolFlag{
 						Name:  "ascending",
@@ -117,8 +174,13 @@ func NewHireCommand(store db.Store, w io.Writer) cli.Command {
 
 					pipelines.Sort(!c.Bool("ascending"))
 
+					limit, err := listLimit(store, c)
+					if err != nil {
+						return err
+					}
+
 					text := "Here are the candidates currently in hiring pipelines: \n"
-					for i := 0; i < c.Int("limit") && i < len(pipelines); i++ {
+					for i := 0; i < limit && i < len(pipelines); i++ {
 						text += fmt.Sprintf("*%s*\n", strings.Title(pipelines[i].Name))
 					}
 
@@ -145,6 +207,10 @@ func NewHireCommand(store db.Store, w io.Writer) cli.Command {
 						return candidateDoesNotExist(candidateName)
 					}
 
+					if err := auth.Authorize(store, userID, auth.ActionHireStep, candidate.ManagerID); err != nil {
+						return err
+					}
+
 					pipelines := models.Pipelines{}
 					if err := store.Read(db.PipelinesKey, &pipelines); err != nil {
 						return err
@@ -159,11 +225,22 @@ func NewHireCommand(store db.Store, w io.Writer) cli.Command {
 						return slackbot.NewUserInputError("This pipeline has already been completed")
 					}
 
-					pipeline.CurrentStep += 1
+					completedStep := pipeline.CurrentStep
+					pipeline.CompleteStep(completedStep)
 					if err := store.Write(db.PipelinesKey, pipelines); err != nil {
 						return err
 					}
 
+					candidate.Events = append(candidate.Events, newCandidateEvent(models.CandidateEventStep, userID,
+						"Completed step %d: %s", completedStep+1, pipeline.Steps[completedStep]))
+					if pipeline.CurrentStep >= len(pipeline.Steps) {
+						candidate.Events = append(candidate.Events, newCandidateEvent(models.CandidateEventStage, userID, "Completed hiring pipeline"))
+					}
+
+					if err := store.Write(db.CandidatesKey, candidates); err != nil {
+						return err
+					}
+
 					name := strings.Title(candidateName)
 					escapedManagerID := slackbot.EscapeUserID(candidate.ManagerID)
 					if pipeline.CurrentStep >= len(pipeline.Steps) {
@@ -173,7 +250,7 @@ func NewHireCommand(store db.Store, w io.Writer) cli.Command {
 						return slackbot.WriteString(w, text)
 					}
 
-					text := fmt.Sprintf("Ok, I'll make a note that you've completed step *%d* ", pipeline.CurrentStep)
+					text := fmt.Sprintf("Ok, I'll make a note that you've completed step *%d* ", completedStep+1)
 					text += fmt.Sprintf("of *%s's* hiring pipeline.\n", name)
 					text += fmt.Sprintf("The next step is to: `%s`", pipeline.Steps[pipeline.CurrentStep])
 					return slackbot.WriteString(w, text)
@@ -189,13 +266,23 @@ func NewHireCommand(store db.Store, w io.Writer) cli.Command {
 						return slackbot.NewUserInputError("Argument CANDIDATE is required")
 					}
 
+					candidates := models.Candidates{}
+					if err := store.Read(db.CandidatesKey, &candidates); err != nil {
+						return err
+					}
+
+					candidate, ok := candidates.Get(candidateName)
+					if err := auth.Authorize(store, userID, auth.ActionHireStep, candidateManagerID(candidate)); err != nil {
+						return err
+					}
+
 					pipelines := models.Pipelines{}
 					if err := store.Read(db.PipelinesKey, &pipelines); err != nil {
 						return err
 					}
 
-					pipeline, ok := pipelines.Get(candidateName)
-					if !ok {
+					pipeline, exists := pipelines.Get(candidateName)
+					if !exists {
 						return hiringPipelineDoesNotExist(candidateName)
 					}
 
@@ -203,17 +290,216 @@ func NewHireCommand(store db.Store, w io.Writer) cli.Command {
 						return slackbot.NewUserInputError("This pipeline is already on the first step")
 					}
 
-					pipeline.CurrentStep -= 1
+					pipeline.RevertStep(pipeline.CurrentStep - 1)
 					if err := store.Write(db.PipelinesKey, pipelines); err != nil {
 						return err
 					}
 
+					if ok {
+						candidate.Events = append(candidate.Events, newCandidateEvent(models.CandidateEventStep, userID,
+							"Reverted to step %d: %s", pipeline.CurrentStep+1, pipeline.Steps[pipeline.CurrentStep]))
+						if err := store.Write(db.CandidatesKey, candidates); err != nil {
+							return err
+						}
+					}
+
 					name := strings.Title(candidateName)
 					text := fmt.Sprintf("Ok, I've reverted *%s's* hiring pipeline back one step.\n", name)
 					text += fmt.Sprintf("The current step is to: `%s`\n", pipeline.Steps[pipeline.CurrentStep])
 					return slackbot.WriteString(w, text)
 				},
 			},
+			{
+				Name:  "status",
+				Usage: "show the status of active hiring pipelines, grouped by manager",
+				Flags: []cli.Flag{
+					cli.BoolFlag{
+						Name:  "mine",
+						Usage: "Only show pipelines for candidates you manage",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					var managerID string
+					if c.Bool("mine") {
+						managerID = userID
+					}
+
+					blocks, err := readHireStatusBlocks(store, managerID)
+					if err != nil {
+						return err
+					}
+
+					// the text is only shown in notifications, since the message has blocks
+					options := []slack.MsgOption{
+						slack.MsgOptionText("Hiring status", false),
+						slack.MsgOptionBlocks(blocks...),
+					}
+
+					if _, _, _, err := client.SendMessage(msg.Channel, options...); err != nil {
+						return err
+					}
+
+					return nil
+				},
+			},
+			{
+				Name:  "step",
+				Usage: "edit the steps in a candidate's hiring pipeline",
+				Subcommands: []cli.Command{
+					{
+						Name:      "add",
+						Usage:     "add a step to a candidate's hiring pipeline",
+						ArgsUsage: "CANDIDATE TEXT",
+						Flags: []cli.Flag{
+							cli.IntFlag{
+								Name:  "at",
+								Usage: "The position of the new step (default: after the last step)",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							args := c.Args()
+							candidateName := args.Get(0)
+							if candidateName == "" {
+								return slackbot.NewUserInputError("Argument CANDIDATE is required")
+							}
+
+							text := strings.Join(args.Tail(), " ")
+							if text == "" {
+								return slackbot.NewUserInputError("Argument TEXT is required")
+							}
+
+							var position int
+							err := editHiringPipeline(store, userID, candidateName, func(pipeline *models.Pipeline) (string, error) {
+								position = len(pipeline.Steps) + 1
+								if c.IsSet("at") {
+									position = c.Int("at")
+								}
+
+								if position < 1 || position > len(pipeline.Steps)+1 {
+									return "", slackbot.NewUserInputErrorf("Position must be between 1 and %d", len(pipeline.Steps)+1)
+								}
+
+								pipeline.AddStep(position-1, text)
+								return fmt.Sprintf("Added step %d: %s", position, text), nil
+							})
+							if err != nil {
+								return err
+							}
+
+							return slackbot.WriteStringf(w, "Ok, I've added `%s` as step *%d* of *%s's* hiring pipeline", text, position, strings.Title(candidateName))
+						},
+					},
+					{
+						Name:      "rm",
+						Usage:     "remove a step from a candidate's hiring pipeline",
+						ArgsUsage: "CANDIDATE N",
+						Action: func(c *cli.Context) error {
+							args := c.Args()
+							candidateName := args.Get(0)
+							if candidateName == "" {
+								return slackbot.NewUserInputError("Argument CANDIDATE is required")
+							}
+
+							var step string
+							err := editHiringPipeline(store, userID, candidateName, func(pipeline *models.Pipeline) (string, error) {
+								i, err := parsePipelineStep(pipeline, args.Get(1))
+								if err != nil {
+									return "", err
+								}
+
+								if len(pipeline.Steps) == 1 {
+									return "", slackbot.NewUserInputError("A hiring pipeline must have at least one step")
+								}
+
+								step = pipeline.Steps[i]
+								pipeline.RemoveStep(i)
+								return fmt.Sprintf("Removed step %d: %s", i+1, step), nil
+							})
+							if err != nil {
+								return err
+							}
+
+							return slackbot.WriteStringf(w, "Ok, I've removed `%s` from *%s's* hiring pipeline", step, strings.Title(candidateName))
+						},
+					},
+					{
+						Name:      "move",
+						Usage:     "move a step in a candidate's hiring pipeline to a new position",
+						ArgsUsage: "CANDIDATE FROM TO",
+						Action: func(c *cli.Context) error {
+							args := c.Args()
+							candidateName := args.Get(0)
+							if candidateName == "" {
+								return slackbot.NewUserInputError("Argument CANDIDATE is required")
+							}
+
+							var step string
+							var to int
+							err := editHiringPipeline(store, userID, candidateName, func(pipeline *models.Pipeline) (string, error) {
+								from, err := parsePipelineStep(pipeline, args.Get(1))
+								if err != nil {
+									return "", err
+								}
+
+								to, err = parsePipelineStep(pipeline, args.Get(2))
+								if err != nil {
+									return "", err
+								}
+
+								step = pipeline.Steps[from]
+								pipeline.MoveStep(from, to)
+								return fmt.Sprintf("Moved step %d to step %d: %s", from+1, to+1, step), nil
+							})
+							if err != nil {
+								return err
+							}
+
+							return slackbot.WriteStringf(w, "Ok, `%s` is now step *%d* of *%s's* hiring pipeline", step, to+1, strings.Title(candidateName))
+						},
+					},
+					{
+						Name:      "done",
+						Usage:     "complete a step in a candidate's hiring pipeline, even if it is out of order",
+						ArgsUsage: "CANDIDATE N",
+						Action: func(c *cli.Context) error {
+							args := c.Args()
+							candidateName := args.Get(0)
+							if candidateName == "" {
+								return slackbot.NewUserInputError("Argument CANDIDATE is required")
+							}
+
+							var pipeline *models.Pipeline
+							var index int
+							err := editHiringPipeline(store, userID, candidateName, func(p *models.Pipeline) (string, error) {
+								i, err := parsePipelineStep(p, args.Get(1))
+								if err != nil {
+									return "", err
+								}
+
+								if p.IsComplete(i) {
+									return "", slackbot.NewUserInputErrorf("Step %d has already been completed", i+1)
+								}
+
+								pipeline, index = p, i
+								pipeline.CompleteStep(i)
+								return fmt.Sprintf("Completed step %d: %s", i+1, pipeline.Steps[i]), nil
+							})
+							if err != nil {
+								return err
+							}
+
+							name := strings.Title(candidateName)
+							if pipeline.CurrentStep >= len(pipeline.Steps) {
+								return slackbot.WriteStringf(w, "Thank you for completing *%s's* hiring pipeline!", name)
+							}
+
+							text := fmt.Sprintf("Ok, I'll make a note that you've completed step *%d* of *%s's* hiring pipeline.\n", index+1, name)
+							text += fmt.Sprintf("The next incomplete step is to: `%s`", pipeline.Steps[pipeline.CurrentStep])
+							return slackbot.WriteString(w, text)
+						},
+					},
+				},
+			},
 			{
 				Name:      "show",
 				Usage:     "show the hiring pipeline for a candidate",
@@ -244,15 +530,15 @@ func NewHireCommand(store db.Store, w io.Writer) cli.Command {
 						return hiringPipelineDoesNotExist(candidateName)
 					}
 
-					var steps string
-					for i, step := range pipeline.Steps {
-						steps += fmt.Sprintf("%d. %s\n", i+1, step)
-					}
-
 					name := strings.Title(candidateName)
 					escapedManagerID := slackbot.EscapeUserID(candidate.ManagerID)
 					text := fmt.Sprintf("This is the hiring pipeline for *%s*: \n", name)
-					text += fmt.Sprintf("```%s```\n", steps)
+					text += fmt.Sprintf("```%s```\n", formatPipelineSteps(pipeline))
+					if pipeline.CurrentStep >= len(pipeline.Steps) {
+						text += fmt.Sprintf("*%s's* manager, %s, has completed the hiring pipeline", name, escapedManagerID)
+						return slackbot.WriteString(w, text)
+					}
+
 					text += fmt.Sprintf("*%s's* manager, %s, ", name, escapedManagerID)
 					text += fmt.Sprintf("is currently on step *%d* of the hiring pipeline: ", pipeline.CurrentStep+1)
 					text += fmt.Sprintf("`%s`", pipeline.Steps[pipeline.CurrentStep])
@@ -265,8 +551,9 @@ func NewHireCommand(store db.Store, w io.Writer) cli.Command {
 
 func newHiringPipeline(candidateName string) models.Pipeline {
 	return models.Pipeline{
-		Name: candidateName,
-		Type: models.HiringPipelineType,
+		Name:        candidateName,
+		Type:        models.HiringPipelineType,
+		StepUpdated: time.Now(),
 		Steps: []string{
 			"Order hardware (latop, keyboard, mouse, dock, etc.)",
 			"Order software (MSDN, etc.)",
@@ -277,6 +564,135 @@ func newHiringPipeline(candidateName string) models.Pipeline {
 	}
 }
 
+// startHiringPipeline creates a new hiring pipeline for the candidate and records it in the candidate's timeline.
+// Only users allowed to add hires may start a pipeline.
+func startHiringPipeline(store db.Store, userID, candidateName string) (*models.Candidate, error) {
+	candidates := models.Candidates{}
+	if err := store.Read(db.CandidatesKey, &candidates); err != nil {
+		return nil, err
+	}
+
+	candidate, ok := candidates.Get(candidateName)
+	if !ok {
+		return nil, candidateDoesNotExist(candidateName)
+	}
+
+	if err := auth.Authorize(store, userID, auth.ActionHireAdd, candidate.ManagerID); err != nil {
+		return nil, err
+	}
+
+	pipelines := models.Pipelines{}
+	if err := store.Read(db.PipelinesKey, &pipelines); err != nil {
+		return nil, err
+	}
+
+	if _, ok := pipelines.Get(candidateName); ok {
+		return nil, slackbot.NewUserInputErrorf("A hiring pipeline for *%s* already exists", candidateName)
+	}
+
+	pipeline := newHiringPipeline(candidate.Name)
+	pipelines = append(pipelines, &pipeline)
+	if err := store.Write(db.PipelinesKey, pipelines); err != nil {
+		return nil, err
+	}
+
+	candidate.Events = append(candidate.Events, newCandidateEvent(models.CandidateEventStage, userID, "Started hiring pipeline"))
+	if err := store.Write(db.CandidatesKey, candidates); err != nil {
+		return nil, err
+	}
+
+	return candidate, nil
+}
+
+// editHiringPipeline applies edit to the candidate's hiring pipeline and saves the result.
+// Only users allowed to step the pipeline may edit it.
+// The text returned by edit is recorded in the candidate's timeline; edits that return an empty text didn't change anything.
+func editHiringPipeline(store db.Store, userID, candidateName string, edit func(*models.Pipeline) (string, error)) error {
+	candidates := models.Candidates{}
+	if err := store.Read(db.CandidatesKey, &candidates); err != nil {
+		return err
+	}
+
+	candidate, ok := candidates.Get(candidateName)
+	if err := auth.Authorize(store, userID, auth.ActionHireStep, candidateManagerID(candidate)); err != nil {
+		return err
+	}
+
+	pipelines := models.Pipelines{}
+	if err := store.Read(db.PipelinesKey, &pipelines); err != nil {
+		return err
+	}
+
+	pipeline, exists := pipelines.Get(candidateName)
+	if !exists {
+		return hiringPipelineDoesNotExist(candidateName)
+	}
+
+	wasComplete := pipeline.CurrentStep >= len(pipeline.Steps)
+	text, err := edit(pipeline)
+	if err != nil {
+		return err
+	}
+
+	if text == "" {
+		return nil
+	}
+
+	if err := store.Write(db.PipelinesKey, pipelines); err != nil {
+		return err
+	}
+
+	if !ok {
+		return nil
+	}
+
+	candidate.Events = append(candidate.Events, newCandidateEvent(models.CandidateEventStep, userID, "%s", text))
+	if !wasComplete && pipeline.CurrentStep >= len(pipeline.Steps) {
+		candidate.Events = append(candidate.Events, newCandidateEvent(models.CandidateEventStage, userID, "Completed hiring pipeline"))
+	}
+
+	return store.Write(db.CandidatesKey, candidates)
+}
+
+// candidateManagerID returns the id of the candidate's manager, or an empty string if candidate is nil.
+// Pipelines may outlive their candidates, in which case only users with a role can manage them.
+func candidateManagerID(candidate *models.Candidate) string {
+	if candidate == nil {
+		return ""
+	}
+
+	return candidate.ManagerID
+}
+
+// parsePipelineStep converts a step number, starting at 1, into an index of the pipeline's steps
+func parsePipelineStep(pipeline *models.Pipeline, input string) (int, error) {
+	if input == "" {
+		return 0, slackbot.NewUserInputError("A step number is required")
+	}
+
+	n, err := strconv.Atoi(input)
+	if err != nil || n < 1 || n > len(pipeline.Steps) {
+		return 0, slackbot.NewUserInputErrorf("'%s' is not a valid step: must be between 1 and %d", input, len(pipeline.Steps))
+	}
+
+	return n - 1, nil
+}
+
+// formatPipelineSteps returns a numbered list of the pipeline's steps, marking the completed steps
+func formatPipelineSteps(pipeline *models.Pipeline) string {
+	var text string
+	for i, step := range pipeline.Steps {
+		check := " "
+		if pipeline.IsComplete(i) {
+			check = "x"
+		}
+
+		text += fmt.Sprintf("%d. [%s] %s\n", i+1, check, step)
+	}
+
+	return text
+}
+
 func hiringPipelineDoesNotExist(name string) *slackbot.UserInputError {
 	return slackbot.NewUserInputErrorf("There aren't any candidates in a hiring pipeline with the name *%s*", name)
 }
@@ -0,0 +1,352 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nlopes/slack"
+	"github.com/quintilesims/iqvbot/db"
+	"github.com/quintilesims/iqvbot/models"
+	"github.com/quintilesims/iqvbot/slash"
+	"github.com/zpatrick/slackbot"
+)
+
+// action names of the buttons and menus in /hire views
+const (
+	ActionHireFilter = "hire_filter"
+	ActionHireShow   = "hire_show"
+	ActionHireBack   = "hire_back"
+	ActionHireToggle = "hire_toggle"
+)
+
+// callback ids of the /hire views
+const (
+	CallbackHireList   = "hire_list"
+	CallbackHireDetail = "hire_detail"
+)
+
+// the values of the /hire list filter
+const (
+	hireFilterActive    = "active"
+	hireFilterCompleted = "completed"
+	hireFilterAll       = "all"
+)
+
+var hireFilterOptions = []slack.AttachmentActionOption{
+	{Text: "Active", Value: hireFilterActive},
+	{Text: "Completed", Value: hireFilterCompleted},
+	{Text: "All", Value: hireFilterAll},
+}
+
+// HireSlashCommand manages hiring pipelines with the /hire slash command.
+// It uses the same store data as the !hire command, and also handles the buttons on the hire status dashboard.
+type HireSlashCommand struct {
+	store db.Store
+}
+
+func NewHireSlashCommand(store db.Store) *HireSlashCommand {
+	return &HireSlashCommand{
+		store: store,
+	}
+}
+
+func (cmd *HireSlashCommand) Schema() *slash.CommandSchema {
+	root := &slash.Command{
+		Name:  "/hire",
+		Usage: "view hiring pipelines, or view/complete the steps of a single pipeline",
+		Args: []slash.Arg{
+			{Name: "CANDIDATE", Usage: "The name of the candidate whose pipeline to view", Optional: true, Variadic: true},
+		},
+		Action: func(c *slash.Context) (*slack.Message, error) {
+			if !c.IsSet("CANDIDATE") {
+				return cmd.list(hireFilterActive)
+			}
+
+			return cmd.show(c.String("CANDIDATE"))
+		},
+		Subcommands: []*slash.Command{
+			{
+				Name:  "status",
+				Usage: "show the status of active hiring pipelines, grouped by manager",
+				Flags: []slash.Flag{
+					{Name: "mine", Usage: "Only show pipelines for candidates you manage", Type: slash.TypeBool},
+				},
+				Action: cmd.status,
+			},
+		},
+	}
+
+	schema := root.Schema()
+	schema.Callback = cmd.callback
+	schema.BlockActionIDs = []string{ActionHireStatusStepDone}
+	schema.BlockAction = NewHireStatusBlockAction(cmd.store)
+	return schema
+}
+
+func (cmd *HireSlashCommand) callback(req slack.AttachmentActionCallback) (*slack.Message, error) {
+	action := req.Actions[0]
+	switch action.Name {
+	case ActionHireFilter:
+		return cmd.list(attachmentActionValue(action))
+	case ActionHireShow:
+		return cmd.show(action.Value)
+	case ActionHireBack:
+		return cmd.list(hireFilterActive)
+	case ActionHireToggle:
+		return cmd.toggle(req.User.ID, action.Value)
+	default:
+		return nil, fmt.Errorf("Unexpected callback action name '%s'", action.Name)
+	}
+}
+
+// status displays the hire status dashboard
+func (cmd *HireSlashCommand) status(c *slash.Context) (*slack.Message, error) {
+	var managerID string
+	if c.Bool("mine") {
+		managerID = c.Request.UserID
+	}
+
+	blocks, err := readHireStatusBlocks(cmd.store, managerID)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := slack.NewBlockMessage(blocks...)
+	return &msg, nil
+}
+
+// list displays the hiring pipelines that match the filter
+func (cmd *HireSlashCommand) list(filter string) (*slack.Message, error) {
+	pipelines := models.Pipelines{}
+	if err := cmd.store.Read(db.PipelinesKey, &pipelines); err != nil {
+		return nil, err
+	}
+
+	candidates := models.Candidates{}
+	if err := cmd.store.Read(db.CandidatesKey, &candidates); err != nil {
+		return nil, err
+	}
+
+	limit, err := readListLimit(cmd.store)
+	if err != nil {
+		return nil, err
+	}
+
+	return newHireListView(pipelines, candidates, filter, limit), nil
+}
+
+// show displays the steps of a candidate's hiring pipeline
+func (cmd *HireSlashCommand) show(name string) (*slack.Message, error) {
+	pipelines := models.Pipelines{}
+	if err := cmd.store.Read(db.PipelinesKey, &pipelines); err != nil {
+		return nil, err
+	}
+
+	pipelines.FilterByType(models.HiringPipelineType)
+	pipeline, ok := pipelines.Get(name)
+	if !ok {
+		return nil, slashCommandError(hiringPipelineDoesNotExist(name))
+	}
+
+	candidates := models.Candidates{}
+	if err := cmd.store.Read(db.CandidatesKey, &candidates); err != nil {
+		return nil, err
+	}
+
+	return newHireDetailView(pipeline, candidateOrNil(candidates, pipeline.Name))
+}
+
+// toggle sets the step in value to the state in value.
+// Clicking the same button twice doesn't change the step back, since the second click sets the same state.
+func (cmd *HireSlashCommand) toggle(userID, value string) (*slack.Message, error) {
+	var step hireStatusValue
+	if err := json.Unmarshal([]byte(value), &step); err != nil {
+		return nil, err
+	}
+
+	err := editHiringPipeline(cmd.store, userID, step.Candidate, func(pipeline *models.Pipeline) (string, error) {
+		if err := step.checkStep(pipeline); err != nil {
+			return "", err
+		}
+
+		switch {
+		case pipeline.IsComplete(step.Step) == step.Done:
+			return "", nil
+		case step.Done:
+			pipeline.CompleteStep(step.Step)
+			return fmt.Sprintf("Completed step %d: %s", step.Step+1, pipeline.Steps[step.Step]), nil
+		default:
+			pipeline.RevertStep(step.Step)
+			return fmt.Sprintf("Marked step %d as incomplete: %s", step.Step+1, pipeline.Steps[step.Step]), nil
+		}
+	})
+	if err != nil {
+		return nil, slashCommandError(err)
+	}
+
+	return cmd.show(step.Candidate)
+}
+
+// newHireListView renders a filter menu followed by a card for each hiring pipeline.
+// The filter is one of 'active', 'completed', or 'all'.
+func newHireListView(pipelines models.Pipelines, candidates models.Candidates, filter string, limit int) *slack.Message {
+	menu := slack.AttachmentAction{
+		Name:    ActionHireFilter,
+		Text:    "Filter by status",
+		Type:    "select",
+		Options: hireFilterOptions,
+	}
+
+	for _, option := range hireFilterOptions {
+		if option.Value == filter {
+			menu.SelectedOptions = []slack.AttachmentActionOption{option}
+		}
+	}
+
+	matches := models.Pipelines{}
+	for _, pipeline := range pipelines {
+		if pipeline.Type != models.HiringPipelineType {
+			continue
+		}
+
+		isComplete := pipeline.CurrentStep >= len(pipeline.Steps)
+		if (filter == hireFilterActive && isComplete) || (filter == hireFilterCompleted && !isComplete) {
+			continue
+		}
+
+		matches = append(matches, pipeline)
+	}
+
+	matches.Sort(true)
+	text := "Here are the candidates currently in hiring pipelines: "
+	if len(matches) == 0 {
+		text = "There aren't any candidates in hiring pipelines at the moment"
+	}
+
+	header := slack.Attachment{
+		Fallback:   "You are currently unable to filter hiring pipelines. Please try again later.",
+		CallbackID: CallbackHireList,
+		Actions:    []slack.AttachmentAction{menu},
+	}
+
+	if len(matches) > limit {
+		header.Footer = fmt.Sprintf("Showing %d of %d hiring pipelines", limit, len(matches))
+		matches = matches[:limit]
+	}
+
+	attachments := []slack.Attachment{header}
+	for _, pipeline := range matches {
+		attachments = append(attachments, slack.Attachment{
+			Title:      strings.Title(pipeline.Name),
+			Text:       formatPipelineProgress(pipeline),
+			Fallback:   "You are currently unable to view this hiring pipeline. Please try again later.",
+			CallbackID: CallbackHireList,
+			Fields: []slack.AttachmentField{
+				{
+					Title: "Manager",
+					Value: formatManager(candidateManagerID(candidateOrNil(candidates, pipeline.Name))),
+					Short: true,
+				},
+			},
+			Actions: []slack.AttachmentAction{
+				{
+					Name:  ActionHireShow,
+					Text:  "View",
+					Type:  "button",
+					Value: pipeline.Name,
+				},
+			},
+		})
+	}
+
+	return &slack.Message{Msg: slack.Msg{Text: text, Attachments: attachments}}
+}
+
+// newHireDetailView renders the progress of a hiring pipeline, followed by a checkbox for each step.
+// The candidate is nil if the pipeline has outlived its candidate.
+func newHireDetailView(pipeline *models.Pipeline, candidate *models.Candidate) (*slack.Message, error) {
+	attachments := []slack.Attachment{
+		{
+			Title:      strings.Title(pipeline.Name),
+			Text:       formatPipelineProgress(pipeline),
+			Fallback:   "You are currently unable to view this hiring pipeline. Please try again later.",
+			CallbackID: CallbackHireDetail,
+			Fields: []slack.AttachmentField{
+				{
+					Title: "Manager",
+					Value: formatManager(candidateManagerID(candidate)),
+					Short: true,
+				},
+			},
+			Actions: []slack.AttachmentAction{
+				{
+					Name: ActionHireBack,
+					Text: "Back",
+					Type: "button",
+				},
+			},
+		},
+	}
+
+	for i, step := range pipeline.Steps {
+		value, err := json.Marshal(hireStatusValue{
+			Candidate: pipeline.Name,
+			Step:      i,
+			StepText:  step,
+			Done:      !pipeline.IsComplete(i),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		checkbox := slack.Attachment{
+			Text:       fmt.Sprintf(":white_large_square: %d. %s", i+1, step),
+			Fallback:   "You are currently unable to complete this step. Please try again later.",
+			CallbackID: CallbackHireDetail,
+			Actions: []slack.AttachmentAction{
+				{
+					Name:  ActionHireToggle,
+					Text:  "Done",
+					Type:  "button",
+					Style: "primary",
+					Value: string(value),
+				},
+			},
+		}
+
+		if pipeline.IsComplete(i) {
+			checkbox.Text = fmt.Sprintf(":white_check_mark: %d. ~%s~", i+1, step)
+			checkbox.Color = "good"
+			checkbox.Actions[0].Text = "Undo"
+			checkbox.Actions[0].Style = ""
+		}
+
+		attachments = append(attachments, checkbox)
+	}
+
+	return &slack.Message{Msg: slack.Msg{Attachments: attachments}}, nil
+}
+
+// formatPipelineProgress describes how far along the steps of a pipeline are
+func formatPipelineProgress(pipeline *models.Pipeline) string {
+	if pipeline.CurrentStep >= len(pipeline.Steps) {
+		return "Completed"
+	}
+
+	percent := pipeline.CompletedSteps() * 100 / len(pipeline.Steps)
+	return fmt.Sprintf("Step %d of %d (%d%% complete): `%s`",
+		pipeline.CurrentStep+1,
+		len(pipeline.Steps),
+		percent,
+		pipeline.Steps[pipeline.CurrentStep])
+}
+
+// formatManager escapes the manager's id, or describes a missing manager
+func formatManager(managerID string) string {
+	if managerID == "" {
+		return "no manager"
+	}
+
+	return slackbot.EscapeUserID(managerID)
+}
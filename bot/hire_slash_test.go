@@ -0,0 +1,136 @@
+package bot
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/quintilesims/iqvbot/db"
+	"github.com/quintilesims/iqvbot/models"
+	"github.com/quintilesims/iqvbot/slash"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHireListView(t *testing.T) {
+	pipelines, candidates := newHireStatusTestData(time.Now())
+
+	cases := map[string][]string{
+		hireFilterActive:    {"Alice", "Bob"},
+		hireFilterCompleted: {"Carol"},
+		hireFilterAll:       {"Alice", "Bob", "Carol"},
+	}
+
+	for filter, expected := range cases {
+		t.Run(filter, func(t *testing.T) {
+			msg := newHireListView(pipelines, candidates, filter, 50)
+			assert.Equal(t, expected, attachmentTitles(msg))
+			assert.Equal(t, filter, msg.Attachments[0].Actions[0].SelectedOptions[0].Value)
+		})
+	}
+
+	msg := newHireListView(pipelines, candidates, hireFilterAll, 1)
+	assert.Equal(t, []string{"Alice"}, attachmentTitles(msg))
+	assert.Equal(t, "Showing 1 of 3 hiring pipelines", msg.Attachments[0].Footer)
+	assert.Equal(t, "Step 1 of 2 (0% complete): `a`", msg.Attachments[1].Text)
+	assert.Equal(t, "<@m1>", msg.Attachments[1].Fields[0].Value)
+}
+
+func TestHireDetailView(t *testing.T) {
+	pipeline := &models.Pipeline{Name: "alice", Steps: []string{"a", "b", "c"}, Completed: []bool{false, true, false}}
+	msg, err := newHireDetailView(pipeline, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, msg.Attachments, 4)
+	assert.Equal(t, "no manager", msg.Attachments[0].Fields[0].Value)
+
+	expected := []string{
+		":white_large_square: 1. a",
+		":white_check_mark: 2. ~b~",
+		":white_large_square: 3. c",
+	}
+
+	for i, attachment := range msg.Attachments[1:] {
+		assert.Equal(t, expected[i], attachment.Text)
+
+		var value hireStatusValue
+		if err := json.Unmarshal([]byte(attachment.Actions[0].Value), &value); err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, hireStatusValue{Candidate: "alice", Step: i, StepText: pipeline.Steps[i], Done: i != 1}, value)
+	}
+
+	assert.Equal(t, "Undo", msg.Attachments[2].Actions[0].Text)
+}
+
+func TestHireSlashCommandToggle(t *testing.T) {
+	store := newMemoryStore(t)
+	pipelines, candidates := newHireStatusTestData(time.Now())
+	if err := store.Write(db.PipelinesKey, pipelines); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Write(db.CandidatesKey, candidates); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := NewHireSlashCommand(store)
+	toggle := func(userID string, step int, stepText string, done bool) (*slack.Message, error) {
+		b, err := json.Marshal(hireStatusValue{Candidate: "alice", Step: step, StepText: stepText, Done: done})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return cmd.callback(slack.AttachmentActionCallback{
+			CallbackID: CallbackHireDetail,
+			User:       slack.User{ID: userID},
+			Actions:    []slack.AttachmentAction{{Name: ActionHireToggle, Value: string(b)}},
+		})
+	}
+
+	// clicking the same button twice leaves the step complete
+	for i := 0; i < 2; i++ {
+		msg, err := toggle("m1", 1, "b", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, ":white_check_mark: 2. ~b~", msg.Attachments[2].Text)
+	}
+
+	if _, err := toggle("m1", 1, "b", false); err != nil {
+		t.Fatal(err)
+	}
+
+	result := models.Pipelines{}
+	if err := store.Read(db.PipelinesKey, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.False(t, result[0].IsComplete(1))
+
+	if _, err := toggle("m2", 0, "a", true); err == nil {
+		t.Fatal("Error was nil!")
+	} else if _, ok := err.(*slash.SlackMessageError); !ok {
+		t.Fatalf("Error was not SlackMessageError: %#v", err)
+	}
+
+	if _, err := toggle("m1", 5, "f", true); err == nil {
+		t.Fatal("Error was nil!")
+	}
+
+	if _, err := toggle("m1", 0, "b", true); err == nil {
+		t.Fatal("Error was nil!")
+	}
+
+	candidates = models.Candidates{}
+	if err := store.Read(db.CandidatesKey, &candidates); err != nil {
+		t.Fatal(err)
+	}
+
+	alice, _ := candidates.Get("alice")
+	assert.Len(t, alice.Events, 2)
+}
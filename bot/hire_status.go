@@ -0,0 +1,198 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/quintilesims/iqvbot/db"
+	"github.com/quintilesims/iqvbot/models"
+	"github.com/zpatrick/slackbot"
+)
+
+// ActionHireStatusStepDone is the action id of the "mark step done" buttons on the hire status dashboard
+const ActionHireStatusStepDone = "hire_status_step_done"
+
+// The number of days a pipeline can stay on the same step before it is overdue
+const hireStatusOverdueDays = 7
+
+// hireStatusValue is stored in the value of each "mark step done" button
+type hireStatusValue struct {
+	Candidate string `json:"candidate"`
+	Step      int    `json:"step"`
+
+	// StepText is the text of the step when the button was rendered,
+	// so buttons can't act on a different step after the pipeline's steps are moved or removed
+	StepText string `json:"step_text"`
+
+	// Done is the state the button sets the step to
+	Done bool `json:"done"`
+
+	// ManagerID is set when the dashboard only shows pipelines managed by that user
+	ManagerID string `json:"manager_id,omitempty"`
+}
+
+// checkStep returns an error if the step in v no longer exists in the pipeline,
+// or if a different step is now at the same position
+func (v hireStatusValue) checkStep(pipeline *models.Pipeline) error {
+	if v.Step < 0 || v.Step >= len(pipeline.Steps) || pipeline.Steps[v.Step] != v.StepText {
+		return slackbot.NewUserInputErrorf("The steps of *%s's* hiring pipeline have changed: please refresh and try again", strings.Title(v.Candidate))
+	}
+
+	return nil
+}
+
+// newHireStatusBlocks renders a dashboard of the active hiring pipelines, grouped by manager.
+// If managerID is set, only pipelines for candidates managed by that user are shown.
+func newHireStatusBlocks(pipelines models.Pipelines, candidates models.Candidates, managerID string, now time.Time) ([]slack.Block, error) {
+	groups := map[string]models.Pipelines{}
+	var total, overdue int
+	for _, pipeline := range pipelines {
+		if pipeline.Type != models.HiringPipelineType || pipeline.CurrentStep >= len(pipeline.Steps) {
+			continue
+		}
+
+		pipelineManagerID := candidateManagerID(candidateOrNil(candidates, pipeline.Name))
+		if managerID != "" && pipelineManagerID != managerID {
+			continue
+		}
+
+		if days, ok := pipeline.DaysOnStep(now); ok && days >= hireStatusOverdueDays {
+			overdue++
+		}
+
+		groups[pipelineManagerID] = append(groups[pipelineManagerID], pipeline)
+		total++
+	}
+
+	if total == 0 {
+		text := slack.NewTextBlockObject(slack.MarkdownType, "There aren't any active hiring pipelines at the moment", false, false)
+		return []slack.Block{slack.NewSectionBlock(text, nil, nil)}, nil
+	}
+
+	managerIDs := make([]string, 0, len(groups))
+	for id := range groups {
+		managerIDs = append(managerIDs, id)
+	}
+
+	sort.Strings(managerIDs)
+
+	summary := fmt.Sprintf("*Hiring status*: %d active pipeline(s), %d overdue", total, overdue)
+	blocks := []slack.Block{
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, summary, false, false), nil, nil),
+	}
+
+	for _, id := range managerIDs {
+		header := slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf("*Manager*: %s", formatManager(id)), false, false)
+		blocks = append(blocks, slack.NewDividerBlock(), slack.NewSectionBlock(header, nil, nil))
+
+		group := groups[id]
+		group.Sort(true)
+		for _, pipeline := range group {
+			block, err := newHireStatusPipelineBlock(pipeline, managerID, now)
+			if err != nil {
+				return nil, err
+			}
+
+			blocks = append(blocks, block)
+		}
+	}
+
+	return blocks, nil
+}
+
+func newHireStatusPipelineBlock(pipeline *models.Pipeline, managerID string, now time.Time) (slack.Block, error) {
+	percent := pipeline.CompletedSteps() * 100 / len(pipeline.Steps)
+	text := fmt.Sprintf("*%s*: step %d of %d (%d%% complete)\n`%s`\n",
+		strings.Title(pipeline.Name),
+		pipeline.CurrentStep+1,
+		len(pipeline.Steps),
+		percent,
+		pipeline.Steps[pipeline.CurrentStep])
+
+	days, ok := pipeline.DaysOnStep(now)
+	switch {
+	case !ok:
+		text += "Time on this step is unknown"
+	case days >= hireStatusOverdueDays:
+		text += fmt.Sprintf(":warning: *Overdue*: stuck on this step for %d days", days)
+	default:
+		text += fmt.Sprintf("On this step for %d day(s)", days)
+	}
+
+	value, err := json.Marshal(hireStatusValue{
+		Candidate: pipeline.Name,
+		Step:      pipeline.CurrentStep,
+		StepText:  pipeline.Steps[pipeline.CurrentStep],
+		Done:      true,
+		ManagerID: managerID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	buttonText := slack.NewTextBlockObject(slack.PlainTextType, "Mark step done", false, false)
+	button := slack.NewButtonBlockElement(ActionHireStatusStepDone, string(value), buttonText)
+	textBlock := slack.NewTextBlockObject(slack.MarkdownType, text, false, false)
+	return slack.NewSectionBlock(textBlock, nil, slack.NewAccessory(button)), nil
+}
+
+// NewHireStatusBlockAction returns a handler for the "mark step done" buttons on the hire status dashboard.
+// The step is completed on behalf of the user that pressed the button, and the dashboard is refreshed.
+func NewHireStatusBlockAction(store db.Store) func(slack.InteractionCallback) (*slack.Message, error) {
+	return func(req slack.InteractionCallback) (*slack.Message, error) {
+		var value hireStatusValue
+		if err := json.Unmarshal([]byte(req.ActionCallback.BlockActions[0].Value), &value); err != nil {
+			return nil, err
+		}
+
+		err := editHiringPipeline(store, req.User.ID, value.Candidate, func(pipeline *models.Pipeline) (string, error) {
+			if err := value.checkStep(pipeline); err != nil {
+				return "", err
+			}
+
+			if pipeline.IsComplete(value.Step) {
+				return "", slackbot.NewUserInputErrorf("That step of *%s's* hiring pipeline has already been completed", strings.Title(value.Candidate))
+			}
+
+			pipeline.CompleteStep(value.Step)
+			return fmt.Sprintf("Completed step %d: %s", value.Step+1, pipeline.Steps[value.Step]), nil
+		})
+
+		if err != nil {
+			return nil, slashCommandError(err)
+		}
+
+		blocks, err := readHireStatusBlocks(store, value.ManagerID)
+		if err != nil {
+			return nil, err
+		}
+
+		msg := slack.NewBlockMessage(blocks...)
+		msg.ReplaceOriginal = true
+		return &msg, nil
+	}
+}
+
+// readHireStatusBlocks renders the hire status dashboard from the pipelines and candidates in store
+func readHireStatusBlocks(store db.Store, managerID string) ([]slack.Block, error) {
+	pipelines := models.Pipelines{}
+	if err := store.Read(db.PipelinesKey, &pipelines); err != nil {
+		return nil, err
+	}
+
+	candidates := models.Candidates{}
+	if err := store.Read(db.CandidatesKey, &candidates); err != nil {
+		return nil, err
+	}
+
+	return newHireStatusBlocks(pipelines, candidates, managerID, time.Now())
+}
+
+func candidateOrNil(candidates models.Candidates, name string) *models.Candidate {
+	candidate, _ := candidates.Get(name)
+	return candidate
+}
@@ -0,0 +1,187 @@
+package bot
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/quintilesims/iqvbot/db"
+	"github.com/quintilesims/iqvbot/models"
+	"github.com/quintilesims/iqvbot/slash"
+	"github.com/stretchr/testify/assert"
+)
+
+func newHireStatusTestData(now time.Time) (models.Pipelines, models.Candidates) {
+	pipelines := models.Pipelines{
+		{Name: "alice", Type: models.HiringPipelineType, Steps: []string{"a", "b"}, StepUpdated: now.Add(-time.Hour * 24 * 10)},
+		{Name: "bob", Type: models.HiringPipelineType, Steps: []string{"a", "b"}, CurrentStep: 1, StepUpdated: now},
+		{Name: "carol", Type: models.HiringPipelineType, Steps: []string{"a"}, CurrentStep: 1},
+		{Name: "dave", Type: "other", Steps: []string{"a"}},
+	}
+
+	candidates := models.Candidates{
+		{Name: "Alice", ManagerID: "m1"},
+		{Name: "Bob", ManagerID: "m2"},
+		{Name: "Carol", ManagerID: "m1"},
+		{Name: "Dave", ManagerID: "m1"},
+	}
+
+	return pipelines, candidates
+}
+
+func sectionTexts(blocks []slack.Block) []string {
+	texts := []string{}
+	for _, block := range blocks {
+		if section, ok := block.(*slack.SectionBlock); ok {
+			texts = append(texts, section.Text.Text)
+		}
+	}
+
+	return texts
+}
+
+func TestHireStatusBlocks(t *testing.T) {
+	now := time.Now()
+	pipelines, candidates := newHireStatusTestData(now)
+
+	blocks, err := newHireStatusBlocks(pipelines, candidates, "", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"*Hiring status*: 2 active pipeline(s), 1 overdue",
+		"*Manager*: <@m1>",
+		"*Alice*: step 1 of 2 (0% complete)\n`a`\n:warning: *Overdue*: stuck on this step for 10 days",
+		"*Manager*: <@m2>",
+		"*Bob*: step 2 of 2 (50% complete)\n`b`\nOn this step for 0 day(s)",
+	}
+
+	assert.Equal(t, expected, sectionTexts(blocks))
+
+	button := blocks[3].(*slack.SectionBlock).Accessory.ButtonElement
+	assert.Equal(t, ActionHireStatusStepDone, button.ActionID)
+	assert.JSONEq(t, `{"candidate": "alice", "step": 0, "step_text": "a", "done": true}`, button.Value)
+}
+
+func TestHireStatusBlocksMine(t *testing.T) {
+	now := time.Now()
+	pipelines, candidates := newHireStatusTestData(now)
+
+	blocks, err := newHireStatusBlocks(pipelines, candidates, "m2", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	texts := sectionTexts(blocks)
+	assert.Len(t, texts, 3)
+	assert.Equal(t, "*Manager*: <@m2>", texts[1])
+
+	blocks, err = newHireStatusBlocks(pipelines, candidates, "m3", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{"There aren't any active hiring pipelines at the moment"}, sectionTexts(blocks))
+}
+
+func newHireStatusCallback(t *testing.T, userID string, value hireStatusValue) slack.InteractionCallback {
+	b, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := slack.InteractionCallback{User: slack.User{ID: userID}}
+	req.ActionCallback.BlockActions = []*slack.BlockAction{
+		{ActionID: ActionHireStatusStepDone, Value: string(b)},
+	}
+
+	return req
+}
+
+func TestHireStatusBlockAction(t *testing.T) {
+	store := newMemoryStore(t)
+	pipelines, candidates := newHireStatusTestData(time.Now())
+	if err := store.Write(db.PipelinesKey, pipelines); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Write(db.CandidatesKey, candidates); err != nil {
+		t.Fatal(err)
+	}
+
+	action := NewHireStatusBlockAction(store)
+	msg, err := action(newHireStatusCallback(t, "m1", hireStatusValue{Candidate: "alice", Step: 0, StepText: "a"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, msg.ReplaceOriginal)
+	assert.Contains(t, sectionTexts(msg.Blocks.BlockSet)[2], "step 2 of 2 (50% complete)")
+
+	result := models.Pipelines{}
+	if err := store.Read(db.PipelinesKey, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, result[0].CurrentStep)
+
+	// pressing the same button twice should not complete the next step
+	if _, err := action(newHireStatusCallback(t, "m1", hireStatusValue{Candidate: "alice", Step: 0, StepText: "a"})); err == nil {
+		t.Fatal("Error was nil!")
+	} else if _, ok := err.(*slash.SlackMessageError); !ok {
+		t.Fatalf("Error was not SlackMessageError: %#v", err)
+	}
+}
+
+func TestHireStatusBlockActionPermissionDenied(t *testing.T) {
+	store := newMemoryStore(t)
+	pipelines, candidates := newHireStatusTestData(time.Now())
+	if err := store.Write(db.PipelinesKey, pipelines); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Write(db.CandidatesKey, candidates); err != nil {
+		t.Fatal(err)
+	}
+
+	action := NewHireStatusBlockAction(store)
+	_, err := action(newHireStatusCallback(t, "m2", hireStatusValue{Candidate: "alice", Step: 0, StepText: "a"}))
+	if _, ok := err.(*slash.SlackMessageError); !ok {
+		t.Fatalf("Error was not SlackMessageError: %#v", err)
+	}
+}
+
+func TestHireStatusBlockActionStaleStep(t *testing.T) {
+	store := newMemoryStore(t)
+	pipelines, candidates := newHireStatusTestData(time.Now())
+	if err := store.Write(db.PipelinesKey, pipelines); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Write(db.CandidatesKey, candidates); err != nil {
+		t.Fatal(err)
+	}
+
+	// the dashboard was rendered before alice's steps were moved or removed
+	action := NewHireStatusBlockAction(store)
+	for _, value := range []hireStatusValue{
+		{Candidate: "alice", Step: 0, StepText: "b"},
+		{Candidate: "alice", Step: 2, StepText: "c"},
+	} {
+		_, err := action(newHireStatusCallback(t, "m1", value))
+		if e, ok := err.(*slash.SlackMessageError); !ok {
+			t.Fatalf("Error was not SlackMessageError: %#v", err)
+		} else {
+			assert.Contains(t, e.Text, "have changed")
+		}
+	}
+
+	result := models.Pipelines{}
+	if err := store.Read(db.PipelinesKey, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 0, result[0].CurrentStep)
+}
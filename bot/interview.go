@@ -0,0 +1,463 @@
+package bot
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/quintilesims/iqvbot/auth"
+	"github.com/quintilesims/iqvbot/db"
+	"github.com/quintilesims/iqvbot/models"
+	"github.com/quintilesims/iqvbot/slash"
+	"github.com/quintilesims/iqvbot/utils"
+	"github.com/urfave/cli"
+	"github.com/zpatrick/slackbot"
+)
+
+// The format used to parse the --at flag of interview commands
+const interviewTimeFormat = "2006-01-02 15:04"
+
+// NewInterviewCommand create a cli.Command that allows users to add, list, reschedule, and remove interviews.
+// Interviews are shared with the /interview slash command.
+// The msg is the slack message that invoked the command; its user is used for authorization.
+// Interviewers are sent calendar invites through the client when interviews are scheduled, rescheduled, or cancelled.
+func NewInterviewCommand(store db.Store, client utils.SlackClient, msg slack.Msg, w io.Writer) cli.Command {
+	userID := msg.User
+	return cli.Command{
+		Name:  "interview",
+		Usage: "manage interviews",
+		Subcommands: []cli.Command{
+			{
+				Name:      "add",
+				Usage:     "schedule an interview",
+				ArgsUsage: "CANDIDATE",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "at",
+						Usage: fmt.Sprintf("The time of the interview in '%s' format, in your time zone (default: the %s setting)", interviewTimeFormat, models.SettingTimeZone),
+					},
+					cli.StringSliceFlag{
+						Name:  "with",
+						Usage: "The @users conducting the interview; additional @users may follow the first",
+					},
+					cli.DurationFlag{
+						Name:  "remind",
+						Usage: fmt.Sprintf("How long before the interview to remind the interviewers (default: the %s setting)", models.SettingInterviewReminder),
+					},
+					cli.BoolFlag{
+						Name:  "override",
+						Usage: "Schedule the interview even if it is in the past or an interviewer is already booked",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					if err := auth.Authorize(store, userID, auth.ActionInterviewAdd); err != nil {
+						return err
+					}
+
+					// '--with @a @b' leaves '@b' in the arguments, so mentions are treated as interviewers
+					var candidateTokens []string
+					interviewerIDs := []string{}
+					for _, arg := range append(c.StringSlice("with"), c.Args()...) {
+						if interviewerID, err := slackbot.ParseUserID(arg); err == nil {
+							interviewerIDs = append(interviewerIDs, interviewerID)
+							continue
+						}
+
+						candidateTokens = append(candidateTokens, arg)
+					}
+
+					candidate := strings.Join(candidateTokens, " ")
+					if candidate == "" {
+						return slackbot.NewUserInputError("Argument CANDIDATE is required")
+					}
+
+					if len(interviewerIDs) == 0 {
+						interviewerIDs = append(interviewerIDs, userID)
+					}
+
+					config, err := readConfig(store)
+					if err != nil {
+						return err
+					}
+
+					loc := userLocation(client, userID, config)
+					t, err := parseInterviewTime(c.String("at"), loc)
+					if err != nil {
+						return err
+					}
+
+					reminder := config.Duration(models.SettingInterviewReminder)
+					if c.IsSet("remind") {
+						reminder = c.Duration("remind")
+					}
+
+					interview := &models.Interview{
+						InterviewID:    models.NewInterviewID(),
+						Candidate:      strings.Title(candidate),
+						InterviewerIDs: interviewerIDs,
+						Time:           t,
+						Reminder:       reminder,
+					}
+
+					if err := interview.Validate(); err != nil {
+						return slackbot.NewUserInputError(err.Error())
+					}
+
+					interviews := models.Interviews{}
+					if err := store.Read(db.InterviewsKey, &interviews); err != nil {
+						return err
+					}
+
+					if !c.Bool("override") {
+						if err := checkInterviewConflicts(interviews, interview, nil, config, loc); err != nil {
+							return err
+						}
+					}
+
+					interviews = append(interviews, interview)
+					if err := store.Write(db.InterviewsKey, interviews); err != nil {
+						return err
+					}
+
+					if err := slash.RecordInterviewEvent(store, userID, nil, interview); err != nil {
+						return err
+					}
+
+					slash.NotifyInterviewers(client, nil, interview, config.Duration(models.SettingInterviewDuration), loc)
+
+					text := fmt.Sprintf("Ok, I've scheduled an interview for %s (id: `%s`)", formatInterview(interview, loc), interview.InterviewID)
+					return slackbot.WriteString(w, text)
+				},
+			},
+			{
+				Name:  "ls",
+				Usage: "list upcoming interviews",
+				Flags: []cli.Flag{
+					cli.BoolFlag{
+						Name:  "all",
+						Usage: "Include interviews that have already happened",
+					},
+					cli.IntFlag{
+						Name:  "limit",
+						Usage: fmt.Sprintf("The maximum number of interviews to display (default: the %s setting)", models.SettingListLimit),
+					},
+				},
+				Action: func(c *cli.Context) error {
+					interviews := models.Interviews{}
+					if err := store.Read(db.InterviewsKey, &interviews); err != nil {
+						return err
+					}
+
+					interviews.Sort()
+
+					config, err := readConfig(store)
+					if err != nil {
+						return err
+					}
+
+					limit, err := listLimit(store, c)
+					if err != nil {
+						return err
+					}
+
+					loc := userLocation(client, userID, config)
+					now := time.Now()
+					text := "Here are the upcoming interviews: \n"
+					var count int
+					for _, interview := range interviews {
+						if count >= limit {
+							break
+						}
+
+						if !c.Bool("all") && interview.Time.Before(now) {
+							continue
+						}
+
+						text += fmt.Sprintf("`%s` %s\n", interview.InterviewID, formatInterview(interview, loc))
+						count++
+					}
+
+					if count == 0 {
+						return slackbot.WriteString(w, "There aren't any upcoming interviews at the moment")
+					}
+
+					return slackbot.WriteString(w, text)
+				},
+			},
+			{
+				Name:  "conflicts",
+				Usage: "list upcoming interviews that have an interviewer booked at the same time",
+				Action: func(c *cli.Context) error {
+					interviews := models.Interviews{}
+					if err := store.Read(db.InterviewsKey, &interviews); err != nil {
+						return err
+					}
+
+					config, err := readConfig(store)
+					if err != nil {
+						return err
+					}
+
+					loc := userLocation(client, userID, config)
+					text := formatInterviewConflicts(interviews, time.Now(), loc, config.Duration(models.SettingInterviewDuration))
+					if text == "" {
+						return slackbot.WriteString(w, "There aren't any interview conflicts at the moment")
+					}
+
+					return slackbot.WriteString(w, "Here are the upcoming interview conflicts: \n"+text)
+				},
+			},
+			{
+				Name:      "show",
+				Usage:     "show information about an interview",
+				ArgsUsage: "ID",
+				Action: func(c *cli.Context) error {
+					interviewID := c.Args().Get(0)
+					if interviewID == "" {
+						return slackbot.NewUserInputError("Argument ID is required")
+					}
+
+					interviews := models.Interviews{}
+					if err := store.Read(db.InterviewsKey, &interviews); err != nil {
+						return err
+					}
+
+					interview, ok := interviews.Get(interviewID)
+					if !ok {
+						return interviewDoesNotExist(interviewID)
+					}
+
+					config, err := readConfig(store)
+					if err != nil {
+						return err
+					}
+
+					loc := userLocation(client, userID, config)
+					text := fmt.Sprintf("Interview `%s`: %s\n", interview.InterviewID, formatInterview(interview, loc))
+					text += fmt.Sprintf("Interviewers will be reminded %d minutes beforehand", int(interview.Reminder.Minutes()))
+					return slackbot.WriteString(w, text)
+				},
+			},
+			{
+				Name:      "reschedule",
+				Usage:     "change the time of an interview",
+				ArgsUsage: "ID",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "at",
+						Usage: fmt.Sprintf("The new time of the interview in '%s' format, in your time zone (default: the %s setting)", interviewTimeFormat, models.SettingTimeZone),
+					},
+					cli.DurationFlag{
+						Name:  "remind",
+						Usage: "How long before the interview to remind the interviewers",
+					},
+					cli.BoolFlag{
+						Name:  "override",
+						Usage: "Reschedule the interview even if it is in the past or an interviewer is already booked",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					interviewID := c.Args().Get(0)
+					if interviewID == "" {
+						return slackbot.NewUserInputError("Argument ID is required")
+					}
+
+					if !c.IsSet("at") && !c.IsSet("remind") {
+						return slackbot.NewUserInputError("At least one of --at or --remind is required")
+					}
+
+					interviews := models.Interviews{}
+					if err := store.Read(db.InterviewsKey, &interviews); err != nil {
+						return err
+					}
+
+					interview, ok := interviews.Get(interviewID)
+					if !ok {
+						return interviewDoesNotExist(interviewID)
+					}
+
+					if err := auth.Authorize(store, userID, auth.ActionInterviewEdit, interview.InterviewerIDs...); err != nil {
+						return err
+					}
+
+					config, err := readConfig(store)
+					if err != nil {
+						return err
+					}
+
+					previous := *interview
+					loc := userLocation(client, userID, config)
+					if c.IsSet("at") {
+						t, err := parseInterviewTime(c.String("at"), loc)
+						if err != nil {
+							return err
+						}
+
+						interview.Time = t
+					}
+
+					if c.IsSet("remind") {
+						interview.Reminder = c.Duration("remind")
+					}
+
+					if err := interview.Validate(); err != nil {
+						return slackbot.NewUserInputError(err.Error())
+					}
+
+					// changing the reminder can't cause a conflict
+					if c.IsSet("at") && !c.Bool("override") {
+						if err := checkInterviewConflicts(interviews, interview, &previous, config, loc); err != nil {
+							return err
+						}
+					}
+
+					interview.Sequence++
+					if err := store.Write(db.InterviewsKey, interviews); err != nil {
+						return err
+					}
+
+					if err := slash.RecordInterviewEvent(store, userID, &previous, interview); err != nil {
+						return err
+					}
+
+					slash.NotifyInterviewers(client, &previous, interview, config.Duration(models.SettingInterviewDuration), loc)
+
+					return slackbot.WriteStringf(w, "Ok, I've rescheduled the interview for %s", formatInterview(interview, loc))
+				},
+			},
+			{
+				Name:      "rm",
+				Usage:     "cancel an interview",
+				ArgsUsage: "ID",
+				Action: func(c *cli.Context) error {
+					interviewID := c.Args().Get(0)
+					if interviewID == "" {
+						return slackbot.NewUserInputError("Argument ID is required")
+					}
+
+					interviews := models.Interviews{}
+					if err := store.Read(db.InterviewsKey, &interviews); err != nil {
+						return err
+					}
+
+					interview, ok := interviews.Get(interviewID)
+					if !ok {
+						return interviewDoesNotExist(interviewID)
+					}
+
+					if err := auth.Authorize(store, userID, auth.ActionInterviewRemove, interview.InterviewerIDs...); err != nil {
+						return err
+					}
+
+					interviews.Delete(interviewID)
+					if err := store.Write(db.InterviewsKey, interviews); err != nil {
+						return err
+					}
+
+					if err := slash.RecordInterviewEvent(store, userID, interview, nil); err != nil {
+						return err
+					}
+
+					config, err := readConfig(store)
+					if err != nil {
+						return err
+					}
+
+					slash.NotifyInterviewers(client, interview, nil, config.Duration(models.SettingInterviewDuration), userLocation(client, userID, config))
+
+					return slackbot.WriteStringf(w, "Ok, I've cancelled the interview for *%s*", interview.Candidate)
+				},
+			},
+		},
+	}
+}
+
+// parseInterviewTime parses the --at flag of interview commands in the specified location.
+// Interview times are stored in UTC.
+func parseInterviewTime(input string, loc *time.Location) (time.Time, error) {
+	if input == "" {
+		return time.Time{}, slackbot.NewUserInputError("Flag --at is required")
+	}
+
+	t, err := time.ParseInLocation(interviewTimeFormat, input, loc)
+	if err != nil {
+		return time.Time{}, slackbot.NewUserInputErrorf("'%s' is not a valid time: please use the '%s' format", input, interviewTimeFormat)
+	}
+
+	return t.UTC(), nil
+}
+
+// checkInterviewConflicts returns an error listing the same problems the /interview modal warns about:
+// the interview is in the past, or one of its interviewers has another interview at the same time.
+// The existing interview is nil if the interview is new.
+func checkInterviewConflicts(interviews models.Interviews, interview, existing *models.Interview, config models.Config, loc *time.Location) error {
+	warnings := slash.ConflictWarnings(interviews, *interview, existing, config, loc)
+	if len(warnings) == 0 {
+		return nil
+	}
+
+	return slackbot.NewUserInputErrorf("%s\nUse --override to save the interview anyway", strings.Join(warnings, "\n"))
+}
+
+// formatInterview describes the candidate, time, and interviewers of an interview.
+// The time is displayed in the specified location.
+func formatInterview(interview *models.Interview, loc *time.Location) string {
+	interviewers := []string{}
+	for _, interviewerID := range interview.InterviewerIDs {
+		if interviewerID != "" {
+			interviewers = append(interviewers, slackbot.EscapeUserID(interviewerID))
+		}
+	}
+
+	return fmt.Sprintf("*%s* on *%s* at *%s* with %s",
+		interview.Candidate,
+		interview.Time.In(loc).Format(slash.DateDisplayFormat),
+		interview.Time.In(loc).Format(slash.TimeDisplayFormat),
+		strings.Join(interviewers, ", "))
+}
+
+// formatInterviewConflicts describes each pair of overlapping interviews that share an interviewer,
+// skipping interviews that finished before now. An empty string is returned if there aren't any conflicts.
+func formatInterviewConflicts(interviews models.Interviews, now time.Time, loc *time.Location, defaultDuration time.Duration) string {
+	interviews.Sort()
+
+	var text string
+	for i, interview := range interviews {
+		if interview.End(defaultDuration).Before(now) {
+			continue
+		}
+
+		for _, other := range interviews[i+1:] {
+			if !interview.Overlaps(*other, defaultDuration) {
+				continue
+			}
+
+			interviewers := []string{}
+			for _, interviewerID := range interview.SharedInterviewers(*other) {
+				interviewers = append(interviewers, slackbot.EscapeUserID(interviewerID))
+			}
+
+			if len(interviewers) == 0 {
+				continue
+			}
+
+			text += fmt.Sprintf("%s booked for `%s` *%s* at *%s* and `%s` *%s* at *%s* on *%s*\n",
+				strings.Join(interviewers, ", "),
+				interview.InterviewID,
+				interview.Candidate,
+				interview.Time.In(loc).Format(slash.TimeDisplayFormat),
+				other.InterviewID,
+				other.Candidate,
+				other.Time.In(loc).Format(slash.TimeDisplayFormat),
+				interview.Time.In(loc).Format(slash.DateDisplayFormat))
+		}
+	}
+
+	return text
+}
+
+func interviewDoesNotExist(interviewID string) *slackbot.UserInputError {
+	return slackbot.NewUserInputErrorf("There isn't an interview with the id `%s`", interviewID)
+}
@@ -0,0 +1,83 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/quintilesims/iqvbot/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInterviewTime(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := parseInterviewTime("2026-10-20 14:00", loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, time.Date(2026, 10, 20, 21, 0, 0, 0, time.UTC), result.UTC())
+
+	for _, input := range []string{"", "tomorrow", "2026-10-20", "10/20/2026 14:00"} {
+		if _, err := parseInterviewTime(input, loc); err == nil {
+			t.Errorf("%s: error was nil!", input)
+		}
+	}
+}
+
+func TestFormatInterview(t *testing.T) {
+	interview := &models.Interview{
+		Candidate:      "John Doe",
+		InterviewerIDs: []string{"uid1", "", "uid2"},
+		Time:           time.Date(2026, 10, 20, 21, 0, 0, 0, time.UTC),
+	}
+
+	expected := "*John Doe* on *Tuesday, October 20* at *2:00 PM* with <@uid1>, <@uid2>"
+	assert.Equal(t, expected, formatInterview(interview, time.FixedZone("PDT", -7*60*60)))
+}
+
+func TestFormatInterviewConflicts(t *testing.T) {
+	now := time.Date(2026, 10, 20, 12, 0, 0, 0, time.UTC)
+	other := &models.Interview{InterviewID: "c", Candidate: "Jim Doe", InterviewerIDs: []string{"uid3"}, Time: now.Add(time.Hour * 2)}
+	later := &models.Interview{InterviewID: "d", Candidate: "Joe Doe", InterviewerIDs: []string{"uid1"}, Time: now.Add(time.Hour * 4)}
+	interviews := models.Interviews{
+		{InterviewID: "b", Candidate: "Jane Doe", InterviewerIDs: []string{"uid1", "uid2"}, Time: now.Add(time.Hour * 2).Add(time.Minute * 30)},
+		{InterviewID: "a", Candidate: "John Doe", InterviewerIDs: []string{"uid2", "uid1"}, Time: now.Add(time.Hour * 2)},
+		other,
+		later,
+		{InterviewID: "past1", Candidate: "Old Doe", InterviewerIDs: []string{"uid1"}, Time: now.Add(-time.Hour * 3)},
+		{InterviewID: "past2", Candidate: "Old Doe", InterviewerIDs: []string{"uid1"}, Time: now.Add(-time.Hour * 3)},
+	}
+
+	expected := "<@uid2>, <@uid1> booked for `a` *John Doe* at *2:00 PM* and `b` *Jane Doe* at *2:30 PM* on *Tuesday, October 20*\n"
+	assert.Equal(t, expected, formatInterviewConflicts(interviews, now, time.UTC, time.Hour))
+	assert.Equal(t, "", formatInterviewConflicts(models.Interviews{other, later}, now, time.UTC, time.Hour))
+}
+
+func TestCheckInterviewConflicts(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Minute)
+	booked := &models.Interview{InterviewID: "a", Candidate: "Jane Doe", InterviewerIDs: []string{"uid1"}, Time: now.Add(time.Hour * 2)}
+	interviews := models.Interviews{booked}
+	config := models.Config{}
+
+	free := &models.Interview{InterviewID: "b", Candidate: "John Doe", InterviewerIDs: []string{"uid2"}, Time: booked.Time}
+	assert.NoError(t, checkInterviewConflicts(interviews, free, nil, config, time.UTC))
+
+	busy := &models.Interview{InterviewID: "b", Candidate: "John Doe", InterviewerIDs: []string{"uid1"}, Time: booked.Time}
+	if err := checkInterviewConflicts(interviews, busy, nil, config, time.UTC); assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "<@uid1>")
+		assert.Contains(t, err.Error(), "--override")
+	}
+
+	past := &models.Interview{InterviewID: "b", Candidate: "John Doe", InterviewerIDs: []string{"uid2"}, Time: now.Add(-time.Hour)}
+	assert.Error(t, checkInterviewConflicts(interviews, past, nil, config, time.UTC))
+
+	// interviews that already happened can be edited without moving them
+	assert.NoError(t, checkInterviewConflicts(interviews, past, past, config, time.UTC))
+
+	// the interview doesn't conflict with itself
+	assert.NoError(t, checkInterviewConflicts(interviews, booked, booked, config, time.UTC))
+}
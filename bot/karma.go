@@ -3,52 +3,330 @@ package bot
 import (
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/nlopes/slack"
 
+	"github.com/quintilesims/iqvbot/auth"
 	"github.com/quintilesims/iqvbot/db"
 	"github.com/quintilesims/iqvbot/models"
+	"github.com/quintilesims/iqvbot/utils"
 	glob "github.com/ryanuber/go-glob"
 	"github.com/urfave/cli"
 	"github.com/zpatrick/slackbot"
 )
 
 // NewKarmaBehavior returns a behavior that updates karma in the provided store.
-// Karma updates are triggered by the presence of '++', '--', '+-', or '-+' at the end of a message.
-func NewKarmaBehavior(store db.Store) slackbot.Behavior {
+// Karma updates are triggered by '++', '--', '+-', or '-+' following any token in a message,
+// e.g. 'thanks alice++ and (the build team)++ for the fix'.
+// The votes produced by each message are tracked so that editing a message re-applies its votes,
+// and deleting a message reverts them.
+// The new totals for each updated entry are sent back to the message's channel.
+func NewKarmaBehavior(store db.Store, client slackbot.SlackClient) slackbot.Behavior {
 	return func(e slack.RTMEvent) error {
 		d, ok := e.Data.(*slack.MessageEvent)
 		if !ok {
 			return nil
 		}
 
-		var update func(models.KarmaEntry) models.KarmaEntry
-		switch {
-		case strings.HasSuffix(d.Msg.Text, "++"):
-			update = func(e models.KarmaEntry) models.KarmaEntry { e.Upvotes += 1; return e }
-		case strings.HasSuffix(d.Msg.Text, "--"):
-			update = func(e models.KarmaEntry) models.KarmaEntry { e.Downvotes += 1; return e }
-		case strings.HasSuffix(d.Msg.Text, "+-"), strings.HasSuffix(d.Msg.Text, "-+"):
-			update = func(e models.KarmaEntry) models.KarmaEntry { e.Upvotes += 1; e.Downvotes += 1; return e }
+		timestamp, text := d.Timestamp, d.Msg.Text
+		switch d.SubType {
+		case "message_changed":
+			if d.SubMessage == nil {
+				return nil
+			}
+
+			timestamp, text = d.SubMessage.Timestamp, d.SubMessage.Text
+		case "message_deleted":
+			timestamp, text = d.DeletedTimestamp, ""
+		}
+
+		votes := parseKarmaVotes(text)
+		if len(votes) == 0 && d.SubType != "message_changed" && d.SubType != "message_deleted" {
+			return nil
+		}
+
+		history := models.KarmaHistory{}
+		if err := store.Read(db.KarmaHistoryKey, &history); err != nil {
+			return err
+		}
+
+		messageID := models.KarmaMessageID(d.Channel, timestamp)
+		message, ok := history[messageID]
+		switch d.SubType {
+		case "message_changed":
+			if ok && sameKarmaVotes(message.Votes, votes) {
+				return nil
+			}
+
+			// untracked messages either had no votes, are too old to be edited,
+			// or were posted before the current season started and cleared the history
+			if !ok {
+				t := parseTimestamp(timestamp)
+				if len(votes) == 0 || time.Since(t) > models.KarmaHistoryExpiry {
+					return nil
+				}
+
+				seasonStart, err := currentKarmaSeasonStart(store)
+				if err != nil {
+					return err
+				}
+
+				if t.Before(seasonStart) {
+					return nil
+				}
+
+				message = &models.KarmaMessage{Time: t}
+			}
+		case "message_deleted":
+			if !ok {
+				return nil
+			}
+		default:
+			message = &models.KarmaMessage{Time: parseTimestamp(timestamp)}
+		}
+
+		karma := models.Karma{}
+		if err := store.Read(db.KarmaKey, &karma); err != nil {
+			return err
+		}
+
+		votes = stampKarmaVotes(message.Votes, votes, time.Now().UTC())
+		for _, vote := range message.Votes {
+			karma.Revert(vote)
+		}
+
+		var response string
+		for _, vote := range votes {
+			entry := karma.Apply(vote)
+			response += fmt.Sprintf("*%s*: %d\n", vote.Key, entry.Upvotes-entry.Downvotes)
+		}
+
+		if d.SubType == "message_deleted" {
+			for _, vote := range message.Reactions {
+				karma.Revert(vote)
+			}
+
+			delete(history, messageID)
+		} else if timestamp != "" {
+			message.Votes = votes
+			history[messageID] = message
+		}
+
+		if err := store.Write(db.KarmaKey, karma); err != nil {
+			return err
+		}
+
+		if err := store.Write(db.KarmaHistoryKey, history); err != nil {
+			return err
+		}
+
+		if response == "" {
+			return nil
+		}
+
+		_, _, _, err := client.SendMessage(d.Channel, slack.MsgOptionText(response, false))
+		return err
+	}
+}
+
+func sameKarmaVotes(a, b []models.KarmaVote) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !a[i].Same(b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// stampKarmaVotes sets when each of the votes was applied.
+// Votes that were already in the previous version of a message keep their original time,
+// so editing a message doesn't reset the decay of the votes it kept.
+func stampKarmaVotes(previous, votes []models.KarmaVote, now time.Time) []models.KarmaVote {
+	used := make([]bool, len(previous))
+	stamped := make([]models.KarmaVote, len(votes))
+	for i, vote := range votes {
+		vote.Applied = now
+		for j, p := range previous {
+			if !used[j] && p.Same(vote) {
+				vote.Applied = p.Applied
+				used[j] = true
+				break
+			}
+		}
+
+		stamped[i] = vote
+	}
+
+	return stamped
+}
+
+// currentKarmaSeasonStart returns when the current karma season started,
+// or the zero time if a season has never been started
+func currentKarmaSeasonStart(store db.Store) (time.Time, error) {
+	seasons := models.KarmaSeasons{}
+	if err := store.Read(db.KarmaSeasonsKey, &seasons); err != nil {
+		return time.Time{}, err
+	}
+
+	current, ok := seasons.Current()
+	if !ok {
+		return time.Time{}, nil
+	}
+
+	return current.Start, nil
+}
+
+// parseTimestamp converts a slack timestamp, e.g. '1525215129.000001', into a time.Time.
+// If the timestamp is invalid, the current time is returned.
+func parseTimestamp(timestamp string) time.Time {
+	seconds, err := strconv.ParseFloat(timestamp, 64)
+	if err != nil {
+		return time.Now().UTC()
+	}
+
+	return time.Unix(int64(seconds), 0).UTC()
+}
+
+// DefaultKarmaReactions maps emoji reactions to the karma they grant the author of a message
+var DefaultKarmaReactions = map[string]int{
+	"+1":         1,
+	"thumbsup":   1,
+	"tada":       1,
+	"clap":       1,
+	"heart":      1,
+	"-1":         -1,
+	"thumbsdown": -1,
+}
+
+// ParseKarmaReactions parses inputs in emoji=delta format, e.g. 'tada=1' or '-1=-1'
+func ParseKarmaReactions(inputs []string) (map[string]int, error) {
+	reactions := map[string]int{}
+	for _, input := range inputs {
+		i := strings.LastIndex(input, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("'%s' is not in proper emoji=delta format", input)
+		}
+
+		delta, err := strconv.Atoi(input[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("'%s' is not in proper emoji=delta format", input)
+		}
+
+		reactions[strings.Trim(input[:i], ":")] = delta
+	}
+
+	return reactions, nil
+}
+
+// NewReactionKarmaBehavior returns a behavior that updates karma in the provided store
+// when users add or remove emoji reactions on a message.
+// The author of the reacted message is granted the karma for that emoji in reactions.
+// Reactions on a user's own message and on messages authored by bots are ignored.
+func NewReactionKarmaBehavior(store db.Store, client utils.SlackClient, reactions map[string]int) slackbot.Behavior {
+	return func(e slack.RTMEvent) error {
+		var added bool
+		var userID, authorID, reaction, channelID, timestamp string
+		switch d := e.Data.(type) {
+		case *slack.ReactionAddedEvent:
+			added = true
+			userID, authorID, reaction = d.User, d.ItemUser, d.Reaction
+			channelID, timestamp = d.Item.Channel, d.Item.Timestamp
+		case *slack.ReactionRemovedEvent:
+			userID, authorID, reaction = d.User, d.ItemUser, d.Reaction
+			channelID, timestamp = d.Item.Channel, d.Item.Timestamp
 		default:
 			return nil
 		}
 
+		// strip skin tones, e.g. '+1::skin-tone-2'
+		if i := strings.Index(reaction, "::"); i != -1 {
+			reaction = reaction[:i]
+		}
+
+		delta, ok := reactions[reaction]
+		if !ok || delta == 0 || authorID == "" || authorID == userID {
+			return nil
+		}
+
+		author, err := client.GetUserInfo(authorID)
+		if err != nil {
+			return err
+		}
+
+		if author.IsBot {
+			return nil
+		}
+
+		vote := models.KarmaVote{Key: slackbot.EscapeUserID(authorID)}
+		if delta > 0 {
+			vote.Upvotes = delta
+		} else {
+			vote.Downvotes = -delta
+		}
+
 		karma := models.Karma{}
 		if err := store.Read(db.KarmaKey, &karma); err != nil {
 			return err
 		}
 
-		// strip '++', '--', etc. from key
-		key := d.Msg.Text[:len(d.Msg.Text)-2]
-		karma[key] = update(karma[key])
-		return store.Write(db.KarmaKey, karma)
+		history := models.KarmaHistory{}
+		if err := store.Read(db.KarmaHistoryKey, &history); err != nil {
+			return err
+		}
+
+		// track reactions so their votes are reverted if the message is deleted
+		messageID := models.KarmaMessageID(channelID, timestamp)
+		message, ok := history[messageID]
+		if added {
+			if !ok {
+				message = &models.KarmaMessage{Time: parseTimestamp(timestamp)}
+				history[messageID] = message
+			}
+
+			vote.Applied = time.Now().UTC()
+			karma.Apply(vote)
+			message.Reactions = append(message.Reactions, vote)
+		} else {
+			// reactions that aren't tracked were added before the history expired or the season started,
+			// so their votes aren't in the current karma.
+			// The tracked reaction knows when it was applied, so only its decayed weight is reverted.
+			tracked := false
+			for i := 0; ok && i < len(message.Reactions); i++ {
+				if message.Reactions[i].Same(vote) {
+					vote = message.Reactions[i]
+					message.Reactions = append(message.Reactions[:i], message.Reactions[i+1:]...)
+					tracked = true
+					break
+				}
+			}
+
+			if !tracked {
+				return nil
+			}
+
+			karma.Revert(vote)
+		}
+
+		if err := store.Write(db.KarmaKey, karma); err != nil {
+			return err
+		}
+
+		return store.Write(db.KarmaHistoryKey, history)
 	}
 }
 
-// NewKarmaCommand returns a cli.Command that displays karma
-func NewKarmaCommand(store db.Store, w io.Writer) cli.Command {
+// NewKarmaCommand returns a cli.Command that displays karma and manages karma seasons.
+// The msg is the slack message that invoked the command; only admins may start a new season.
+func NewKarmaCommand(store db.Store, msg slack.Msg, w io.Writer) cli.Command {
+	userID := msg.User
 	return cli.Command{
 		Name:      "karma",
 		Usage:     "display karma entries that match the given GLOB pattern",
@@ -63,6 +341,15 @@ func NewKarmaCommand(store db.Store, w io.Writer) cli.Command {
 				Name:  "ascending",
 				Usage: "Show results in ascending order",
 			},
+			cli.StringFlag{
+				Name:  "season",
+				Usage: "Show results from the season with the specified name",
+			},
+			cli.StringFlag{
+				Name:  "sort",
+				Value: models.KarmaSortTotal,
+				Usage: "Sort results by their 'total' or time-'decay'ed score",
+			},
 		},
 		Action: func(c *cli.Context) error {
 			g := c.Args().Get(0)
@@ -70,11 +357,33 @@ func NewKarmaCommand(store db.Store, w io.Writer) cli.Command {
 				return slackbot.NewUserInputError("Argument GLOB is required")
 			}
 
+			mode := c.String("sort")
+			if mode != models.KarmaSortTotal && mode != models.KarmaSortDecay {
+				return slackbot.NewUserInputErrorf("Sort must be '%s' or '%s'", models.KarmaSortTotal, models.KarmaSortDecay)
+			}
+
 			karma := models.Karma{}
 			if err := store.Read(db.KarmaKey, &karma); err != nil {
 				return err
 			}
 
+			if name := c.String("season"); name != "" {
+				seasons := models.KarmaSeasons{}
+				if err := store.Read(db.KarmaSeasonsKey, &seasons); err != nil {
+					return err
+				}
+
+				season, ok := seasons.Get(name)
+				if !ok {
+					return karmaSeasonDoesNotExist(name)
+				}
+
+				// the current season's karma is not archived
+				if !season.End.IsZero() {
+					karma = season.Karma
+				}
+			}
+
 			matches := models.Karma{}
 			for k, v := range karma {
 				if glob.Glob(g, k) {
@@ -82,14 +391,23 @@ func NewKarmaCommand(store db.Store, w io.Writer) cli.Command {
 				}
 			}
 
-			keys := matches.SortKeys(c.Bool("ascending"))
+			keys := matches.SortKeys(mode, c.Bool("ascending"))
 			if len(keys) == 0 {
 				return slackbot.NewUserInputErrorf("Could not find any karma entries matching *%s*", g)
 			}
 
+			now := time.Now().UTC()
 			var text string
 			for i := 0; i < len(keys) && i < c.Int("count"); i++ {
 				entry := matches[keys[i]]
+				if mode == models.KarmaSortDecay {
+					text += fmt.Sprintf("*%s*: %.1f (%d total)\n",
+						keys[i],
+						entry.DecayedScore(now),
+						entry.Upvotes-entry.Downvotes)
+					continue
+				}
+
 				text += fmt.Sprintf("*%s*: %d (%d upvotes, %d downvotes)\n",
 					keys[i],
 					entry.Upvotes-entry.Downvotes,
@@ -99,5 +417,111 @@ func NewKarmaCommand(store db.Store, w io.Writer) cli.Command {
 
 			return slackbot.WriteString(w, text)
 		},
+		Subcommands: []cli.Command{
+			{
+				Name:  "season",
+				Usage: "manage karma seasons",
+				Subcommands: []cli.Command{
+					{
+						Name:      "start",
+						Usage:     "archive the current karma and start a new season",
+						ArgsUsage: "NAME",
+						Action: func(c *cli.Context) error {
+							// starting a season archives everyone's karma and karma history
+							if err := auth.Authorize(store, userID, auth.ActionKarmaSeason); err != nil {
+								return err
+							}
+
+							name := strings.Join(c.Args(), " ")
+							if name == "" {
+								return slackbot.NewUserInputError("Argument NAME is required")
+							}
+
+							seasons := models.KarmaSeasons{}
+							if err := store.Read(db.KarmaSeasonsKey, &seasons); err != nil {
+								return err
+							}
+
+							current, ok := seasons.Current()
+							if !ok {
+								current = &models.KarmaSeason{Name: models.DefaultKarmaSeasonName}
+								seasons = append(seasons, current)
+							}
+
+							if _, ok := seasons.Get(name); ok {
+								return slackbot.NewUserInputErrorf("A karma season named *%s* already exists", name)
+							}
+
+							karma := models.Karma{}
+							if err := store.Read(db.KarmaKey, &karma); err != nil {
+								return err
+							}
+
+							now := time.Now().UTC()
+							current.End = now
+							current.Karma = karma
+							seasons = append(seasons, &models.KarmaSeason{Name: name, Start: now})
+
+							if err := store.Write(db.KarmaSeasonsKey, seasons); err != nil {
+								return err
+							}
+
+							if err := store.Write(db.KarmaKey, models.Karma{}); err != nil {
+								return err
+							}
+
+							// votes from the archived season can no longer be reverted
+							if err := store.Write(db.KarmaHistoryKey, models.KarmaHistory{}); err != nil {
+								return err
+							}
+
+							text := fmt.Sprintf("Ok, I've archived the *%s* season and started the *%s* season.\n", current.Name, name)
+							text += fmt.Sprintf("You can view the archived leaderboard by running `!karma --season \"%s\" *`", current.Name)
+							return slackbot.WriteString(w, text)
+						},
+					},
+					{
+						Name:  "ls",
+						Usage: "list karma seasons",
+						Action: func(c *cli.Context) error {
+							seasons := models.KarmaSeasons{}
+							if err := store.Read(db.KarmaSeasonsKey, &seasons); err != nil {
+								return err
+							}
+
+							if len(seasons) == 0 {
+								return slackbot.WriteString(w, "There aren't any karma seasons at the moment")
+							}
+
+							text := "Here are the karma seasons I have: \n"
+							for _, season := range seasons {
+								if season.End.IsZero() {
+									text += fmt.Sprintf("*%s* (current, started %s)\n", season.Name, season.Start.Format(karmaSeasonDateFormat))
+									continue
+								}
+
+								if season.Start.IsZero() {
+									text += fmt.Sprintf("*%s* (ended %s)\n", season.Name, season.End.Format(karmaSeasonDateFormat))
+									continue
+								}
+
+								text += fmt.Sprintf("*%s* (%s - %s)\n",
+									season.Name,
+									season.Start.Format(karmaSeasonDateFormat),
+									season.End.Format(karmaSeasonDateFormat))
+							}
+
+							return slackbot.WriteString(w, text)
+						},
+					},
+				},
+			},
+		},
 	}
 }
+
+const karmaSeasonDateFormat = "January 2, 2006"
+
+func karmaSeasonDoesNotExist(name string) *slackbot.UserInputError {
+	return slackbot.NewUserInputErrorf("I don't have any karma seasons by the name *%s*", name)
+}
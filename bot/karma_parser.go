@@ -0,0 +1,95 @@
+package bot
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/quintilesims/iqvbot/models"
+)
+
+var (
+	// matches ```code blocks``` and `code spans`
+	codeRegex = regexp.MustCompile("(?s)```.*?```|`[^`\n]*`")
+
+	// matches 'token++', '(multi word token)--', '<@USERID>+-', etc.
+	karmaVoteRegex = regexp.MustCompile(`(?:\(([^()]+)\)|([^\s()]+?))(\+\+|--|\+-|-\+)(?:[\s.,!?;:)]|$)`)
+
+	// matches the 'for <reason>' text that follows a vote
+	karmaReasonRegex = regexp.MustCompile(`(?i)^\s*for\s+(.*?)(?:[\s,]+(?:and|but|or))?[\s,]*$`)
+
+	// matches the text that joins votes which share a reason, e.g. 'alice++ and bob++ for ...'
+	karmaConjunctionRegex = regexp.MustCompile(`(?i)^[\s,&]*(?:and)?[\s,&]*$`)
+)
+
+// StripCodeBlocks replaces any code spans and code blocks in text with whitespace
+func StripCodeBlocks(text string) string {
+	return codeRegex.ReplaceAllStringFunc(text, func(s string) string {
+		return strings.Repeat(" ", len(s))
+	})
+}
+
+// parseKarmaVotes returns the karma votes found anywhere in text.
+// Each vote may be followed by 'for <reason>', and votes separated only by
+// commas or 'and' share the reason of the vote that follows them.
+// Text inside of code spans and code blocks is ignored.
+func parseKarmaVotes(text string) []models.KarmaVote {
+	text = StripCodeBlocks(text)
+	matches := karmaVoteRegex.FindAllStringSubmatchIndex(text, -1)
+	votes := make([]models.KarmaVote, 0, len(matches))
+	for i := len(matches) - 1; i >= 0; i-- {
+		m := matches[i]
+
+		var key string
+		if m[2] != -1 {
+			key = strings.TrimSpace(text[m[2]:m[3]])
+		} else {
+			key = text[m[4]:m[5]]
+		}
+
+		if key == "" {
+			continue
+		}
+
+		vote := models.KarmaVote{Key: key}
+		switch text[m[6]:m[7]] {
+		case "++":
+			vote.Upvotes = 1
+		case "--":
+			vote.Downvotes = 1
+		default:
+			vote.Upvotes = 1
+			vote.Downvotes = 1
+		}
+
+		end := len(text)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+
+		between := text[m[7]:end]
+		if j := strings.IndexAny(between, ".!?\n"); j != -1 {
+			between = between[:j]
+		}
+
+		switch {
+		case karmaReasonRegex.MatchString(between):
+			vote.Reason = karmaReasonRegex.FindStringSubmatch(between)[1]
+		case karmaConjunctionRegex.MatchString(between) && len(votes) > 0:
+			vote.Reason = votes[len(votes)-1].Reason
+		}
+
+		votes = append(votes, vote)
+	}
+
+	// votes were parsed in reverse order; flip them and drop duplicate keys
+	result := make([]models.KarmaVote, 0, len(votes))
+	seen := map[string]bool{}
+	for i := len(votes) - 1; i >= 0; i-- {
+		if !seen[votes[i].Key] {
+			seen[votes[i].Key] = true
+			result = append(result, votes[i])
+		}
+	}
+
+	return result
+}
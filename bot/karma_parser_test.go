@@ -0,0 +1,53 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/quintilesims/iqvbot/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKarmaVotes(t *testing.T) {
+	cases := map[string][]models.KarmaVote{
+		"dogs++": {
+			{Key: "dogs", Upvotes: 1},
+		},
+		"cats-- dogs+- birds-+": {
+			{Key: "cats", Downvotes: 1},
+			{Key: "dogs", Upvotes: 1, Downvotes: 1},
+			{Key: "birds", Upvotes: 1, Downvotes: 1},
+		},
+		"thanks alice++ and bob++ for the fix": {
+			{Key: "alice", Upvotes: 1, Reason: "the fix"},
+			{Key: "bob", Upvotes: 1, Reason: "the fix"},
+		},
+		"alice++ for the docs and bob++ for the tests. See you tomorrow": {
+			{Key: "alice", Upvotes: 1, Reason: "the docs"},
+			{Key: "bob", Upvotes: 1, Reason: "the tests"},
+		},
+		"alice++ thanks! bob++": {
+			{Key: "alice", Upvotes: 1},
+			{Key: "bob", Upvotes: 1},
+		},
+		"(the build team)++, <@uid>--": {
+			{Key: "the build team", Upvotes: 1},
+			{Key: "<@uid>", Downvotes: 1},
+		},
+		"dogs++ dogs++ dogs++": {
+			{Key: "dogs", Upvotes: 1},
+		},
+		"run `i++` in a loop":                           {},
+		"```\nfor i := 0; i < 10; i++ {\n\tj--\n}\n```": {},
+		"c++ is great, but x--y is not a vote": {
+			{Key: "c", Upvotes: 1},
+		},
+		"blah blah": {},
+		"()++":      {},
+	}
+
+	for input, expected := range cases {
+		t.Run(input, func(t *testing.T) {
+			assert.Equal(t, expected, parseKarmaVotes(input))
+		})
+	}
+}
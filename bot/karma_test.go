@@ -1,17 +1,34 @@
 package bot
 
 import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
 	"testing"
+	"time"
 
+	"github.com/golang/mock/gomock"
 	"github.com/nlopes/slack"
+	"github.com/quintilesims/iqvbot/auth"
 	"github.com/quintilesims/iqvbot/db"
+	"github.com/quintilesims/iqvbot/mock"
 	"github.com/quintilesims/iqvbot/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/zpatrick/slackbot"
+	"github.com/zpatrick/slackbot/mock_slack"
 )
 
 func TestKarmaBehavior(t *testing.T) {
-	store := db.NewMemoryStore()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockSlackClient := mock_slack.NewMockSlackClient(ctrl)
+
+	mockSlackClient.EXPECT().
+		SendMessage(gomock.Any(), gomock.Any()).
+		Return("", "", "", nil).
+		Times(8)
+
+	store := newMemoryStore(t)
 	karma := models.Karma{
 		"dogs": models.KarmaEntry{Upvotes: 10, Downvotes: 0},
 		"cats": models.KarmaEntry{Upvotes: 0, Downvotes: 10},
@@ -34,30 +51,512 @@ func TestKarmaBehavior(t *testing.T) {
 		{},
 	}
 
-	b := NewKarmaBehavior(store)
+	b := NewKarmaBehavior(store, mockSlackClient)
 	for _, e := range events {
 		if err := b(e); err != nil {
 			t.Fatal(err)
 		}
 	}
 
-	result := models.Karma{}
-	if err := store.Read(db.KarmaKey, &result); err != nil {
-		t.Fatal(err)
-	}
-
-	expected := models.Karma{
+	assertKarmaTotals(t, store, models.Karma{
 		"dogs": {Upvotes: 12, Downvotes: 0},
 		"cats": {Upvotes: 0, Downvotes: 12},
 		"new":  {Upvotes: 3, Downvotes: 3},
+	})
+}
+
+func TestKarmaBehaviorInline(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockSlackClient := mock_slack.NewMockSlackClient(ctrl)
+
+	mockSlackClient.EXPECT().
+		SendMessage("cid", gomock.Any()).
+		Return("", "", "", nil)
+
+	store := newMemoryStore(t)
+	e := slack.RTMEvent{
+		Data: &slack.MessageEvent{
+			Msg: slack.Msg{
+				Channel: "cid",
+				Text:    "thanks alice++ and <@uid>++ for the fix, but (the build)-- for being slow",
+			},
+		},
+	}
+
+	if err := NewKarmaBehavior(store, mockSlackClient)(e); err != nil {
+		t.Fatal(err)
+	}
+
+	assertKarmaTotals(t, store, models.Karma{
+		"alice":     {Upvotes: 1, Reasons: []string{"the fix"}},
+		"<@uid>":    {Upvotes: 1, Reasons: []string{"the fix"}},
+		"the build": {Downvotes: 1, Reasons: []string{"being slow"}},
+	})
+}
+
+func TestKarmaBehaviorEditAndDelete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockSlackClient := mock_slack.NewMockSlackClient(ctrl)
+
+	mockSlackClient.EXPECT().
+		SendMessage("cid", gomock.Any()).
+		Return("", "", "", nil).
+		AnyTimes()
+
+	mockUtilsSlackClient := mock.NewMockSlackClient(ctrl)
+	mockUtilsSlackClient.EXPECT().
+		GetUserInfo("author").
+		Return(&slack.User{ID: "author"}, nil)
+
+	ts := fmt.Sprintf("%d.000100", time.Now().Unix())
+	newMessage := func(text string) slack.RTMEvent {
+		return slack.RTMEvent{Data: &slack.MessageEvent{Msg: slack.Msg{Channel: "cid", Timestamp: ts, Text: text}}}
+	}
+
+	editMessage := func(text string) slack.RTMEvent {
+		return slack.RTMEvent{Data: &slack.MessageEvent{
+			Msg:        slack.Msg{Channel: "cid", SubType: "message_changed"},
+			SubMessage: &slack.Msg{Timestamp: ts, Text: text},
+		}}
+	}
+
+	deleteMessage := func() slack.RTMEvent {
+		return slack.RTMEvent{Data: &slack.MessageEvent{
+			Msg: slack.Msg{Channel: "cid", SubType: "message_deleted", DeletedTimestamp: ts},
+		}}
+	}
+
+	store := newMemoryStore(t)
+	karmaBehavior := NewKarmaBehavior(store, mockSlackClient)
+	reactionBehavior := NewReactionKarmaBehavior(store, mockUtilsSlackClient, DefaultKarmaReactions)
+
+	if err := karmaBehavior(newMessage("foo++ bar++")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := karmaBehavior(editMessage("foo-- for breaking the build")); err != nil {
+		t.Fatal(err)
+	}
+
+	assertKarmaTotals(t, store, models.Karma{
+		"foo": {Upvotes: 0, Downvotes: 1, Reasons: []string{"breaking the build"}},
+		"bar": {Upvotes: 0, Downvotes: 0},
+	})
+
+	reaction := &slack.ReactionAddedEvent{User: "u1", ItemUser: "author", Reaction: "tada"}
+	reaction.Item.Channel = "cid"
+	reaction.Item.Timestamp = ts
+	if err := reactionBehavior(slack.RTMEvent{Data: reaction}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := karmaBehavior(deleteMessage()); err != nil {
+		t.Fatal(err)
+	}
+
+	assertKarmaTotals(t, store, models.Karma{
+		"foo":                           {Upvotes: 0, Downvotes: 0, Reasons: []string{}},
+		"bar":                           {Upvotes: 0, Downvotes: 0},
+		slackbot.EscapeUserID("author"): {Upvotes: 0, Downvotes: 0},
+	})
+
+	history := models.KarmaHistory{}
+	if err := store.Read(db.KarmaHistoryKey, &history); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, history, 0)
+}
+
+func TestKarmaBehaviorEditKeepsDecay(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockSlackClient := mock_slack.NewMockSlackClient(ctrl)
+
+	mockSlackClient.EXPECT().
+		SendMessage("cid", gomock.Any()).
+		Return("", "", "", nil).
+		AnyTimes()
+
+	applied := time.Now().UTC().Add(-models.KarmaHalfLife)
+	ts := fmt.Sprintf("%d.000100", applied.Unix())
+	store := newMemoryStore(t)
+	karma := models.Karma{
+		"foo": {Upvotes: 1, Score: 1, Updated: applied},
+	}
+
+	history := models.KarmaHistory{
+		models.KarmaMessageID("cid", ts): {
+			Time:  applied,
+			Votes: []models.KarmaVote{{Key: "foo", Upvotes: 1, Applied: applied}},
+		},
+	}
+
+	if err := store.Write(db.KarmaKey, karma); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Write(db.KarmaHistoryKey, history); err != nil {
+		t.Fatal(err)
+	}
+
+	e := slack.RTMEvent{Data: &slack.MessageEvent{
+		Msg:        slack.Msg{Channel: "cid", SubType: "message_changed"},
+		SubMessage: &slack.Msg{Timestamp: ts, Text: "foo++ bar++"},
+	}}
+
+	if err := NewKarmaBehavior(store, mockSlackClient)(e); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Read(db.KarmaKey, &karma); err != nil {
+		t.Fatal(err)
+	}
+
+	// the vote that was kept still has half of its weight
+	now := time.Now().UTC()
+	assert.InDelta(t, 0.5, karma["foo"].DecayedScore(now), 0.001)
+	assert.InDelta(t, 1, karma["bar"].DecayedScore(now), 0.001)
+}
+
+func TestKarmaBehaviorIgnoresEditsFromPreviousSeason(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockSlackClient := mock_slack.NewMockSlackClient(ctrl)
+
+	mockSlackClient.EXPECT().
+		SendMessage("cid", gomock.Any()).
+		Return("", "", "", nil)
+
+	store := newMemoryStore(t)
+	now := time.Now().UTC()
+	seasons := models.KarmaSeasons{{Name: "spring", Start: now.Add(-time.Minute)}}
+	if err := store.Write(db.KarmaSeasonsKey, seasons); err != nil {
+		t.Fatal(err)
+	}
+
+	editMessage := func(posted time.Time) slack.RTMEvent {
+		return slack.RTMEvent{Data: &slack.MessageEvent{
+			Msg:        slack.Msg{Channel: "cid", SubType: "message_changed"},
+			SubMessage: &slack.Msg{Timestamp: fmt.Sprintf("%d.000100", posted.Unix()), Text: "foo++"},
+		}}
+	}
+
+	b := NewKarmaBehavior(store, mockSlackClient)
+	if err := b(editMessage(now.Add(-time.Hour))); err != nil {
+		t.Fatal(err)
+	}
+
+	assertKarmaTotals(t, store, models.Karma{})
+
+	// messages posted during the current season may still be edited to add votes
+	if err := b(editMessage(now)); err != nil {
+		t.Fatal(err)
+	}
+
+	assertKarmaTotals(t, store, models.Karma{
+		"foo": {Upvotes: 1},
+	})
+}
+
+func TestReactionKarmaBehavior(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockSlackClient := mock.NewMockSlackClient(ctrl)
+
+	mockSlackClient.EXPECT().
+		GetUserInfo("author").
+		Return(&slack.User{ID: "author"}, nil).
+		AnyTimes()
+
+	mockSlackClient.EXPECT().
+		GetUserInfo("bot").
+		Return(&slack.User{ID: "bot", IsBot: true}, nil).
+		AnyTimes()
+
+	store := newMemoryStore(t)
+	events := []slack.RTMEvent{
+		{Data: &slack.ReactionAddedEvent{User: "u1", ItemUser: "author", Reaction: "tada"}},
+		{Data: &slack.ReactionAddedEvent{User: "u2", ItemUser: "author", Reaction: "+1::skin-tone-3"}},
+		{Data: &slack.ReactionAddedEvent{User: "u3", ItemUser: "author", Reaction: "-1"}},
+		{Data: &slack.ReactionAddedEvent{User: "u4", ItemUser: "author", Reaction: "eyes"}},
+		{Data: &slack.ReactionRemovedEvent{User: "u1", ItemUser: "author", Reaction: "tada"}},
+		{Data: &slack.ReactionAddedEvent{User: "author", ItemUser: "author", Reaction: "tada"}},
+		{Data: &slack.ReactionAddedEvent{User: "u1", ItemUser: "bot", Reaction: "tada"}},
+	}
+
+	b := NewReactionKarmaBehavior(store, mockSlackClient, DefaultKarmaReactions)
+	for _, e := range events {
+		if err := b(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assertKarmaTotals(t, store, models.Karma{
+		slackbot.EscapeUserID("author"): {Upvotes: 1, Downvotes: 1},
+	})
+}
+
+func TestReactionKarmaBehaviorIgnoresUntrackedRemovals(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockSlackClient := mock.NewMockSlackClient(ctrl)
+
+	mockSlackClient.EXPECT().
+		GetUserInfo("author").
+		Return(&slack.User{ID: "author"}, nil).
+		AnyTimes()
+
+	store := newMemoryStore(t)
+	karma := models.Karma{
+		slackbot.EscapeUserID("author"): {Upvotes: 3},
+	}
+
+	if err := store.Write(db.KarmaKey, karma); err != nil {
+		t.Fatal(err)
+	}
+
+	// the reaction was added before the history was cleared by a new season
+	removed := &slack.ReactionRemovedEvent{User: "u1", ItemUser: "author", Reaction: "tada"}
+	removed.Item.Channel = "cid"
+	removed.Item.Timestamp = "1.000100"
+	if err := NewReactionKarmaBehavior(store, mockSlackClient, DefaultKarmaReactions)(slack.RTMEvent{Data: removed}); err != nil {
+		t.Fatal(err)
+	}
+
+	assertKarmaTotals(t, store, models.Karma{
+		slackbot.EscapeUserID("author"): {Upvotes: 3},
+	})
+
+	history := models.KarmaHistory{}
+	if err := store.Read(db.KarmaHistoryKey, &history); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, history, 0)
+}
+
+func TestParseKarmaReactions(t *testing.T) {
+	result, err := ParseKarmaReactions([]string{"tada=1", ":-1:=-1", "rocket=3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]int{
+		"tada":   1,
+		"-1":     -1,
+		"rocket": 3,
 	}
 
 	assert.Equal(t, expected, result)
+
+	for _, input := range []string{"tada", "=1", "tada=one"} {
+		if _, err := ParseKarmaReactions([]string{input}); err == nil {
+			t.Errorf("%s: error was nil!", input)
+		}
+	}
+}
+
+func newKarmaCommandTestStore(t *testing.T) *db.MemoryStore {
+	store := newMemoryStore(t)
+	karma := models.Karma{
+		"alpha": {Upvotes: 3},
+		"beta":  {Upvotes: 5, Downvotes: 3},
+		"gamma": {Upvotes: 1},
+		"other": {Upvotes: 10},
+	}
+
+	if err := store.Write(db.KarmaKey, karma); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Write(db.RolesKey, models.Roles{"admin": {models.RoleAdmin}}); err != nil {
+		t.Fatal(err)
+	}
+
+	return store
 }
 
-// TestKarmaCommandDefaults
-// TestKarmaCommandWithCountFlag
-// TestKarmaCommandWithAscendingFlag
-// TestKarmaCommandUserInputErrors
 func TestKarmaCommandWithDefaults(t *testing.T) {
+	w := bytes.NewBuffer(nil)
+	cmd := NewKarmaCommand(newKarmaCommandTestStore(t), slack.Msg{User: "uid"}, w)
+	if err := slackbot.NewTestApp(cmd, "!karma *a"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "*alpha*: 3 (3 upvotes, 0 downvotes)\n"
+	expected += "*beta*: 2 (5 upvotes, 3 downvotes)\n"
+	expected += "*gamma*: 1 (1 upvotes, 0 downvotes)\n"
+	assert.Equal(t, expected, w.String())
+}
+
+func TestKarmaCommandWithCountFlag(t *testing.T) {
+	w := bytes.NewBuffer(nil)
+	cmd := NewKarmaCommand(newKarmaCommandTestStore(t), slack.Msg{User: "uid"}, w)
+	if err := slackbot.NewTestApp(cmd, "!karma --count 1 *a"); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "*alpha*: 3 (3 upvotes, 0 downvotes)\n", w.String())
+}
+
+func TestKarmaCommandWithAscendingFlag(t *testing.T) {
+	w := bytes.NewBuffer(nil)
+	cmd := NewKarmaCommand(newKarmaCommandTestStore(t), slack.Msg{User: "uid"}, w)
+	if err := slackbot.NewTestApp(cmd, "!karma --ascending --count 2 *a"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "*gamma*: 1 (1 upvotes, 0 downvotes)\n"
+	expected += "*beta*: 2 (5 upvotes, 3 downvotes)\n"
+	assert.Equal(t, expected, w.String())
+}
+
+func TestKarmaCommandWithDecaySort(t *testing.T) {
+	now := time.Now().UTC()
+	karma := models.Karma{
+		"old": {Upvotes: 10, Score: 10, Updated: now.Add(-models.KarmaHalfLife * 2)},
+		"new": {Upvotes: 3, Score: 3, Updated: now},
+	}
+
+	store := newMemoryStore(t)
+	if err := store.Write(db.KarmaKey, karma); err != nil {
+		t.Fatal(err)
+	}
+
+	w := bytes.NewBuffer(nil)
+	cmd := NewKarmaCommand(store, slack.Msg{User: "uid"}, w)
+	if err := slackbot.NewTestApp(cmd, "!karma --sort decay *"); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "*new*: 3.0 (3 total)\n*old*: 2.5 (10 total)\n", w.String())
+
+	w.Reset()
+	if err := slackbot.NewTestApp(cmd, "!karma --sort total *"); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "*old*: 10 (10 upvotes, 0 downvotes)\n*new*: 3 (3 upvotes, 0 downvotes)\n", w.String())
+}
+
+func TestKarmaCommandWithSeasonFlag(t *testing.T) {
+	now := time.Now().UTC()
+	seasons := models.KarmaSeasons{
+		{Name: "Winter", End: now.AddDate(0, -3, 0), Karma: models.Karma{"alpha": {Upvotes: 7}}},
+		{Name: "Spring", Start: now.AddDate(0, -3, 0)},
+	}
+
+	store := newKarmaCommandTestStore(t)
+	if err := store.Write(db.KarmaSeasonsKey, seasons); err != nil {
+		t.Fatal(err)
+	}
+
+	w := bytes.NewBuffer(nil)
+	cmd := NewKarmaCommand(store, slack.Msg{User: "uid"}, w)
+	if err := slackbot.NewTestApp(cmd, "!karma --season winter alpha"); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "*alpha*: 7 (7 upvotes, 0 downvotes)\n", w.String())
+
+	// the current season's karma is read from the current karma
+	w.Reset()
+	if err := slackbot.NewTestApp(cmd, "!karma --season spring alpha"); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "*alpha*: 3 (3 upvotes, 0 downvotes)\n", w.String())
+}
+
+func TestKarmaCommandUserInputErrors(t *testing.T) {
+	inputs := []string{
+		"!karma",
+		"!karma nothing",
+		"!karma --sort score *",
+		"!karma --season summer *",
+	}
+
+	cmd := NewKarmaCommand(newKarmaCommandTestStore(t), slack.Msg{User: "uid"}, ioutil.Discard)
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			err := slackbot.NewTestApp(cmd, input)
+			if _, ok := err.(*slackbot.UserInputError); !ok {
+				t.Fatalf("Error was not UserInputError: %#v", err)
+			}
+		})
+	}
+}
+
+func TestKarmaSeasonStart(t *testing.T) {
+	store := newKarmaCommandTestStore(t)
+	history := models.KarmaHistory{"ts": {Votes: []models.KarmaVote{{Key: "alpha", Upvotes: 1}}}}
+	if err := store.Write(db.KarmaHistoryKey, history); err != nil {
+		t.Fatal(err)
+	}
+
+	w := bytes.NewBuffer(nil)
+	cmd := NewKarmaCommand(store, slack.Msg{User: "admin"}, w)
+	if err := slackbot.NewTestApp(cmd, "!karma season start Spring 2026"); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Contains(t, w.String(), "archived the *preseason* season and started the *Spring 2026* season")
+
+	seasons := models.KarmaSeasons{}
+	if err := store.Read(db.KarmaSeasonsKey, &seasons); err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.Len(t, seasons, 2) {
+		assert.Equal(t, models.DefaultKarmaSeasonName, seasons[0].Name)
+		assert.False(t, seasons[0].End.IsZero())
+		assert.Equal(t, models.Karma{"alpha": {Upvotes: 3}, "beta": {Upvotes: 5, Downvotes: 3}, "gamma": {Upvotes: 1}, "other": {Upvotes: 10}}, seasons[0].Karma)
+
+		current, ok := seasons.Current()
+		if assert.True(t, ok) {
+			assert.Equal(t, "Spring 2026", current.Name)
+			assert.Equal(t, seasons[0].End, current.Start)
+		}
+	}
+
+	// the karma and the history of the archived season are cleared
+	assertKarmaTotals(t, store, models.Karma{})
+
+	resultHistory := models.KarmaHistory{}
+	if err := store.Read(db.KarmaHistoryKey, &resultHistory); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, models.KarmaHistory{}, resultHistory)
+
+	// season names must be unique
+	err := slackbot.NewTestApp(cmd, "!karma season start spring 2026")
+	if _, ok := err.(*slackbot.UserInputError); !ok {
+		t.Fatalf("Error was not UserInputError: %#v", err)
+	}
+}
+
+func TestKarmaSeasonStartPermissionDenied(t *testing.T) {
+	store := newKarmaCommandTestStore(t)
+	cmd := NewKarmaCommand(store, slack.Msg{User: "uid"}, ioutil.Discard)
+	err := slackbot.NewTestApp(cmd, "!karma season start Spring")
+	if _, ok := err.(*auth.PermissionDeniedError); !ok {
+		t.Fatalf("Error was not PermissionDeniedError: %#v", err)
+	}
+
+	seasons := models.KarmaSeasons{}
+	if err := store.Read(db.KarmaSeasonsKey, &seasons); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, seasons, 0)
+	assertKarmaTotals(t, store, models.Karma{
+		"alpha": {Upvotes: 3},
+		"beta":  {Upvotes: 5, Downvotes: 3},
+		"gamma": {Upvotes: 1},
+		"other": {Upvotes: 10},
+	})
 }
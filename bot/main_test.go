@@ -4,8 +4,11 @@ import (
 	"io/ioutil"
 	"log"
 	"testing"
+	"time"
 
 	"github.com/quintilesims/iqvbot/db"
+	"github.com/quintilesims/iqvbot/models"
+	"github.com/stretchr/testify/assert"
 )
 
 func init() {
@@ -20,3 +23,20 @@ func newMemoryStore(t *testing.T) *db.MemoryStore {
 
 	return store
 }
+
+// assertKarmaTotals compares the upvotes, downvotes, and reasons of the karma in store;
+// decayed scores depend on the current time, so they are ignored
+func assertKarmaTotals(t *testing.T, store *db.MemoryStore, expected models.Karma) {
+	result := models.Karma{}
+	if err := store.Read(db.KarmaKey, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, entry := range result {
+		entry.Score = 0
+		entry.Updated = time.Time{}
+		result[key] = entry
+	}
+
+	assert.Equal(t, expected, result)
+}
@@ -0,0 +1,196 @@
+package bot
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/quintilesims/iqvbot/auth"
+	"github.com/quintilesims/iqvbot/db"
+	"github.com/quintilesims/iqvbot/models"
+	"github.com/quintilesims/iqvbot/runner"
+	"github.com/quintilesims/iqvbot/slash"
+	"github.com/quintilesims/iqvbot/utils"
+	"github.com/urfave/cli"
+	"github.com/zpatrick/slackbot"
+)
+
+// NewRemindCommand create a cli.Command that allows users to set, list, and remove reminders.
+// Reminders are sent by the reminder runner, which only reads the store periodically,
+// so new reminders are also scheduled as soon as they are set.
+// The msg is the slack message that invoked the command; its user is the creator of new reminders.
+// Times are read and displayed in that user's time zone, which is looked up through the client.
+func NewRemindCommand(store db.Store, client utils.SlackClient, msg slack.Msg, w io.Writer) cli.Command {
+	userID := msg.User
+	return cli.Command{
+		Name:      "remind",
+		Usage:     "set a reminder for yourself, another user, or a channel",
+		ArgsUsage: "@USER|me|#CHANNEL in DURATION|at [DAY] TIME|every DAYS TIME TEXT",
+		Action: func(c *cli.Context) error {
+			args := c.Args()
+			if len(args) == 0 {
+				return slackbot.NewUserInputError("Argument TARGET is required: please use 'me', an @user, or a #channel")
+			}
+
+			reminder := &models.Reminder{
+				ReminderID: models.NewReminderID(),
+				CreatorID:  userID,
+			}
+
+			target := args[0]
+			switch {
+			case strings.ToLower(target) == "me":
+				reminder.UserID = userID
+			case channelRegex.MatchString(target):
+				reminder.ChannelID = channelRegex.FindStringSubmatch(target)[1]
+			default:
+				targetID, err := slackbot.ParseUserID(target)
+				if err != nil {
+					return slackbot.NewUserInputErrorf("'%s' is not a valid target: please use 'me', an @user, or a #channel", target)
+				}
+
+				reminder.UserID = targetID
+			}
+
+			config, err := readConfig(store)
+			if err != nil {
+				return err
+			}
+
+			loc := userLocation(client, userID, config)
+			schedule, remaining, err := parseReminderSchedule(args[1:], time.Now().In(loc))
+			if err != nil {
+				return err
+			}
+
+			reminder.Time = schedule.Time
+			reminder.TimeZone = loc.String()
+			reminder.Weekdays = schedule.Weekdays
+			reminder.Text = strings.Join(remaining, " ")
+			if reminder.Text == "" {
+				return slackbot.NewUserInputError("Argument TEXT is required")
+			}
+
+			reminders := models.Reminders{}
+			if err := store.Read(db.RemindersKey, &reminders); err != nil {
+				return err
+			}
+
+			reminders = append(reminders, reminder)
+			if err := store.Write(db.RemindersKey, reminders); err != nil {
+				return err
+			}
+
+			runner.ScheduleUserReminder(store, client, reminder)
+			return slackbot.WriteStringf(w, "Ok, I'll remind %s (id: `%s`)", formatReminder(reminder, loc), reminder.ReminderID)
+		},
+		Subcommands: []cli.Command{
+			{
+				Name:  "ls",
+				Usage: "list the reminders you have set or will receive",
+				Flags: []cli.Flag{
+					cli.IntFlag{
+						Name:  "limit",
+						Usage: fmt.Sprintf("The maximum number of reminders to display (default: the %s setting)", models.SettingListLimit),
+					},
+				},
+				Action: func(c *cli.Context) error {
+					reminders := models.Reminders{}
+					if err := store.Read(db.RemindersKey, &reminders); err != nil {
+						return err
+					}
+
+					reminders.Sort()
+
+					config, err := readConfig(store)
+					if err != nil {
+						return err
+					}
+
+					limit, err := listLimit(store, c)
+					if err != nil {
+						return err
+					}
+
+					loc := userLocation(client, userID, config)
+					text := "Here are your reminders: \n"
+					var count int
+					for _, reminder := range reminders {
+						if count >= limit {
+							break
+						}
+
+						if reminder.CreatorID != userID && reminder.UserID != userID {
+							continue
+						}
+
+						text += fmt.Sprintf("`%s` %s\n", reminder.ReminderID, formatReminder(reminder, loc))
+						count++
+					}
+
+					if count == 0 {
+						return slackbot.WriteString(w, "You don't have any reminders at the moment")
+					}
+
+					return slackbot.WriteString(w, text)
+				},
+			},
+			{
+				Name:      "rm",
+				Usage:     "remove a reminder",
+				ArgsUsage: "ID",
+				Action: func(c *cli.Context) error {
+					reminderID := c.Args().Get(0)
+					if reminderID == "" {
+						return slackbot.NewUserInputError("Argument ID is required")
+					}
+
+					reminders := models.Reminders{}
+					if err := store.Read(db.RemindersKey, &reminders); err != nil {
+						return err
+					}
+
+					reminder, ok := reminders.Get(reminderID)
+					if !ok {
+						return slackbot.NewUserInputErrorf("There isn't a reminder with the id `%s`", reminderID)
+					}
+
+					if err := auth.Authorize(store, userID, auth.ActionReminderRemove, reminder.CreatorID); err != nil {
+						return err
+					}
+
+					reminders.Delete(reminderID)
+					if err := store.Write(db.RemindersKey, reminders); err != nil {
+						return err
+					}
+
+					return slackbot.WriteStringf(w, "Ok, I've removed the reminder `%s`", reminderID)
+				},
+			},
+		},
+	}
+}
+
+// formatReminder describes the target, schedule, and text of a reminder.
+// Times are displayed in the specified location.
+func formatReminder(reminder *models.Reminder, loc *time.Location) string {
+	target := fmt.Sprintf("<#%s>", reminder.ChannelID)
+	if reminder.UserID != "" {
+		target = slackbot.EscapeUserID(reminder.UserID)
+	}
+
+	t := reminder.Time.In(loc)
+	schedule := fmt.Sprintf("on *%s* at *%s*", t.Format(slash.DateDisplayFormat), t.Format(slash.TimeDisplayFormat))
+	if reminder.IsRecurring() {
+		days := make([]string, len(reminder.Weekdays))
+		for i, weekday := range reminder.Weekdays {
+			days[i] = weekday.String()
+		}
+
+		schedule = fmt.Sprintf("every *%s* at *%s*", strings.Join(days, ", "), t.Format(slash.TimeDisplayFormat))
+	}
+
+	return fmt.Sprintf("%s %s: %s", target, schedule, reminder.Text)
+}
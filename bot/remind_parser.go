@@ -0,0 +1,222 @@
+package bot
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/quintilesims/iqvbot/models"
+	"github.com/zpatrick/slackbot"
+)
+
+var (
+	// matches '<#CHANNELID>' and '<#CHANNELID|name>'
+	channelRegex = regexp.MustCompile(`^<#([A-Z0-9]+)(?:\|[^>]*)?>$`)
+
+	// matches '9am', '9:30pm', '14:00', etc.
+	clockRegex = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)?$`)
+
+	// matches whole days, e.g. '1d' or '14d'
+	reminderDurationRegex = regexp.MustCompile(`^(\d+)d$`)
+)
+
+var weekdayNames = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"sun":       time.Sunday,
+	"monday":    time.Monday,
+	"mon":       time.Monday,
+	"tuesday":   time.Tuesday,
+	"tue":       time.Tuesday,
+	"tues":      time.Tuesday,
+	"wednesday": time.Wednesday,
+	"wed":       time.Wednesday,
+	"thursday":  time.Thursday,
+	"thu":       time.Thursday,
+	"thurs":     time.Thursday,
+	"friday":    time.Friday,
+	"fri":       time.Friday,
+	"saturday":  time.Saturday,
+	"sat":       time.Saturday,
+}
+
+var (
+	everyDay = []time.Weekday{
+		time.Sunday,
+		time.Monday,
+		time.Tuesday,
+		time.Wednesday,
+		time.Thursday,
+		time.Friday,
+		time.Saturday,
+	}
+
+	everyWeekday = []time.Weekday{
+		time.Monday,
+		time.Tuesday,
+		time.Wednesday,
+		time.Thursday,
+		time.Friday,
+	}
+)
+
+// A reminderSchedule is the time a reminder is first sent, and the weekdays it repeats on
+type reminderSchedule struct {
+	Time     time.Time
+	Weekdays []time.Weekday
+}
+
+// parseReminderSchedule parses the schedule at the start of args, relative to now.
+// Schedules are 'in DURATION' (e.g. 'in 2h'), 'at [DAY] TIME' (e.g. 'at friday 9am'),
+// or 'every DAYS TIME' (e.g. 'every weekday 10:00').
+// The remaining args are also returned.
+func parseReminderSchedule(args []string, now time.Time) (reminderSchedule, []string, error) {
+	if len(args) < 2 {
+		return reminderSchedule{}, nil, slackbot.NewUserInputError("Please specify when to send the reminder, e.g. 'in 2h', 'at friday 9am', or 'every weekday 10:00'")
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "in":
+		d, err := parseReminderDuration(args[1])
+		if err != nil {
+			return reminderSchedule{}, nil, err
+		}
+
+		return reminderSchedule{Time: now.Add(d)}, args[2:], nil
+	case "at":
+		day := strings.ToLower(args[1])
+		if hour, minute, err := parseClock(day); err == nil {
+			t := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+			if !t.After(now) {
+				t = t.AddDate(0, 0, 1)
+			}
+
+			return reminderSchedule{Time: t}, args[2:], nil
+		}
+
+		if len(args) < 3 {
+			return reminderSchedule{}, nil, slackbot.NewUserInputErrorf("Please specify a time after '%s', e.g. '%s 9am'", args[1], args[1])
+		}
+
+		hour, minute, err := parseClock(args[2])
+		if err != nil {
+			return reminderSchedule{}, nil, err
+		}
+
+		var t time.Time
+		switch day {
+		case "today", "tomorrow":
+			t = time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+			if day == "tomorrow" {
+				t = t.AddDate(0, 0, 1)
+			}
+
+			if !t.After(now) {
+				return reminderSchedule{}, nil, slackbot.NewUserInputErrorf("%s has already passed", strings.Join(args[1:3], " "))
+			}
+		default:
+			weekday, ok := weekdayNames[day]
+			if !ok {
+				return reminderSchedule{}, nil, slackbot.NewUserInputErrorf("'%s' is not a valid day: please use 'today', 'tomorrow', or a day of the week", args[1])
+			}
+
+			t = models.NextOccurrence(now, []time.Weekday{weekday}, hour, minute)
+		}
+
+		return reminderSchedule{Time: t}, args[3:], nil
+	case "every":
+		if len(args) < 3 {
+			return reminderSchedule{}, nil, slackbot.NewUserInputErrorf("Please specify a time after '%s', e.g. '%s 9am'", args[1], args[1])
+		}
+
+		weekdays, err := parseReminderWeekdays(args[1])
+		if err != nil {
+			return reminderSchedule{}, nil, err
+		}
+
+		hour, minute, err := parseClock(args[2])
+		if err != nil {
+			return reminderSchedule{}, nil, err
+		}
+
+		t := models.NextOccurrence(now, weekdays, hour, minute)
+		return reminderSchedule{Time: t, Weekdays: weekdays}, args[3:], nil
+	default:
+		return reminderSchedule{}, nil, slackbot.NewUserInputErrorf("'%s' is not a valid schedule: please use 'in', 'at', or 'every'", args[0])
+	}
+}
+
+// parseReminderDuration parses go durations, e.g. '90m' or '1h30m', and whole days, e.g. '2d'
+func parseReminderDuration(input string) (time.Duration, error) {
+	if match := reminderDurationRegex.FindStringSubmatch(input); match != nil {
+		days, err := strconv.Atoi(match[1])
+		if err != nil {
+			return 0, err
+		}
+
+		return time.Hour * 24 * time.Duration(days), nil
+	}
+
+	d, err := time.ParseDuration(input)
+	if err != nil || d <= 0 {
+		return 0, slackbot.NewUserInputErrorf("'%s' is not a valid duration, e.g. '30m', '2h', or '1d'", input)
+	}
+
+	return d, nil
+}
+
+// parseReminderWeekdays parses 'day', 'weekday', or the name of a day of the week.
+// Plural forms, e.g. 'weekdays' or 'mondays', are also accepted.
+func parseReminderWeekdays(input string) ([]time.Weekday, error) {
+	input = strings.ToLower(input)
+	for _, name := range []string{input, strings.TrimSuffix(input, "s")} {
+		switch name {
+		case "day":
+			return everyDay, nil
+		case "weekday":
+			return everyWeekday, nil
+		}
+
+		if weekday, ok := weekdayNames[name]; ok {
+			return []time.Weekday{weekday}, nil
+		}
+	}
+
+	return nil, slackbot.NewUserInputErrorf("'%s' is not valid: please use 'day', 'weekday', or a day of the week", input)
+}
+
+// parseClock parses a time of day, e.g. '9am', '9:30pm', or '14:00', into an hour and minute
+func parseClock(input string) (int, int, error) {
+	match := clockRegex.FindStringSubmatch(strings.ToLower(input))
+	if match == nil {
+		return 0, 0, slackbot.NewUserInputErrorf("'%s' is not a valid time, e.g. '9am', '9:30pm', or '14:00'", input)
+	}
+
+	hour, _ := strconv.Atoi(match[1])
+	var minute int
+	if match[2] != "" {
+		minute, _ = strconv.Atoi(match[2])
+	}
+
+	switch match[3] {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, 0, slackbot.NewUserInputErrorf("'%s' is not a valid time", input)
+		}
+
+		hour = hour % 12
+		if match[3] == "pm" {
+			hour += 12
+		}
+	default:
+		if match[2] == "" {
+			return 0, 0, slackbot.NewUserInputErrorf("'%s' is ambiguous: please use '%sam', '%spm', or '%s:00'", input, input, input, input)
+		}
+	}
+
+	if hour > 23 || minute > 59 {
+		return 0, 0, slackbot.NewUserInputErrorf("'%s' is not a valid time", input)
+	}
+
+	return hour, minute, nil
+}
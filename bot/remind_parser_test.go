@@ -0,0 +1,64 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseReminderSchedule(t *testing.T) {
+	// 2018-01-03 is a Wednesday
+	now := time.Date(2018, 1, 3, 10, 0, 0, 0, time.UTC)
+
+	cases := map[string]reminderSchedule{
+		"in 2h":                {Time: now.Add(time.Hour * 2)},
+		"in 1h30m":             {Time: now.Add(time.Minute * 90)},
+		"in 2d":                {Time: now.AddDate(0, 0, 2)},
+		"at 3pm":               {Time: time.Date(2018, 1, 3, 15, 0, 0, 0, time.UTC)},
+		"at 9:30am":            {Time: time.Date(2018, 1, 4, 9, 30, 0, 0, time.UTC)},
+		"at today 12pm":        {Time: time.Date(2018, 1, 3, 12, 0, 0, 0, time.UTC)},
+		"at tomorrow 08:15":    {Time: time.Date(2018, 1, 4, 8, 15, 0, 0, time.UTC)},
+		"at Friday 9am":        {Time: time.Date(2018, 1, 5, 9, 0, 0, 0, time.UTC)},
+		"at wednesday 9am":     {Time: time.Date(2018, 1, 10, 9, 0, 0, 0, time.UTC)},
+		"every day 12am":       {Time: time.Date(2018, 1, 4, 0, 0, 0, 0, time.UTC), Weekdays: everyDay},
+		"every weekday 10:00":  {Time: time.Date(2018, 1, 4, 10, 0, 0, 0, time.UTC), Weekdays: everyWeekday},
+		"every mondays 9:30am": {Time: time.Date(2018, 1, 8, 9, 30, 0, 0, time.UTC), Weekdays: []time.Weekday{time.Monday}},
+	}
+
+	for input, expected := range cases {
+		schedule, remaining, err := parseReminderSchedule(strings.Split(input+" do the thing", " "), now)
+		if err != nil {
+			t.Fatalf("%s: %v", input, err)
+		}
+
+		assert.Equal(t, expected, schedule, input)
+		assert.Equal(t, []string{"do", "the", "thing"}, remaining, input)
+	}
+}
+
+func TestParseReminderScheduleErrors(t *testing.T) {
+	now := time.Date(2018, 1, 3, 10, 0, 0, 0, time.UTC)
+	inputs := []string{
+		"",
+		"in",
+		"in soon",
+		"in -2h",
+		"at 25:00",
+		"at 13pm",
+		"at 9",
+		"at today 9am",
+		"at someday 9am",
+		"at friday",
+		"every fortnight 9am",
+		"every day",
+		"sometime 9am",
+	}
+
+	for _, input := range inputs {
+		if _, _, err := parseReminderSchedule(strings.Fields(input), now); err == nil {
+			t.Errorf("%s: error was nil!", input)
+		}
+	}
+}
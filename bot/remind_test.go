@@ -0,0 +1,257 @@
+package bot
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/nlopes/slack"
+	"github.com/quintilesims/iqvbot/auth"
+	"github.com/quintilesims/iqvbot/db"
+	"github.com/quintilesims/iqvbot/mock"
+	"github.com/quintilesims/iqvbot/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/zpatrick/slackbot"
+)
+
+func newRemindTestClient(ctrl *gomock.Controller) *mock.MockSlackClient {
+	mockSlackClient := mock.NewMockSlackClient(ctrl)
+	mockSlackClient.EXPECT().
+		GetUserInfo(gomock.Any()).
+		Return(&slack.User{TZ: "America/New_York"}, nil).
+		AnyTimes()
+
+	return mockSlackClient
+}
+
+func TestRemindTargets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockSlackClient := newRemindTestClient(ctrl)
+
+	cases := map[string]models.Reminder{
+		"me":              {UserID: "uid"},
+		"ME":              {UserID: "uid"},
+		"<@other>":        {UserID: "other"},
+		"<#C123|general>": {ChannelID: "C123"},
+		"<#C123>":         {ChannelID: "C123"},
+	}
+
+	for target, expected := range cases {
+		t.Run(target, func(t *testing.T) {
+			store := newMemoryStore(t)
+			cmd := NewRemindCommand(store, mockSlackClient, slack.Msg{User: "uid"}, ioutil.Discard)
+			if err := slackbot.NewTestApp(cmd, "!remind "+target+" in 1h do the thing"); err != nil {
+				t.Fatal(err)
+			}
+
+			result := models.Reminders{}
+			if err := store.Read(db.RemindersKey, &result); err != nil {
+				t.Fatal(err)
+			}
+
+			if assert.Len(t, result, 1) {
+				assert.Equal(t, "uid", result[0].CreatorID)
+				assert.Equal(t, expected.UserID, result[0].UserID)
+				assert.Equal(t, expected.ChannelID, result[0].ChannelID)
+				assert.Equal(t, "do the thing", result[0].Text)
+				assert.Equal(t, "America/New_York", result[0].TimeZone)
+				assert.WithinDuration(t, time.Now().Add(time.Hour), result[0].Time, time.Minute)
+			}
+		})
+	}
+}
+
+func TestRemindErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockSlackClient := newRemindTestClient(ctrl)
+
+	inputs := []string{
+		"!remind",
+		"!remind bob in 1h do the thing",
+		"!remind @bob in 1h do the thing",
+		"!remind #general in 1h do the thing",
+		"!remind me do the thing",
+		"!remind me in 1h",
+	}
+
+	store := newMemoryStore(t)
+	cmd := NewRemindCommand(store, mockSlackClient, slack.Msg{User: "uid"}, ioutil.Discard)
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			if err := slackbot.NewTestApp(cmd, input); err == nil {
+				t.Fatal("Error was nil!")
+			}
+		})
+	}
+
+	result := models.Reminders{}
+	if err := store.Read(db.RemindersKey, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, result, 0)
+}
+
+func TestRemindList(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockSlackClient := newRemindTestClient(ctrl)
+
+	now := time.Now().UTC()
+	reminders := models.Reminders{
+		{ReminderID: "created", CreatorID: "uid", ChannelID: "C123", Text: "one", Time: now.Add(time.Hour * 2)},
+		{ReminderID: "received", CreatorID: "other", UserID: "uid", Text: "two", Time: now.Add(time.Hour)},
+		{ReminderID: "unrelated", CreatorID: "other", UserID: "other", Text: "three", Time: now.Add(time.Hour)},
+	}
+
+	store := newMemoryStore(t)
+	if err := store.Write(db.RemindersKey, reminders); err != nil {
+		t.Fatal(err)
+	}
+
+	w := bytes.NewBuffer(nil)
+	cmd := NewRemindCommand(store, mockSlackClient, slack.Msg{User: "uid"}, w)
+	if err := slackbot.NewTestApp(cmd, "!remind ls"); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Contains(t, w.String(), "`created` <#C123>")
+	assert.Contains(t, w.String(), "`received` <@uid>")
+	assert.NotContains(t, w.String(), "unrelated")
+	assert.True(t, bytes.Index(w.Bytes(), []byte("received")) < bytes.Index(w.Bytes(), []byte("created")))
+
+	w.Reset()
+	cmd = NewRemindCommand(store, mockSlackClient, slack.Msg{User: "nobody"}, w)
+	if err := slackbot.NewTestApp(cmd, "!remind ls"); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "You don't have any reminders at the moment", w.String())
+}
+
+func TestRemindListLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockSlackClient := newRemindTestClient(ctrl)
+
+	now := time.Now().UTC()
+	reminders := models.Reminders{
+		{ReminderID: "first", CreatorID: "uid", UserID: "uid", Time: now.Add(time.Hour)},
+		{ReminderID: "second", CreatorID: "uid", UserID: "uid", Time: now.Add(time.Hour * 2)},
+		{ReminderID: "third", CreatorID: "uid", UserID: "uid", Time: now.Add(time.Hour * 3)},
+	}
+
+	store := newMemoryStore(t)
+	if err := store.Write(db.RemindersKey, reminders); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Write(db.ConfigKey, models.Config{models.SettingListLimit: "1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	w := bytes.NewBuffer(nil)
+	cmd := NewRemindCommand(store, mockSlackClient, slack.Msg{User: "uid"}, w)
+	if err := slackbot.NewTestApp(cmd, "!remind ls"); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Contains(t, w.String(), "first")
+	assert.NotContains(t, w.String(), "second")
+
+	// the --limit flag overrides the setting
+	w.Reset()
+	if err := slackbot.NewTestApp(cmd, "!remind ls --limit 2"); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Contains(t, w.String(), "second")
+	assert.NotContains(t, w.String(), "third")
+}
+
+func TestRemindRemove(t *testing.T) {
+	cases := map[string]struct {
+		UserID string
+		Roles  models.Roles
+	}{
+		"creator": {"uid", models.Roles{}},
+		"admin":   {"admin", models.Roles{"admin": {models.RoleAdmin}}},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			store := newMemoryStore(t)
+			if err := store.Write(db.RolesKey, c.Roles); err != nil {
+				t.Fatal(err)
+			}
+
+			reminders := models.Reminders{
+				{ReminderID: "a", CreatorID: "uid", UserID: "other"},
+				{ReminderID: "b", CreatorID: "uid", UserID: "other"},
+			}
+
+			if err := store.Write(db.RemindersKey, reminders); err != nil {
+				t.Fatal(err)
+			}
+
+			cmd := NewRemindCommand(store, nil, slack.Msg{User: c.UserID}, ioutil.Discard)
+			if err := slackbot.NewTestApp(cmd, "!remind rm a"); err != nil {
+				t.Fatal(err)
+			}
+
+			result := models.Reminders{}
+			if err := store.Read(db.RemindersKey, &result); err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, models.Reminders{{ReminderID: "b", CreatorID: "uid", UserID: "other"}}, result)
+		})
+	}
+}
+
+func TestRemindRemoveErrors(t *testing.T) {
+	store := newMemoryStore(t)
+	if err := store.Write(db.RemindersKey, models.Reminders{{ReminderID: "a", CreatorID: "uid"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	inputs := []string{
+		"!remind rm",
+		"!remind rm b",
+	}
+
+	cmd := NewRemindCommand(store, nil, slack.Msg{User: "uid"}, ioutil.Discard)
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			if err := slackbot.NewTestApp(cmd, input); err == nil {
+				t.Fatal("Error was nil!")
+			}
+		})
+	}
+}
+
+func TestRemindRemovePermissionDenied(t *testing.T) {
+	store := newMemoryStore(t)
+	reminders := models.Reminders{{ReminderID: "a", CreatorID: "uid", UserID: "other"}}
+	if err := store.Write(db.RemindersKey, reminders); err != nil {
+		t.Fatal(err)
+	}
+
+	// receiving a reminder doesn't allow a user to remove it
+	cmd := NewRemindCommand(store, nil, slack.Msg{User: "other"}, ioutil.Discard)
+	err := slackbot.NewTestApp(cmd, "!remind rm a")
+	if _, ok := err.(*auth.PermissionDeniedError); !ok {
+		t.Fatalf("Error was not PermissionDeniedError: %#v", err)
+	}
+
+	result := models.Reminders{}
+	if err := store.Read(db.RemindersKey, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, reminders, result)
+}
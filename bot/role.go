@@ -0,0 +1,151 @@
+package bot
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/nlopes/slack"
+	"github.com/quintilesims/iqvbot/auth"
+	"github.com/quintilesims/iqvbot/db"
+	"github.com/quintilesims/iqvbot/models"
+	"github.com/urfave/cli"
+	"github.com/zpatrick/slackbot"
+)
+
+// NewRoleCommand create a cli.Command that allows admins to grant and revoke user roles.
+// The msg is the slack message that invoked the command; only admins may grant or revoke roles.
+func NewRoleCommand(store db.Store, msg slack.Msg, w io.Writer) cli.Command {
+	userID := msg.User
+
+	// parseArgs validates the USER and ROLE arguments used by grant and revoke
+	parseArgs := func(c *cli.Context) (string, string, error) {
+		if err := auth.Authorize(store, userID, auth.ActionRoleGrantRevoke); err != nil {
+			return "", "", err
+		}
+
+		args := c.Args()
+		user := args.Get(0)
+		if user == "" {
+			return "", "", slackbot.NewUserInputError("Argument USER is required")
+		}
+
+		targetID, err := slackbot.ParseUserID(user)
+		if err != nil {
+			return "", "", slackbot.NewUserInputErrorf("'%s' is not in valid @username format", user)
+		}
+
+		role := strings.ToLower(args.Get(1))
+		if role == "" {
+			return "", "", slackbot.NewUserInputError("Argument ROLE is required")
+		}
+
+		if !models.IsValidRole(role) {
+			return "", "", slackbot.NewUserInputErrorf("Invalid role '%s': must be one of %s", role, strings.Join(models.ValidRoles, ", "))
+		}
+
+		return targetID, role, nil
+	}
+
+	return cli.Command{
+		Name:  "role",
+		Usage: "manage the roles that allow users to run hire, candidate, and interview commands",
+		Subcommands: []cli.Command{
+			{
+				Name:      "grant",
+				Usage:     "grant a role to a user",
+				ArgsUsage: "@USER ROLE",
+				Action: func(c *cli.Context) error {
+					targetID, role, err := parseArgs(c)
+					if err != nil {
+						return err
+					}
+
+					roles := models.Roles{}
+					if err := store.Read(db.RolesKey, &roles); err != nil {
+						return err
+					}
+
+					if !roles.Grant(targetID, role) {
+						return slackbot.NewUserInputErrorf("%s already has the *%s* role", slackbot.EscapeUserID(targetID), role)
+					}
+
+					if err := store.Write(db.RolesKey, roles); err != nil {
+						return err
+					}
+
+					return slackbot.WriteStringf(w, "Ok, I've granted the *%s* role to %s", role, slackbot.EscapeUserID(targetID))
+				},
+			},
+			{
+				Name:  "ls",
+				Usage: "list users and their roles",
+				Action: func(c *cli.Context) error {
+					roles := models.Roles{}
+					if err := store.Read(db.RolesKey, &roles); err != nil {
+						return err
+					}
+
+					if len(roles) == 0 {
+						return slackbot.WriteString(w, "Nobody has been granted a role at the moment")
+					}
+
+					userIDs := make([]string, 0, len(roles))
+					for userID := range roles {
+						userIDs = append(userIDs, userID)
+					}
+
+					sort.Strings(userIDs)
+
+					text := "Here are the users with roles: \n"
+					for _, userID := range userIDs {
+						text += fmt.Sprintf("%s: %s\n", slackbot.EscapeUserID(userID), strings.Join(roles[userID], ", "))
+					}
+
+					return slackbot.WriteString(w, text)
+				},
+			},
+			{
+				Name:      "revoke",
+				Usage:     "revoke a role from a user",
+				ArgsUsage: "@USER ROLE",
+				Action: func(c *cli.Context) error {
+					targetID, role, err := parseArgs(c)
+					if err != nil {
+						return err
+					}
+
+					roles := models.Roles{}
+					if err := store.Read(db.RolesKey, &roles); err != nil {
+						return err
+					}
+
+					if !roles.Revoke(targetID, role) {
+						return slackbot.NewUserInputErrorf("%s does not have the *%s* role", slackbot.EscapeUserID(targetID), role)
+					}
+
+					if role == models.RoleAdmin && !hasAdmin(roles) {
+						return slackbot.NewUserInputError("I can't revoke the *admin* role from the last admin")
+					}
+
+					if err := store.Write(db.RolesKey, roles); err != nil {
+						return err
+					}
+
+					return slackbot.WriteStringf(w, "Ok, I've revoked the *%s* role from %s", role, slackbot.EscapeUserID(targetID))
+				},
+			},
+		},
+	}
+}
+
+func hasAdmin(roles models.Roles) bool {
+	for userID := range roles {
+		if roles.Has(userID, models.RoleAdmin) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,28 @@
+package bot
+
+import (
+	"github.com/nlopes/slack"
+	"github.com/quintilesims/iqvbot/auth"
+	"github.com/quintilesims/iqvbot/slash"
+	"github.com/zpatrick/slackbot"
+)
+
+// slashCommandError converts errors meant for the user into messages slack can display.
+// Other errors are returned unchanged.
+func slashCommandError(err error) error {
+	switch err.(type) {
+	case *slackbot.UserInputError, *auth.PermissionDeniedError:
+		return slash.NewSlackMessageError(err.Error())
+	default:
+		return err
+	}
+}
+
+// attachmentActionValue returns the selected option of a menu, or the value of a button
+func attachmentActionValue(action slack.AttachmentAction) string {
+	if len(action.SelectedOptions) > 0 {
+		return action.SelectedOptions[0].Value
+	}
+
+	return action.Value
+}
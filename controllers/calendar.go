@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/quintilesims/iqvbot/db"
+	"github.com/quintilesims/iqvbot/models"
+	"github.com/zpatrick/fireball"
+)
+
+// CalendarController serves iCalendar feeds of interviews.
+// Calendar apps can't sign their requests, so the secret token in a feed's url is all that protects it;
+// its routes must not be decorated with SlackSignatureDecorator.
+type CalendarController struct {
+	store db.Store
+}
+
+func NewCalendarController(store db.Store) *CalendarController {
+	return &CalendarController{
+		store: store,
+	}
+}
+
+func (cc *CalendarController) Routes() []*fireball.Route {
+	routes := []*fireball.Route{
+		{
+			Path: "/calendar/:token",
+			Handlers: fireball.Handlers{
+				"GET": cc.feed,
+			},
+		},
+	}
+
+	return routes
+}
+
+func (cc *CalendarController) feed(c *fireball.Context) (fireball.Response, error) {
+	token := strings.TrimSuffix(c.PathVariables["token"], ".ics")
+
+	feeds := models.CalendarFeeds{}
+	if err := cc.store.Read(db.CalendarFeedsKey, &feeds); err != nil {
+		return nil, err
+	}
+
+	owner, ok := feeds[token]
+	if !ok {
+		return fireball.NewResponse(404, []byte("Calendar not found"), nil), nil
+	}
+
+	interviews := models.Interviews{}
+	if err := cc.store.Read(db.InterviewsKey, &interviews); err != nil {
+		return nil, err
+	}
+
+	config := models.Config{}
+	if err := cc.store.Read(db.ConfigKey, &config); err != nil {
+		return nil, err
+	}
+
+	name := "Interviews"
+	if owner != models.TeamCalendarFeed {
+		name = "My interviews"
+		for i := 0; i < len(interviews); i++ {
+			if !interviews[i].HasInterviewer(owner) {
+				interviews = append(interviews[:i], interviews[i+1:]...)
+				i--
+			}
+		}
+	}
+
+	body := models.NewInterviewCalendar(name, models.CalendarMethodPublish, interviews, config.Duration(models.SettingInterviewDuration), time.Now())
+	headers := map[string]string{"Content-Type": "text/calendar; charset=utf-8"}
+	return fireball.NewResponse(200, body, headers), nil
+}
@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/quintilesims/iqvbot/db"
+	"github.com/quintilesims/iqvbot/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/zpatrick/fireball"
+)
+
+func TestCalendarControllerFeed(t *testing.T) {
+	store := newMemoryStore(t)
+	interviews := models.Interviews{
+		{InterviewID: "iid1", Candidate: "John Doe", InterviewerIDs: []string{"uid1"}, Time: time.Now()},
+		{InterviewID: "iid2", Candidate: "Jane Doe", InterviewerIDs: []string{"uid2"}, Time: time.Now()},
+	}
+
+	if err := store.Write(db.InterviewsKey, interviews); err != nil {
+		t.Fatal(err)
+	}
+
+	feeds := models.CalendarFeeds{"user_token": "uid1", "team_token": models.TeamCalendarFeed}
+	if err := store.Write(db.CalendarFeedsKey, feeds); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string][]string{
+		"user_token.ics": {"iid1"},
+		"team_token.ics": {"iid1", "iid2"},
+		"team_token":     {"iid1", "iid2"},
+	}
+
+	controller := NewCalendarController(store)
+	for token, expected := range cases {
+		t.Run(token, func(t *testing.T) {
+			c := &fireball.Context{PathVariables: map[string]string{"token": token}}
+			resp, err := controller.feed(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			recorder := unmarshalBody(t, resp, nil)
+			assert.Equal(t, 200, recorder.Code)
+			assert.Equal(t, "text/calendar; charset=utf-8", recorder.Header().Get("Content-Type"))
+
+			body := recorder.Body.String()
+			for _, interview := range interviews {
+				uid := "UID:" + interview.InterviewID + "@iqvbot"
+				if contains(expected, interview.InterviewID) {
+					assert.Contains(t, body, uid)
+				} else {
+					assert.NotContains(t, body, uid)
+				}
+			}
+		})
+	}
+}
+
+func TestCalendarControllerFeedNotFound(t *testing.T) {
+	store := newMemoryStore(t)
+	controller := NewCalendarController(store)
+
+	c := &fireball.Context{PathVariables: map[string]string{"token": "invalid.ics"}}
+	resp, err := controller.feed(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := unmarshalBody(t, resp, nil)
+	assert.Equal(t, 404, recorder.Code)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/nlopes/slack/slackevents"
+	"github.com/zpatrick/fireball"
+)
+
+// How long the ids of queued events are remembered; slack stops retrying an event well before then
+const queuedEventTTL = time.Minute * 10
+
+// EventsController receives events from the slack Events API and forwards them to the bot,
+// in the same form as the events from the real time messaging api.
+// Requests over http should be verified with SlackSignatureDecorator before they reach its routes.
+type EventsController struct {
+	events chan<- slack.RTMEvent
+
+	// queued maps the ids of recently queued events to when they were queued,
+	// so retries of those events aren't handled twice
+	queued map[string]time.Time
+	mutex  sync.Mutex
+}
+
+func NewEventsController(events chan<- slack.RTMEvent) *EventsController {
+	return &EventsController{
+		events: events,
+		queued: map[string]time.Time{},
+	}
+}
+
+func (e *EventsController) Routes() []*fireball.Route {
+	routes := []*fireball.Route{
+		{
+			Path: "/slack/events",
+			Handlers: fireball.Handlers{
+				"POST": e.receive,
+			},
+		},
+	}
+
+	return routes
+}
+
+// eventsAPIPayload is the outer event that slack posts to the events url
+type eventsAPIPayload struct {
+	Type      string          `json:"type"`
+	Challenge string          `json:"challenge"`
+	EventID   string          `json:"event_id"`
+	Event     json.RawMessage `json:"event"`
+}
+
+func (e *EventsController) receive(c *fireball.Context) (fireball.Response, error) {
+	defer c.Request.Body.Close()
+	body, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload eventsAPIPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+
+	switch payload.Type {
+	case slackevents.URLVerification:
+		return fireball.NewJSONResponse(200, map[string]string{"challenge": payload.Challenge})
+	case slackevents.CallbackEvent:
+	default:
+		log.Printf("[DEBUG] Ignoring events api payload of type '%s'", payload.Type)
+		return fireball.NewResponse(200, nil, nil), nil
+	}
+
+	event, ok, err := parseInnerEvent(payload.Event)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return fireball.NewResponse(200, nil, nil), nil
+	}
+
+	if !e.queue(payload.EventID, event) {
+		// slack retries events that fail, so the event isn't lost once the bot catches up
+		log.Printf("[WARN] Event queue is full: rejecting event %s", payload.EventID)
+		return fireball.NewResponse(http.StatusServiceUnavailable, nil, nil), nil
+	}
+
+	return fireball.NewResponse(200, nil, nil), nil
+}
+
+// queue sends the event to the bot without waiting, returning false if the bot's queue is full.
+// Slack retries events that weren't acknowledged quickly enough, so an event whose id was already queued
+// is dropped instead of being handled twice.
+func (e *EventsController) queue(eventID string, event slack.RTMEvent) bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	now := time.Now()
+	for id, queuedAt := range e.queued {
+		if now.Sub(queuedAt) > queuedEventTTL {
+			delete(e.queued, id)
+		}
+	}
+
+	if _, ok := e.queued[eventID]; ok {
+		log.Printf("[DEBUG] Ignoring retry of event %s", eventID)
+		return true
+	}
+
+	select {
+	case e.events <- event:
+	default:
+		return false
+	}
+
+	if eventID != "" {
+		e.queued[eventID] = now
+	}
+
+	return true
+}
+
+// parseInnerEvent converts an Events API event into the matching real time messaging api event.
+// A bool is also returned denoting if the event has a matching type.
+func parseInnerEvent(raw json.RawMessage) (slack.RTMEvent, bool, error) {
+	var inner struct {
+		Type string `json:"type"`
+	}
+
+	if err := json.Unmarshal(raw, &inner); err != nil {
+		return slack.RTMEvent{}, false, err
+	}
+
+	// events without a real time messaging api type, such as app_home_opened, aren't handled by the bot
+	v, ok := slack.EventMapping[inner.Type]
+	if !ok {
+		log.Printf("[DEBUG] Ignoring event of unknown type '%s'", inner.Type)
+		return slack.RTMEvent{}, false, nil
+	}
+
+	data := reflect.New(reflect.TypeOf(v)).Interface()
+	if err := json.Unmarshal(raw, data); err != nil {
+		return slack.RTMEvent{}, false, fmt.Errorf("Failed to parse '%s' event: %v", inner.Type, err)
+	}
+
+	return slack.RTMEvent{Type: inner.Type, Data: data}, true, nil
+}
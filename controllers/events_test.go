@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/zpatrick/fireball"
+)
+
+func newEventsRequest(t *testing.T, body string) *http.Request {
+	req, err := http.NewRequest("POST", "https://test.com/slack/events", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	return req
+}
+
+func TestEventsControllerURLVerification(t *testing.T) {
+	controller := NewEventsController(make(chan slack.RTMEvent, 1))
+	req := newEventsRequest(t, `{"type": "url_verification", "challenge": "some_challenge"}`)
+
+	resp, err := controller.receive(&fireball.Context{Request: req})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result map[string]string
+	recorder := unmarshalBody(t, resp, &result)
+	assert.Equal(t, 200, recorder.Code)
+	assert.Equal(t, "some_challenge", result["challenge"])
+}
+
+func TestEventsControllerEvents(t *testing.T) {
+	cases := map[string]func(t *testing.T, e slack.RTMEvent){
+		`{"type": "message", "channel": "cid", "user": "uid", "text": "iqvbot echo hi", "ts": "1.0"}`: func(t *testing.T, e slack.RTMEvent) {
+			data, ok := e.Data.(*slack.MessageEvent)
+			if assert.True(t, ok) {
+				assert.Equal(t, "cid", data.Channel)
+				assert.Equal(t, "uid", data.User)
+				assert.Equal(t, "iqvbot echo hi", data.Text)
+			}
+		},
+		`{"type": "reaction_added", "user": "uid", "reaction": "tada", "item_user": "iuid", "item": {"type": "message", "channel": "cid", "ts": "1.0"}}`: func(t *testing.T, e slack.RTMEvent) {
+			data, ok := e.Data.(*slack.ReactionAddedEvent)
+			if assert.True(t, ok) {
+				assert.Equal(t, "tada", data.Reaction)
+				assert.Equal(t, "iuid", data.ItemUser)
+			}
+		},
+	}
+
+	for event, check := range cases {
+		events := make(chan slack.RTMEvent, 1)
+		controller := NewEventsController(events)
+		req := newEventsRequest(t, `{"type": "event_callback", "event_id": "eid", "event": `+event+`}`)
+
+		resp, err := controller.receive(&fireball.Context{Request: req})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		recorder := unmarshalBody(t, resp, nil)
+		assert.Equal(t, 200, recorder.Code)
+		if assert.Len(t, events, 1) {
+			check(t, <-events)
+		}
+	}
+}
+
+func TestEventsControllerIgnoresRetriesOfQueuedEvents(t *testing.T) {
+	events := make(chan slack.RTMEvent, 2)
+	controller := NewEventsController(events)
+	receive := func(eventID, retry string) int {
+		req := newEventsRequest(t, `{"type": "event_callback", "event_id": "`+eventID+`", "event": {"type": "message", "text": "iqvbot echo hi"}}`)
+		if retry != "" {
+			req.Header.Set("X-Slack-Retry-Num", retry)
+		}
+
+		resp, err := controller.receive(&fireball.Context{Request: req})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return unmarshalBody(t, resp, nil).Code
+	}
+
+	assert.Equal(t, 200, receive("eid1", ""))
+	assert.Equal(t, 200, receive("eid1", "1"))
+	assert.Len(t, events, 1)
+
+	// retries of events that were never queued are handled
+	assert.Equal(t, 200, receive("eid2", "1"))
+	assert.Len(t, events, 2)
+}
+
+func TestEventsControllerRejectsEventsWhenQueueIsFull(t *testing.T) {
+	events := make(chan slack.RTMEvent, 1)
+	controller := NewEventsController(events)
+	receive := func(eventID string) int {
+		req := newEventsRequest(t, `{"type": "event_callback", "event_id": "`+eventID+`", "event": {"type": "message", "text": "iqvbot echo hi"}}`)
+		resp, err := controller.receive(&fireball.Context{Request: req})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return unmarshalBody(t, resp, nil).Code
+	}
+
+	assert.Equal(t, 200, receive("eid1"))
+	assert.Equal(t, 503, receive("eid2"))
+
+	// the rejected event is queued when slack retries it
+	<-events
+	assert.Equal(t, 200, receive("eid2"))
+	assert.Len(t, events, 1)
+}
+
+func TestEventsControllerIgnoresUnknownEvents(t *testing.T) {
+	for _, event := range []string{
+		`{"type": "not_an_event"}`,
+		`{"type": "app_home_opened", "user": "uid", "channel": "cid"}`,
+	} {
+		events := make(chan slack.RTMEvent, 1)
+		controller := NewEventsController(events)
+		req := newEventsRequest(t, `{"type": "event_callback", "event": `+event+`}`)
+
+		resp, err := controller.receive(&fireball.Context{Request: req})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		recorder := unmarshalBody(t, resp, nil)
+		assert.Equal(t, 200, recorder.Code)
+		assert.Len(t, events, 0, event)
+	}
+}
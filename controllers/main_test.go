@@ -33,7 +33,7 @@ func newSlackMessageWithCallback(callbackID string) *slack.Message {
 	return &slack.Message{
 		Msg: slack.Msg{
 			Attachments: []slack.Attachment{
-				{CallbackID: "callback_id"},
+				{CallbackID: callbackID},
 			},
 		},
 	}
@@ -62,3 +62,19 @@ func unmarshalBody(t *testing.T, resp fireball.Response, v interface{}) *httptes
 
 	return recorder
 }
+
+// newResponseServer returns a server that records the messages posted to it, for use as a response url
+func newResponseServer(t *testing.T) (*httptest.Server, *[]slack.Message) {
+	var responses []slack.Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg slack.Message
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Error(err)
+			return
+		}
+
+		responses = append(responses, msg)
+	}))
+
+	return server, &responses
+}
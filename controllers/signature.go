@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/nlopes/slack"
+	"github.com/zpatrick/fireball"
+)
+
+// SlackSignatureDecorator returns a fireball.Decorator that rejects requests which were not signed
+// by slack using the signing secret, or whose timestamp is more than five minutes old.
+// See https://api.slack.com/docs/verifying-requests-from-slack for more information.
+func SlackSignatureDecorator(signingSecret string) fireball.Decorator {
+	return func(handler fireball.Handler) fireball.Handler {
+		return func(c *fireball.Context) (fireball.Response, error) {
+			verifier, err := slack.NewSecretsVerifier(c.Request.Header, signingSecret)
+			if err != nil {
+				return unauthorized(c, err), nil
+			}
+
+			body, err := ioutil.ReadAll(c.Request.Body)
+			if err != nil {
+				return nil, err
+			}
+
+			// the handler still needs to parse the body after it has been verified
+			c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+			if _, err := verifier.Write(body); err != nil {
+				return nil, err
+			}
+
+			if err := verifier.Ensure(); err != nil {
+				return unauthorized(c, err), nil
+			}
+
+			return handler(c)
+		}
+	}
+}
+
+func unauthorized(c *fireball.Context, err error) fireball.Response {
+	log.Printf("[WARN] Rejected unverified request to %s: %v", c.Request.URL.Path, err)
+	return fireball.NewResponse(http.StatusUnauthorized, []byte(http.StatusText(http.StatusUnauthorized)), nil)
+}
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zpatrick/fireball"
+)
+
+const testSigningSecret = "secret"
+
+func newSignedRequest(t *testing.T, body string, timestamp time.Time, secret string) *http.Request {
+	req, err := http.NewRequest("POST", "https://test.com/slack/message_action", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	hash := hmac.New(sha256.New, []byte(secret))
+	hash.Write([]byte(fmt.Sprintf("v0:%s:%s", ts, body)))
+
+	req.Header.Set("X-Slack-Request-Timestamp", ts)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(hash.Sum(nil)))
+	return req
+}
+
+// runSignatureDecorator returns the http status of the response, and the body the handler received, if it was called
+func runSignatureDecorator(t *testing.T, req *http.Request) (int, string) {
+	var received string
+	handler := func(c *fireball.Context) (fireball.Response, error) {
+		body, err := ioutil.ReadAll(c.Request.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		received = string(body)
+		return fireball.NewResponse(200, nil, nil), nil
+	}
+
+	resp, err := SlackSignatureDecorator(testSigningSecret)(handler)(&fireball.Context{Request: req})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := unmarshalBody(t, resp, nil)
+	return recorder.Code, received
+}
+
+func TestSlackSignatureDecorator(t *testing.T) {
+	req := newSignedRequest(t, "command=/interview", time.Now(), testSigningSecret)
+	code, received := runSignatureDecorator(t, req)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "command=/interview", received)
+}
+
+func TestSlackSignatureDecoratorRejectsTamperedRequests(t *testing.T) {
+	tamperedBody := newSignedRequest(t, "command=/interview", time.Now(), testSigningSecret)
+	tamperedBody.Body = ioutil.NopCloser(strings.NewReader("command=/interview&text=delete"))
+
+	tamperedTimestamp := newSignedRequest(t, "command=/interview", time.Now(), testSigningSecret)
+	tamperedTimestamp.Header.Set("X-Slack-Request-Timestamp", strconv.FormatInt(time.Now().Unix()+1, 10))
+
+	wrongSecret := newSignedRequest(t, "command=/interview", time.Now(), "wrong")
+
+	badSignature := newSignedRequest(t, "command=/interview", time.Now(), testSigningSecret)
+	badSignature.Header.Set("X-Slack-Signature", "v0=not-hex")
+
+	missingHeaders := newSignedRequest(t, "command=/interview", time.Now(), testSigningSecret)
+	missingHeaders.Header.Del("X-Slack-Signature")
+
+	cases := map[string]*http.Request{
+		"tampered body":      tamperedBody,
+		"tampered timestamp": tamperedTimestamp,
+		"wrong secret":       wrongSecret,
+		"bad signature":      badSignature,
+		"missing headers":    missingHeaders,
+	}
+
+	for name, req := range cases {
+		code, received := runSignatureDecorator(t, req)
+		assert.Equal(t, http.StatusUnauthorized, code, name)
+		assert.Equal(t, "", received, name)
+	}
+}
+
+func TestSlackSignatureDecoratorRejectsStaleRequests(t *testing.T) {
+	for _, timestamp := range []time.Time{time.Now().Add(-time.Minute * 10), time.Now().Add(time.Minute * 10)} {
+		req := newSignedRequest(t, "command=/interview", timestamp, testSigningSecret)
+		code, received := runSignatureDecorator(t, req)
+		assert.Equal(t, http.StatusUnauthorized, code)
+		assert.Equal(t, "", received)
+	}
+}
@@ -5,20 +5,29 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
+	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 
 	"github.com/nlopes/slack"
 	"github.com/quintilesims/iqvbot/db"
-	"github.com/quintilesims/iqvbot/models"
 	"github.com/quintilesims/iqvbot/slash"
 	"github.com/zpatrick/fireball"
 )
 
-// todo: Validate request tokens: https://github.com/nlopes/slack/blob/master/examples/slash/slash.go#L27
+// SlashCommandController handles slash commands and interactive callbacks from slack.
+// Requests over http should be verified with SlackSignatureDecorator before they reach its routes.
+// Slack requires a response within 3 seconds, so commands and callbacks are acknowledged immediately
+// and their results are posted to the request's response url once they finish.
 type SlashCommandController struct {
 	store    db.Store
 	commands []*slash.CommandSchema
+
+	// deferred tracks the responses that are running in the background.
+	// It is only used by tests, to wait for the responses to be posted.
+	deferred sync.WaitGroup
 }
 
 func NewSlashCommandController(store db.Store, commands ...*slash.CommandSchema) *SlashCommandController {
@@ -62,7 +71,7 @@ func (s *SlashCommandController) run(c *fireball.Context) (fireball.Response, er
 	}
 
 	if cmd == nil {
-		return nil, fmt.Errorf("No matching handler found for '%s'", req.Command)
+		return nil, slash.NewSlackMessageErrorf("No matching handler found for '%s'", req.Command)
 	}
 
 	if args := strings.Split(req.Text, " "); len(args) == 1 && args[0] == "help" {
@@ -75,67 +84,201 @@ func (s *SlashCommandController) run(c *fireball.Context) (fireball.Response, er
 		return fireball.NewJSONResponse(200, msg)
 	}
 
-	msg, err := cmd.Run(req)
+	return s.deferResponse(req.ResponseURL, func() (*slack.Message, error) {
+		msg, err := cmd.Run(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := slash.RegisterCallbacks(s.store, cmd.Name, msg); err != nil {
+			return nil, err
+		}
+
+		return msg, nil
+	}), nil
+}
+
+func (s *SlashCommandController) callback(c *fireball.Context) (fireball.Response, error) {
+	payload, err := parsePayload(c.Request.Body)
 	if err != nil {
 		return nil, err
 	}
 
-	callbacks := models.Callbacks{}
-	if err := s.store.Read(db.CallbacksKey, &callbacks); err != nil {
+	var interaction struct {
+		Type slack.InteractionType `json:"type"`
+	}
+
+	if err := json.Unmarshal(payload, &interaction); err != nil {
 		return nil, err
 	}
 
-	// map callback ids to a slash command name
-	for _, a := range msg.Attachments {
-		if a.CallbackID != "" {
-			callbacks[a.CallbackID] = cmd.Name
-		}
+	switch interaction.Type {
+	case slack.InteractionTypeBlockActions:
+		return s.blockAction(payload)
+	case slash.InteractionTypeViewSubmission:
+		return s.viewSubmission(payload)
 	}
 
-	if err := s.store.Write(db.CallbacksKey, callbacks); err != nil {
+	var req *slack.AttachmentActionCallback
+	if err := json.Unmarshal(payload, &req); err != nil {
 		return nil, err
 	}
 
-	return fireball.NewJSONResponse(200, msg)
+	return s.deferResponse(req.ResponseURL, func() (*slack.Message, error) {
+		commandName, callbackID, ok, err := slash.LookupCallback(s.store, req.CallbackID)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			return nil, slash.NewSlackMessageError("This message has expired: please run the command again")
+		}
+
+		var cmd *slash.CommandSchema
+		for _, command := range s.commands {
+			if command.Name == commandName {
+				cmd = command
+				break
+			}
+		}
+
+		if cmd == nil {
+			return nil, slash.NewSlackMessageErrorf("No matching handler found for '%s'", commandName)
+		}
+
+		// commands see the callback ids they created, without the namespace
+		req.CallbackID = callbackID
+		// callbacks that open a modal leave the original message as it is
+		msg, err := cmd.Callback(*req)
+		if err != nil || msg == nil {
+			return nil, err
+		}
+
+		// callbacks replace the original message with a different view, unless they delete it
+		if !msg.DeleteOriginal {
+			msg.ReplaceOriginal = true
+		}
+
+		if err := slash.RegisterCallbacks(s.store, cmd.Name, msg); err != nil {
+			return nil, err
+		}
+
+		return msg, nil
+	}), nil
 }
 
-func (s *SlashCommandController) callback(c *fireball.Context) (fireball.Response, error) {
-	req, err := parseCallback(c.Request.Body)
-	if err != nil {
+// blockAction runs the command that handles the Block Kit action in payload.
+// Slack ignores the response body for block actions, so the resulting message
+// is always posted to the interaction's response url.
+func (s *SlashCommandController) blockAction(payload []byte) (fireball.Response, error) {
+	var req slack.InteractionCallback
+	if err := json.Unmarshal(payload, &req); err != nil {
 		return nil, err
 	}
 
-	callbacks := models.Callbacks{}
-	if err := s.store.Read(db.CallbacksKey, &callbacks); err != nil {
-		return nil, err
+	if len(req.ActionCallback.BlockActions) == 0 {
+		return nil, fmt.Errorf("Block action callback has no actions")
 	}
 
-	commandName, ok := callbacks[req.CallbackID]
-	if !ok {
-		return nil, fmt.Errorf("No matching callback entry found for '%s'", req.CallbackID)
+	actionID := req.ActionCallback.BlockActions[0].ActionID
+
+	var cmd *slash.CommandSchema
+	for _, command := range s.commands {
+		for _, id := range command.BlockActionIDs {
+			if id == actionID {
+				cmd = command
+				break
+			}
+		}
+
+		if cmd != nil {
+			break
+		}
+	}
+
+	if cmd == nil {
+		return nil, fmt.Errorf("No matching handler found for action '%s'", actionID)
+	}
+
+	return s.deferResponse(req.ResponseURL, func() (*slack.Message, error) {
+		return cmd.BlockAction(req)
+	}), nil
+}
+
+// viewSubmission validates the submitted modal with the command that opened it.
+// Validation errors are displayed in the modal, and other messages or views for the user replace the modal.
+// Otherwise, the modal is closed immediately, and the rest of the command runs in the background
+// before its message is posted to the response url in the modal's metadata.
+func (s *SlashCommandController) viewSubmission(payload []byte) (fireball.Response, error) {
+	var req slash.ViewSubmission
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return nil, err
 	}
 
 	var cmd *slash.CommandSchema
 	for _, command := range s.commands {
-		if command.Name == commandName {
+		if command.Name == req.View.CallbackID && command.ViewSubmission != nil {
 			cmd = command
 			break
 		}
 	}
 
 	if cmd == nil {
-		return nil, slash.NewSlackMessageErrorf("No matching handler found for '%s'", commandName)
+		return nil, fmt.Errorf("No matching handler found for view '%s'", req.View.CallbackID)
 	}
 
-	msg, err := cmd.Callback(*req)
+	metadata, err := slash.ParseViewMetadata(req.View.PrivateMetadata)
 	if err != nil {
 		return nil, err
 	}
 
-	return fireball.NewJSONResponse(200, msg)
+	fn, err := cmd.ViewSubmission(req)
+	switch err := err.(type) {
+	case nil:
+	case *slash.ViewValidationError:
+		return fireball.NewJSONResponse(200, slash.NewViewErrorsResponse(err))
+	case *slash.ViewUpdateError:
+		return fireball.NewJSONResponse(200, slash.NewViewUpdateResponse(err.View))
+	case *slash.SlackMessageError:
+		text := slack.NewTextBlockObject(slack.MarkdownType, err.Text, false, false)
+		view := slash.NewModalView(req.View.CallbackID, "Something went wrong", slack.NewSectionBlock(text, nil, nil))
+		view.Close = slack.NewTextBlockObject(slack.PlainTextType, "Close", false, false)
+		return fireball.NewJSONResponse(200, slash.NewViewUpdateResponse(view))
+	default:
+		return nil, err
+	}
+
+	return s.deferResponse(metadata.ResponseURL, fn), nil
+}
+
+// deferResponse acknowledges a request immediately, and runs fn in the background.
+// The message that fn returns, or the error if it fails, is posted to the response url.
+// Nothing is posted if fn returns a nil message, e.g. when it opens a modal instead.
+func (s *SlashCommandController) deferResponse(responseURL string, fn func() (*slack.Message, error)) fireball.Response {
+	s.deferred.Add(1)
+	go func() {
+		defer s.deferred.Done()
+
+		msg, err := fn()
+		if err != nil {
+			e, ok := err.(*slash.SlackMessageError)
+			if !ok {
+				log.Printf("[ERROR] An unhandled error occured: %v", err)
+				e = slash.NewSlackMessageErrorf("Something went wrong: %v", err)
+			}
+
+			msg = &slack.Message{Msg: *e.Msg}
+		}
+
+		if err := postResponse(responseURL, msg); err != nil {
+			log.Printf("[ERROR] Failed to post response to %s: %v", responseURL, err)
+		}
+	}()
+
+	return fireball.NewResponse(200, nil, nil)
 }
 
-func parseCallback(body io.ReadCloser) (*slack.AttachmentActionCallback, error) {
+func parsePayload(body io.ReadCloser) ([]byte, error) {
 	// slack does something odd here, where instead of sending just json in
 	// the body, they send "payload=<json>" with the json url encoded
 	defer body.Close()
@@ -150,10 +293,29 @@ func parseCallback(body io.ReadCloser) (*slack.AttachmentActionCallback, error)
 		return nil, err
 	}
 
-	var callback *slack.AttachmentActionCallback
-	if err := json.Unmarshal([]byte(decodedJSON), &callback); err != nil {
-		return nil, err
+	return []byte(decodedJSON), nil
+}
+
+// postResponse sends msg to a slack response url
+func postResponse(responseURL string, msg *slack.Message) error {
+	if responseURL == "" || msg == nil {
+		return nil
+	}
+
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.Post(responseURL, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Slack responded with status %d", resp.StatusCode)
 	}
 
-	return callback, nil
+	return nil
 }
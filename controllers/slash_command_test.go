@@ -4,9 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/nlopes/slack"
 	"github.com/quintilesims/iqvbot/db"
@@ -17,6 +19,9 @@ import (
 )
 
 func TestSlashCommandControllerRun(t *testing.T) {
+	server, responses := newResponseServer(t)
+	defer server.Close()
+
 	var called bool
 	cmd := &slash.CommandSchema{
 		Name: "!test",
@@ -28,6 +33,7 @@ func TestSlashCommandControllerRun(t *testing.T) {
 
 	form := url.Values{}
 	form.Set("command", "!test")
+	form.Set("response_url", server.URL)
 	req := newFormRequest(t, form)
 	c := &fireball.Context{Request: req}
 
@@ -38,16 +44,23 @@ func TestSlashCommandControllerRun(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	assert.True(t, called)
+	// the command is acknowledged immediately, and its message is posted to the response url
 	recorder := unmarshalBody(t, resp, nil)
 	assert.Equal(t, 200, recorder.Code)
+	assert.Equal(t, "", recorder.Body.String())
+
+	controller.deferred.Wait()
+	assert.True(t, called)
+	if assert.Len(t, *responses, 1) {
+		assert.Equal(t, "!test:callback_id", (*responses)[0].Attachments[0].CallbackID)
+	}
 
 	callbacks := models.Callbacks{}
 	if err := store.Read(db.CallbacksKey, &callbacks); err != nil {
 		t.Fatal(err)
 	}
 
-	assert.Equal(t, "!test", callbacks["callback_id"])
+	assert.Equal(t, "!test", callbacks["!test:callback_id"].Command)
 }
 
 func TestSlashCommandControllerRunHelp(t *testing.T) {
@@ -89,17 +102,20 @@ func TestSlashCommandControllerRunError(t *testing.T) {
 }
 
 func TestSlashCommandControllerCallback(t *testing.T) {
-	var called bool
+	server, responses := newResponseServer(t)
+	defer server.Close()
+
+	var callbackID string
 	cmd := &slash.CommandSchema{
 		Name: "!test",
-		Callback: func(slack.AttachmentActionCallback) (*slack.Message, error) {
-			called = true
-			return &slack.Message{}, nil
+		Callback: func(req slack.AttachmentActionCallback) (*slack.Message, error) {
+			callbackID = req.CallbackID
+			return newSlackMessageWithCallback("other_callback_id"), nil
 		},
 	}
 
 	callbacks := models.Callbacks{
-		"callback_id": "!test",
+		"!test:callback_id": {Command: "!test", Created: time.Now()},
 	}
 
 	store := newMemoryStore(t)
@@ -107,7 +123,7 @@ func TestSlashCommandControllerCallback(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	encoded, err := json.Marshal(slack.AttachmentActionCallback{CallbackID: "callback_id"})
+	encoded, err := json.Marshal(slack.AttachmentActionCallback{CallbackID: "!test:callback_id", ResponseURL: server.URL})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -126,27 +142,409 @@ func TestSlashCommandControllerCallback(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	assert.True(t, called)
 	recorder := unmarshalBody(t, resp, nil)
 	assert.Equal(t, 200, recorder.Code)
+
+	controller.deferred.Wait()
+	assert.Equal(t, "callback_id", callbackID)
+	if assert.Len(t, *responses, 1) {
+		assert.True(t, (*responses)[0].ReplaceOriginal)
+		assert.Equal(t, "!test:other_callback_id", (*responses)[0].Attachments[0].CallbackID)
+	}
+
+	if err := store.Read(db.CallbacksKey, &callbacks); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "!test", callbacks["!test:other_callback_id"].Command)
 }
 
 func TestSlashCommandControllerCallbackError(t *testing.T) {
-	encoded, err := json.Marshal(slack.AttachmentActionCallback{CallbackID: "callback_id"})
+	body := fmt.Sprintf("payload=%s", url.QueryEscape("not json"))
+	req, err := http.NewRequest("POST", "https://test.com/", strings.NewReader(body))
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	escaped := url.QueryEscape(string(encoded))
-	body := fmt.Sprintf("payload=%s", escaped)
+	c := &fireball.Context{Request: req}
+	controller := NewSlashCommandController(newMemoryStore(t))
+	if _, err := controller.callback(c); err == nil {
+		t.Fatalf("Error was nil!")
+	}
+}
+
+func TestSlashCommandControllerCallbackExpired(t *testing.T) {
+	server, responses := newResponseServer(t)
+	defer server.Close()
+
+	cmd := &slash.CommandSchema{
+		Name: "!test",
+		Callback: func(slack.AttachmentActionCallback) (*slack.Message, error) {
+			t.Fatal("Callback should not have been called")
+			return nil, nil
+		},
+	}
+
+	store := newMemoryStore(t)
+	callbacks := models.Callbacks{
+		"!test:callback_id": {Command: "!test", Created: time.Now().Add(-time.Hour * 24 * 8)},
+	}
+
+	if err := store.Write(db.CallbacksKey, callbacks); err != nil {
+		t.Fatal(err)
+	}
+
+	controller := NewSlashCommandController(store, cmd)
+	for _, callbackID := range []string{"!test:callback_id", "!test:unknown"} {
+		encoded, err := json.Marshal(slack.AttachmentActionCallback{CallbackID: callbackID, ResponseURL: server.URL})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body := fmt.Sprintf("payload=%s", url.QueryEscape(string(encoded)))
+		req, err := http.NewRequest("POST", "https://test.com/", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := controller.callback(&fireball.Context{Request: req}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// errors are posted as new ephemeral messages, leaving the original message as it is
+	controller.deferred.Wait()
+	if assert.Len(t, *responses, 2) {
+		for _, response := range *responses {
+			assert.Contains(t, response.Text, "This message has expired")
+			assert.Equal(t, "ephemeral", response.ResponseType)
+			assert.False(t, response.ReplaceOriginal)
+		}
+	}
+}
+
+func TestSlashCommandControllerCallbackDeleteOriginal(t *testing.T) {
+	server, responses := newResponseServer(t)
+	defer server.Close()
+
+	cmd := &slash.CommandSchema{
+		Name: "!test",
+		Callback: func(slack.AttachmentActionCallback) (*slack.Message, error) {
+			return &slack.Message{Msg: slack.Msg{DeleteOriginal: true}}, nil
+		},
+	}
+
+	store := newMemoryStore(t)
+	callbacks := models.Callbacks{
+		"!test:callback_id": {Command: "!test", Created: time.Now()},
+	}
+
+	if err := store.Write(db.CallbacksKey, callbacks); err != nil {
+		t.Fatal(err)
+	}
+
+	encoded, err := json.Marshal(slack.AttachmentActionCallback{CallbackID: "!test:callback_id", ResponseURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := fmt.Sprintf("payload=%s", url.QueryEscape(string(encoded)))
+	req, err := http.NewRequest("POST", "https://test.com/", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	controller := NewSlashCommandController(store, cmd)
+	if _, err := controller.callback(&fireball.Context{Request: req}); err != nil {
+		t.Fatal(err)
+	}
+
+	controller.deferred.Wait()
+	if assert.Len(t, *responses, 1) {
+		assert.True(t, (*responses)[0].DeleteOriginal)
+		assert.False(t, (*responses)[0].ReplaceOriginal)
+	}
+}
+
+func TestSlashCommandControllerRunDeferredError(t *testing.T) {
+	server, responses := newResponseServer(t)
+	defer server.Close()
+
+	cmd := &slash.CommandSchema{
+		Name: "/test",
+		Run: func(req slack.SlashCommand) (*slack.Message, error) {
+			if req.Text == "denied" {
+				return nil, slash.NewSlackMessageError("permission denied")
+			}
+
+			return nil, fmt.Errorf("some error")
+		},
+	}
+
+	controller := NewSlashCommandController(newMemoryStore(t), cmd)
+	for _, text := range []string{"denied", "error"} {
+		form := url.Values{}
+		form.Set("command", "/test")
+		form.Set("text", text)
+		form.Set("response_url", server.URL)
+
+		resp, err := controller.run(&fireball.Context{Request: newFormRequest(t, form)})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		recorder := unmarshalBody(t, resp, nil)
+		assert.Equal(t, 200, recorder.Code)
+		controller.deferred.Wait()
+	}
+
+	if assert.Len(t, *responses, 2) {
+		assert.Equal(t, "permission denied", (*responses)[0].Text)
+		assert.Equal(t, "ephemeral", (*responses)[0].ResponseType)
+		assert.Equal(t, "Something went wrong: some error", (*responses)[1].Text)
+		assert.Equal(t, "ephemeral", (*responses)[1].ResponseType)
+	}
+}
+
+func TestSlashCommandControllerBlockAction(t *testing.T) {
+	var responses []slack.Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg slack.Message
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Fatal(err)
+		}
+
+		responses = append(responses, msg)
+	}))
+	defer server.Close()
+
+	cmd := &slash.CommandSchema{
+		Name:           "!test",
+		BlockActionIDs: []string{"action_id"},
+		BlockAction: func(req slack.InteractionCallback) (*slack.Message, error) {
+			if req.ActionCallback.BlockActions[0].Value == "bad" {
+				return nil, slash.NewSlackMessageError("bad value")
+			}
+
+			return &slack.Message{Msg: slack.Msg{Text: "ok", ReplaceOriginal: true}}, nil
+		},
+	}
+
+	controller := NewSlashCommandController(newMemoryStore(t), cmd)
+	for _, value := range []string{"good", "bad"} {
+		payload := fmt.Sprintf(`{"type": "block_actions", "response_url": "%s", "actions": [{"action_id": "action_id", "block_id": "block_id", "value": "%s"}]}`, server.URL, value)
+		body := fmt.Sprintf("payload=%s", url.QueryEscape(payload))
+		req, err := http.NewRequest("POST", "https://test.com/", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := controller.callback(&fireball.Context{Request: req})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		recorder := unmarshalBody(t, resp, nil)
+		assert.Equal(t, 200, recorder.Code)
+		controller.deferred.Wait()
+	}
+
+	if assert.Len(t, responses, 2) {
+		assert.Equal(t, "ok", responses[0].Text)
+		assert.True(t, responses[0].ReplaceOriginal)
+		assert.Equal(t, "bad value", responses[1].Text)
+		assert.Equal(t, "ephemeral", responses[1].ResponseType)
+	}
+}
+
+func TestSlashCommandControllerBlockActionError(t *testing.T) {
+	payload := `{"type": "block_actions", "actions": [{"action_id": "action_id", "block_id": "block_id"}]}`
+	body := fmt.Sprintf("payload=%s", url.QueryEscape(payload))
 	req, err := http.NewRequest("POST", "https://test.com/", strings.NewReader(body))
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	c := &fireball.Context{Request: req}
 	controller := NewSlashCommandController(newMemoryStore(t))
-	if _, err := controller.callback(c); err == nil {
+	if _, err := controller.callback(&fireball.Context{Request: req}); err == nil {
 		t.Fatalf("Error was nil!")
 	}
 }
+
+// newViewSubmissionContext returns a request that submits the /test modal,
+// with value in its only input and the response url in its metadata
+func newViewSubmissionContext(t *testing.T, responseURL, value string) *fireball.Context {
+	metadata := slash.ViewMetadata{ResponseURL: responseURL}
+	payload := map[string]interface{}{
+		"type": slash.InteractionTypeViewSubmission,
+		"view": map[string]interface{}{
+			"callback_id":      "/test",
+			"private_metadata": metadata.Encode(),
+			"state": map[string]interface{}{
+				"values": map[string]interface{}{
+					"block_id": map[string]interface{}{
+						"action_id": map[string]string{"type": "plain_text_input", "value": value},
+					},
+				},
+			},
+		},
+	}
+
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := fmt.Sprintf("payload=%s", url.QueryEscape(string(encoded)))
+	req, err := http.NewRequest("POST", "https://test.com/", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &fireball.Context{Request: req}
+}
+
+func TestSlashCommandControllerViewSubmission(t *testing.T) {
+	server, responses := newResponseServer(t)
+	defer server.Close()
+
+	cmd := &slash.CommandSchema{
+		Name: "/test",
+		ViewSubmission: func(req slash.ViewSubmission) (func() (*slack.Message, error), error) {
+			switch req.View.State.Get("block_id", "action_id").Value {
+			case "invalid":
+				verr := slash.NewViewValidationError()
+				verr.Add("block_id", "invalid value")
+				return nil, verr
+			case "denied":
+				return nil, slash.NewSlackMessageError("permission denied")
+			case "review":
+				view := slash.NewModalView("/test", "Please review")
+				return nil, &slash.ViewUpdateError{View: view, Reason: "needs review"}
+			default:
+				return func() (*slack.Message, error) {
+					return &slack.Message{Msg: slack.Msg{Text: "ok"}}, nil
+				}, nil
+			}
+		},
+	}
+
+	controller := NewSlashCommandController(newMemoryStore(t), cmd)
+
+	// successful submissions close the modal and post the message to the response url
+	resp, err := controller.callback(newViewSubmissionContext(t, server.URL, "valid"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := unmarshalBody(t, resp, nil)
+	assert.Equal(t, 200, recorder.Code)
+	assert.Equal(t, "", recorder.Body.String())
+
+	controller.deferred.Wait()
+	if assert.Len(t, *responses, 1) {
+		assert.Equal(t, "ok", (*responses)[0].Text)
+	}
+
+	// validation errors are displayed in the modal
+	resp, err = controller.callback(newViewSubmissionContext(t, server.URL, "invalid"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result slash.ViewSubmissionResponse
+	unmarshalBody(t, resp, &result)
+	assert.Equal(t, "errors", result.ResponseAction)
+	assert.Equal(t, map[string]string{"block_id": "invalid value"}, result.Errors)
+
+	// other messages replace the modal
+	resp, err = controller.callback(newViewSubmissionContext(t, server.URL, "denied"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var update struct {
+		ResponseAction string          `json:"response_action"`
+		View           json.RawMessage `json:"view"`
+	}
+
+	unmarshalBody(t, resp, &update)
+	assert.Equal(t, "update", update.ResponseAction)
+	assert.Contains(t, string(update.View), "permission denied")
+	assert.Len(t, *responses, 1)
+
+	// views that need review replace the modal
+	resp, err = controller.callback(newViewSubmissionContext(t, server.URL, "review"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unmarshalBody(t, resp, &update)
+	assert.Equal(t, "update", update.ResponseAction)
+	assert.Contains(t, string(update.View), "Please review")
+	assert.Len(t, *responses, 1)
+}
+
+func TestSlashCommandControllerViewSubmissionReturnsBeforeCommandFinishes(t *testing.T) {
+	server, responses := newResponseServer(t)
+	defer server.Close()
+
+	// the command blocks, like a slow notification to the interviewers, until it is released
+	release := make(chan struct{})
+	cmd := &slash.CommandSchema{
+		Name: "/test",
+		ViewSubmission: func(req slash.ViewSubmission) (func() (*slack.Message, error), error) {
+			return func() (*slack.Message, error) {
+				<-release
+				return &slack.Message{Msg: slack.Msg{Text: "ok"}}, nil
+			}, nil
+		},
+	}
+
+	controller := NewSlashCommandController(newMemoryStore(t), cmd)
+	resp, err := controller.callback(newViewSubmissionContext(t, server.URL, "valid"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := unmarshalBody(t, resp, nil)
+	assert.Equal(t, 200, recorder.Code)
+	assert.Len(t, *responses, 0)
+
+	close(release)
+	controller.deferred.Wait()
+	if assert.Len(t, *responses, 1) {
+		assert.Equal(t, "ok", (*responses)[0].Text)
+	}
+}
+
+func TestSlashCommandControllerRunOpensModal(t *testing.T) {
+	server, responses := newResponseServer(t)
+	defer server.Close()
+
+	cmd := &slash.CommandSchema{
+		Name: "/test",
+		Run: func(slack.SlashCommand) (*slack.Message, error) {
+			return nil, nil
+		},
+	}
+
+	form := url.Values{}
+	form.Set("command", "/test")
+	form.Set("response_url", server.URL)
+	c := &fireball.Context{Request: newFormRequest(t, form)}
+
+	controller := NewSlashCommandController(newMemoryStore(t), cmd)
+	resp, err := controller.run(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := unmarshalBody(t, resp, nil)
+	assert.Equal(t, 200, recorder.Code)
+	assert.Equal(t, "", recorder.Body.String())
+
+	// commands that open a modal don't post anything
+	controller.deferred.Wait()
+	assert.Len(t, *responses, 0)
+}
@@ -0,0 +1,242 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Socket mode envelope types, see https://api.slack.com/apis/connections/socket-implement
+const (
+	socketModeHello         = "hello"
+	socketModeDisconnect    = "disconnect"
+	socketModeSlashCommands = "slash_commands"
+	socketModeInteractive   = "interactive"
+	socketModeEventsAPI     = "events_api"
+)
+
+// How long to wait before reconnecting after a connection fails
+const socketModeRetryDelay = time.Second * 5
+
+// SocketModeClient receives slash commands, interactive callbacks, and events from slack over a websocket,
+// so the bot doesn't need a public url. Each request is served by the handler as if slack had posted it
+// to the matching route, and the handler's response is sent back in the request's acknowledgement.
+// Requests over the websocket are authenticated by the app-level token, so the handler
+// shouldn't use SlackSignatureDecorator.
+type SocketModeClient struct {
+	token    string
+	endpoint string
+	handler  http.Handler
+	client   *http.Client
+	dialer   *websocket.Dialer
+}
+
+// NewSocketModeClient creates a new SocketModeClient object.
+// The token is an app-level token with the connections:write scope, and the endpoint is usually slash.SlackAPIEndpoint.
+func NewSocketModeClient(token, endpoint string, handler http.Handler) *SocketModeClient {
+	return &SocketModeClient{
+		token:    token,
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		handler:  handler,
+		client:   http.DefaultClient,
+		dialer:   websocket.DefaultDialer,
+	}
+}
+
+// socketModeEnvelope is a message that slack sends over the websocket
+type socketModeEnvelope struct {
+	Type                   string          `json:"type"`
+	EnvelopeID             string          `json:"envelope_id"`
+	Payload                json.RawMessage `json:"payload"`
+	AcceptsResponsePayload bool            `json:"accepts_response_payload"`
+	RetryAttempt           int             `json:"retry_attempt"`
+	Reason                 string          `json:"reason"`
+}
+
+// socketModeAck acknowledges an envelope, optionally with the response to its request
+type socketModeAck struct {
+	EnvelopeID string          `json:"envelope_id"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+}
+
+// Run serves requests from slack forever, opening a new connection whenever slack closes the current one
+func (s *SocketModeClient) Run() {
+	for {
+		if err := s.connect(); err != nil {
+			log.Printf("[ERROR] Socket mode connection failed: %v", err)
+			time.Sleep(socketModeRetryDelay)
+		}
+	}
+}
+
+// connect opens a websocket connection and serves the requests that slack sends over it.
+// nil is returned once slack asks the client to reconnect.
+func (s *SocketModeClient) connect() error {
+	connectionURL, err := s.openConnection()
+	if err != nil {
+		return err
+	}
+
+	conn, _, err := s.dialer.Dial(connectionURL, nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// requests are served concurrently, but a websocket connection only supports one writer at a time
+	var writeMutex sync.Mutex
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		var envelope socketModeEnvelope
+		if err := conn.ReadJSON(&envelope); err != nil {
+			return err
+		}
+
+		switch envelope.Type {
+		case socketModeHello:
+			log.Printf("[INFO] Socket mode connection successful!")
+		case socketModeDisconnect:
+			log.Printf("[INFO] Slack closed the socket mode connection: %s", envelope.Reason)
+			return nil
+		case socketModeSlashCommands, socketModeInteractive, socketModeEventsAPI:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ack, ok := s.serve(envelope)
+				if !ok {
+					return
+				}
+
+				writeMutex.Lock()
+				defer writeMutex.Unlock()
+				if err := conn.WriteJSON(ack); err != nil {
+					log.Printf("[ERROR] Failed to acknowledge envelope %s: %v", envelope.EnvelopeID, err)
+				}
+			}()
+		default:
+			log.Printf("[DEBUG] Ignoring socket mode envelope of type '%s'", envelope.Type)
+		}
+	}
+}
+
+// openConnection returns the url of a new websocket connection to slack
+func (s *SocketModeClient) openConnection() (string, error) {
+	req, err := http.NewRequest("POST", s.endpoint+"/apps.connections.open", nil)
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+s.token)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Slack responded with status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+		URL   string `json:"url"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+
+	if !result.OK {
+		return "", fmt.Errorf("Failed to open socket mode connection: %s", result.Error)
+	}
+
+	return result.URL, nil
+}
+
+// serve runs the envelope's request through the handler.
+// A bool is also returned denoting if the envelope should be acknowledged:
+// slack sends unacknowledged envelopes again, so events that failed with a server error, e.g. because the bot's queue is full,
+// are left for slack to retry. Retries of events that were already queued are dropped by the EventsController.
+// Other requests are always acknowledged, since running a command twice could repeat its changes.
+func (s *SocketModeClient) serve(envelope socketModeEnvelope) (socketModeAck, bool) {
+	ack := socketModeAck{EnvelopeID: envelope.EnvelopeID}
+	req, err := newSocketModeRequest(envelope)
+	if err != nil {
+		log.Printf("[ERROR] Failed to read envelope %s: %v", envelope.EnvelopeID, err)
+		return ack, true
+	}
+
+	recorder := httptest.NewRecorder()
+	s.handler.ServeHTTP(recorder, req)
+
+	if envelope.Type == socketModeEventsAPI && recorder.Code >= http.StatusInternalServerError {
+		log.Printf("[WARN] %s responded to envelope %s with status %d: leaving it for slack to retry", req.URL.Path, envelope.EnvelopeID, recorder.Code)
+		return ack, false
+	}
+
+	if recorder.Code != http.StatusOK {
+		log.Printf("[WARN] %s responded to envelope %s with status %d", req.URL.Path, envelope.EnvelopeID, recorder.Code)
+		return ack, true
+	}
+
+	if body := recorder.Body.Bytes(); envelope.AcceptsResponsePayload && len(body) > 0 && json.Valid(body) {
+		ack.Payload = json.RawMessage(body)
+	}
+
+	return ack, true
+}
+
+// newSocketModeRequest converts an envelope into the request that slack would post to the matching route
+func newSocketModeRequest(envelope socketModeEnvelope) (*http.Request, error) {
+	var path, contentType string
+	var body []byte
+
+	switch envelope.Type {
+	case socketModeSlashCommands:
+		var fields map[string]interface{}
+		if err := json.Unmarshal(envelope.Payload, &fields); err != nil {
+			return nil, err
+		}
+
+		form := url.Values{}
+		for key, value := range fields {
+			form.Set(key, fmt.Sprint(value))
+		}
+
+		path, contentType, body = "/slack/message_action", "application/x-www-form-urlencoded", []byte(form.Encode())
+	case socketModeInteractive:
+		form := url.Values{"payload": {string(envelope.Payload)}}
+		path, contentType, body = "/slack/message_callback", "application/x-www-form-urlencoded", []byte(form.Encode())
+	case socketModeEventsAPI:
+		path, contentType, body = "/slack/events", "application/json", envelope.Payload
+	default:
+		return nil, fmt.Errorf("Unexpected envelope type '%s'", envelope.Type)
+	}
+
+	req, err := http.NewRequest("POST", path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	if envelope.RetryAttempt > 0 {
+		req.Header.Set("X-Slack-Retry-Num", strconv.Itoa(envelope.RetryAttempt))
+	}
+
+	return req, nil
+}
@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/zpatrick/fireball"
+)
+
+// newFakeSocketModeServer returns a server that acts as slack: it sends each envelope
+// over a socket mode connection, waits for the envelope to be acknowledged, then asks the client to disconnect
+func newFakeSocketModeServer(t *testing.T, envelopes []socketModeEnvelope, acks chan<- socketModeAck) *httptest.Server {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+
+	mux.HandleFunc("/apps.connections.open", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer xapp-token", r.Header.Get("Authorization"))
+		connectionURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/link"
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "url": connectionURL})
+	})
+
+	mux.HandleFunc("/link", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+
+		conn.WriteJSON(socketModeEnvelope{Type: socketModeHello})
+		for _, envelope := range envelopes {
+			if err := conn.WriteJSON(envelope); err != nil {
+				t.Error(err)
+				return
+			}
+
+			var ack socketModeAck
+			if err := conn.ReadJSON(&ack); err != nil {
+				t.Error(err)
+				return
+			}
+
+			acks <- ack
+		}
+
+		conn.WriteJSON(socketModeEnvelope{Type: socketModeDisconnect, Reason: "refresh_requested"})
+	})
+
+	return server
+}
+
+func TestSocketModeClient(t *testing.T) {
+	envelopes := []socketModeEnvelope{
+		{
+			Type:                   socketModeSlashCommands,
+			EnvelopeID:             "slash",
+			Payload:                json.RawMessage(`{"command": "/interview", "text": "add john doe", "is_enterprise_install": false}`),
+			AcceptsResponsePayload: true,
+		},
+		{
+			Type:       socketModeInteractive,
+			EnvelopeID: "interactive",
+			Payload:    json.RawMessage(`{"type": "block_actions", "user": {"id": "uid"}}`),
+		},
+		{
+			Type:         socketModeEventsAPI,
+			EnvelopeID:   "event",
+			Payload:      json.RawMessage(`{"type": "event_callback", "event": {"type": "message", "text": "iqvbot echo hi"}}`),
+			RetryAttempt: 1,
+		},
+	}
+
+	requests := map[string]*http.Request{}
+	bodies := map[string]string{}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		requests[r.URL.Path] = r
+		bodies[r.URL.Path] = string(body)
+		w.Write([]byte(`{"text": "some response"}`))
+	})
+
+	acks := make(chan socketModeAck, len(envelopes))
+	server := newFakeSocketModeServer(t, envelopes, acks)
+	defer server.Close()
+
+	client := NewSocketModeClient("xapp-token", server.URL, handler)
+	if err := client.connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	close(acks)
+	results := map[string]socketModeAck{}
+	for ack := range acks {
+		results[ack.EnvelopeID] = ack
+	}
+
+	// only requests that accept a response payload are acknowledged with the handler's response
+	assert.JSONEq(t, `{"text": "some response"}`, string(results["slash"].Payload))
+	assert.Nil(t, results["interactive"].Payload)
+	assert.Nil(t, results["event"].Payload)
+
+	if req := requests["/slack/message_action"]; assert.NotNil(t, req) {
+		assert.Equal(t, "application/x-www-form-urlencoded", req.Header.Get("Content-Type"))
+		assert.Contains(t, bodies["/slack/message_action"], "command=%2Finterview")
+		assert.Contains(t, bodies["/slack/message_action"], "text=add+john+doe")
+		assert.Contains(t, bodies["/slack/message_action"], "is_enterprise_install=false")
+	}
+
+	if payload, err := parsePayload(ioutil.NopCloser(strings.NewReader(bodies["/slack/message_callback"]))); assert.NoError(t, err) {
+		assert.JSONEq(t, string(envelopes[1].Payload), string(payload))
+	}
+
+	if req := requests["/slack/events"]; assert.NotNil(t, req) {
+		assert.Equal(t, "1", req.Header.Get("X-Slack-Retry-Num"))
+		assert.JSONEq(t, string(envelopes[2].Payload), bodies["/slack/events"])
+	}
+}
+
+func TestSocketModeClientOpenConnectionError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok": false, "error": "invalid_auth"}`))
+	}))
+	defer server.Close()
+
+	client := NewSocketModeClient("xapp-token", server.URL, http.NotFoundHandler())
+	if err := client.connect(); err == nil || !strings.Contains(err.Error(), "invalid_auth") {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestSocketModeClientRetriesFailedEvents(t *testing.T) {
+	events := make(chan slack.RTMEvent, 1)
+	controller := NewEventsController(events)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp, err := controller.receive(&fireball.Context{Request: r})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		resp.Write(w, r)
+	})
+
+	client := NewSocketModeClient("xapp-token", "https://slack.test", handler)
+	serve := func(eventID string, retryAttempt int) bool {
+		_, ok := client.serve(socketModeEnvelope{
+			Type:         socketModeEventsAPI,
+			EnvelopeID:   "envelope-" + eventID,
+			Payload:      json.RawMessage(`{"type": "event_callback", "event_id": "` + eventID + `", "event": {"type": "message", "text": "iqvbot echo hi"}}`),
+			RetryAttempt: retryAttempt,
+		})
+
+		return ok
+	}
+
+	assert.True(t, serve("eid1", 0))
+
+	// the queue is full, so the event isn't acknowledged and slack sends it again
+	assert.False(t, serve("eid2", 0))
+
+	// retries of queued events are acknowledged without being queued again
+	assert.True(t, serve("eid1", 1))
+	assert.Len(t, events, 1)
+
+	<-events
+	assert.True(t, serve("eid2", 1))
+	assert.Len(t, events, 1)
+}
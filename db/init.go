@@ -20,6 +20,10 @@ func Init(store Store) error {
 		return err
 	}
 
+	if err := initFunc(CalendarFeedsKey, models.CalendarFeeds{}); err != nil {
+		return err
+	}
+
 	if err := initFunc(CallbacksKey, models.Callbacks{}); err != nil {
 		return err
 	}
@@ -28,6 +32,10 @@ func Init(store Store) error {
 		return err
 	}
 
+	if err := initFunc(ConfigKey, models.Config{}); err != nil {
+		return err
+	}
+
 	if err := initFunc(InterviewsKey, models.Interviews{}); err != nil {
 		return err
 	}
@@ -36,6 +44,14 @@ func Init(store Store) error {
 		return err
 	}
 
+	if err := initFunc(KarmaHistoryKey, models.KarmaHistory{}); err != nil {
+		return err
+	}
+
+	if err := initFunc(KarmaSeasonsKey, models.KarmaSeasons{}); err != nil {
+		return err
+	}
+
 	if err := initFunc(KVSKey, map[string]string{}); err != nil {
 		return err
 	}
@@ -44,5 +60,13 @@ func Init(store Store) error {
 		return err
 	}
 
+	if err := initFunc(RemindersKey, models.Reminders{}); err != nil {
+		return err
+	}
+
+	if err := initFunc(RolesKey, models.Roles{}); err != nil {
+		return err
+	}
+
 	return nil
 }
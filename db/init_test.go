@@ -19,12 +19,18 @@ func TestInit(t *testing.T) {
 
 	expected := []string{
 		AliasesKey,
+		CalendarFeedsKey,
 		CallbacksKey,
 		CandidatesKey,
+		ConfigKey,
 		InterviewsKey,
 		KarmaKey,
+		KarmaHistoryKey,
+		KarmaSeasonsKey,
 		KVSKey,
 		PipelinesKey,
+		RemindersKey,
+		RolesKey,
 	}
 
 	assert.ElementsMatch(t, expected, keys)
@@ -14,11 +14,17 @@ type Store interface {
 
 // Keys used for writing/reading data to/from stores
 const (
-	AliasesKey    = "aliases"
-	CallbacksKey  = "callbacks"
-	CandidatesKey = "candidates"
-	InterviewsKey = "interviews"
-	KarmaKey      = "karma"
-	KVSKey        = "kvs"
-	PipelinesKey  = "pipelines"
+	AliasesKey       = "aliases"
+	CalendarFeedsKey = "calendar_feeds"
+	CallbacksKey     = "callbacks"
+	CandidatesKey    = "candidates"
+	ConfigKey        = "config"
+	InterviewsKey    = "interviews"
+	KarmaKey         = "karma"
+	KarmaHistoryKey  = "karma_history"
+	KarmaSeasonsKey  = "karma_seasons"
+	KVSKey           = "kvs"
+	PipelinesKey     = "pipelines"
+	RemindersKey     = "reminders"
+	RolesKey         = "roles"
 )
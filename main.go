@@ -14,6 +14,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/kballard/go-shellquote"
 	"github.com/nlopes/slack"
+	"github.com/quintilesims/iqvbot/auth"
 	"github.com/quintilesims/iqvbot/bot"
 	"github.com/quintilesims/iqvbot/controllers"
 	"github.com/quintilesims/iqvbot/db"
@@ -48,16 +49,37 @@ func main() {
 			Value:  9090,
 			EnvVar: "IB_PORT",
 		},
+		cli.StringFlag{
+			Name:   "mode",
+			Usage:  "how to receive events from slack: 'rtm' for the real time messaging api, 'events' for the events api, or 'socket' for socket mode",
+			Value:  "rtm",
+			EnvVar: "IB_MODE",
+		},
+		cli.StringFlag{
+			Name:   "url",
+			Usage:  "public url of the server, used in links to calendar feeds",
+			EnvVar: "IB_URL",
+		},
 		cli.StringFlag{
 			Name:   "slack-app-token",
 			Usage:  "authentication token for the slack application",
 			EnvVar: "IB_SLACK_APP_TOKEN",
 		},
+		cli.StringFlag{
+			Name:   "slack-app-level-token",
+			Usage:  "app-level token with the connections:write scope, used to connect to slack in socket mode",
+			EnvVar: "IB_SLACK_APP_LEVEL_TOKEN",
+		},
 		cli.StringFlag{
 			Name:   "slack-bot-token",
 			Usage:  "authentication token for the slack bot",
 			EnvVar: "IB_SLACK_BOT_TOKEN",
 		},
+		cli.StringFlag{
+			Name:   "slack-signing-secret",
+			Usage:  "signing secret used to verify requests from slack (optional in socket mode)",
+			EnvVar: "IB_SLACK_SIGNING_SECRET",
+		},
 		cli.StringFlag{
 			Name:   "tenor-key",
 			Usage:  "authentication key for the Tenor API",
@@ -84,6 +106,16 @@ func main() {
 			Usage:  "name of the dynamodb table",
 			EnvVar: "IB_DYNAMODB_TABLE",
 		},
+		cli.StringSliceFlag{
+			Name:   "admin",
+			Usage:  "slack user id that is granted the admin role on startup",
+			EnvVar: "IB_ADMINS",
+		},
+		cli.StringSliceFlag{
+			Name:   "karma-reactions",
+			Usage:  "emoji reactions that grant karma in emoji=delta format, e.g. 'tada=1'",
+			EnvVar: "IB_KARMA_REACTIONS",
+		},
 	}
 
 	iqvbot.Action = func(c *cli.Context) error {
@@ -122,6 +154,10 @@ func main() {
 			return err
 		}
 
+		if err := auth.GrantAdmins(store, c.StringSlice("admin")); err != nil {
+			return err
+		}
+
 		aliasStore := db.NewKeyValueStoreAdapter(store, db.AliasesKey)
 		kvsStore := db.NewKeyValueStoreAdapter(store, db.KVSKey)
 		triviaStore := slackbot.InMemoryTriviaStore{}
@@ -139,6 +175,63 @@ func main() {
 
 		client := slackbot.NewDualSlackClient(appToken, botToken)
 
+		// in socket mode, requests from slack arrive over a websocket instead of being signed
+		mode := c.String("mode")
+		signingSecret := c.String("slack-signing-secret")
+		if signingSecret == "" && mode != "socket" {
+			return fmt.Errorf("Slack Signing Secret is not set! (envvar: IB_SLACK_SIGNING_SECRET)")
+		}
+
+		karmaReactions := bot.DefaultKarmaReactions
+		if inputs := c.StringSlice("karma-reactions"); len(inputs) > 0 {
+			reactions, err := bot.ParseKarmaReactions(inputs)
+			if err != nil {
+				return err
+			}
+
+			karmaReactions = reactions
+		}
+
+		// in events mode, slack posts events to the server instead of sending them over a websocket.
+		// In socket mode, slack sends events, slash commands, and interactive callbacks over a websocket
+		// that the bot opens, so the server doesn't need to be reachable from slack.
+		var events chan slack.RTMEvent
+		var eventsController *controllers.EventsController
+		var reply func(text, channelID string)
+		var botUserID string
+
+		switch mode {
+		case "rtm":
+			rtm := client.NewRTM()
+			go rtm.ManageConnection()
+			defer rtm.Disconnect()
+
+			events = rtm.IncomingEvents
+			reply = func(text, channelID string) {
+				rtm.SendMessage(rtm.NewOutgoingMessage(text, channelID))
+			}
+		case "events", "socket":
+			if mode == "socket" && c.String("slack-app-level-token") == "" {
+				return fmt.Errorf("App-Level Token is not set! (envvar: IB_SLACK_APP_LEVEL_TOKEN)")
+			}
+
+			resp, err := client.AuthTest()
+			if err != nil {
+				return fmt.Errorf("The bot's auth token is invalid: %v", err)
+			}
+
+			botUserID = resp.UserID
+			events = make(chan slack.RTMEvent, 100)
+			eventsController = controllers.NewEventsController(events)
+			reply = func(text, channelID string) {
+				if _, _, err := client.PostMessage(channelID, slack.MsgOptionText(text, false)); err != nil {
+					log.Printf("[ERROR] Failed to post message to %s: %v", channelID, err)
+				}
+			}
+		default:
+			return fmt.Errorf("Mode '%s' is not supported: please use 'rtm', 'events', or 'socket'", mode)
+		}
+
 		// start the runners
 		defer runner.NewCleanupRunner(store).RunEvery(time.Hour).Stop()
 		defer runner.NewReminderRunner(store, client).RunEvery(time.Minute * 5).Stop()
@@ -151,17 +244,47 @@ func main() {
 			slackbot.NewStandardizeTextBehavior(),
 			slackbot.NewExpandPromptBehavior("!", "iqvbot "),
 			aliasBehavior,
-			bot.NewKarmaBehavior(store),
+			bot.NewKarmaBehavior(store, client),
+			bot.NewReactionKarmaBehavior(store, client, karmaReactions),
 		}
 
-		// spin-up our server to handle slash commands
+		// spin-up our server to handle slash commands, and events in events mode.
+		// In socket mode, requests from the websocket are served by the same routes,
+		// which are only served over http as well if the signing secret is set.
 		go func() {
+			views := slash.NewSlackViewsClient(botToken, slash.SlackAPIEndpoint)
 			commands := []*slash.CommandSchema{
-				slash.NewInterviewCommand(store).Schema(),
+				slash.NewInterviewCommand(store, views, client, c.String("url")).Schema(),
+				bot.NewCandidateSlashCommand(store).Schema(),
+				bot.NewHireSlashCommand(store).Schema(),
+			}
+
+			slashCommandController := controllers.NewSlashCommandController(store, commands...)
+			slackRoutes := func() []*fireball.Route {
+				routes := slashCommandController.Routes()
+				if eventsController != nil {
+					routes = append(routes, eventsController.Routes()...)
+				}
+
+				return routes
+			}
+
+			if mode == "socket" {
+				// requests over the websocket are authenticated by the app-level token instead of a signature
+				socketApp := fireball.NewApp(fireball.Decorate(slackRoutes(), fireball.LogDecorator()))
+				socketApp.ErrorHandler = controllers.ErrorHandler
+				go controllers.NewSocketModeClient(c.String("slack-app-level-token"), slash.SlackAPIEndpoint, socketApp).Run()
 			}
 
-			routes := controllers.NewSlashCommandController(store, commands...).Routes()
-			routes = fireball.Decorate(routes, fireball.LogDecorator())
+			// calendar apps can't sign their requests, so feeds are only protected by the token in their url
+			routes := fireball.Decorate(controllers.NewCalendarController(store).Routes(), fireball.LogDecorator())
+			if signingSecret != "" {
+				signedRoutes := fireball.Decorate(slackRoutes(),
+					fireball.LogDecorator(),
+					controllers.SlackSignatureDecorator(signingSecret))
+
+				routes = append(routes, signedRoutes...)
+			}
 
 			app := fireball.NewApp(routes)
 			app.ErrorHandler = controllers.ErrorHandler
@@ -171,14 +294,7 @@ func main() {
 			log.Fatal(http.ListenAndServe(port, app))
 		}()
 
-		// start the real time messaging api
-		rtm := client.NewRTM()
-		go rtm.ManageConnection()
-		defer rtm.Disconnect()
-
-		for e := range rtm.IncomingEvents {
-			info := rtm.GetInfo()
-
+		for e := range events {
 			for _, behavior := range behaviors {
 				if err := behavior(e); err != nil {
 					log.Printf("[ERROR] %s", err.Error())
@@ -187,6 +303,7 @@ func main() {
 
 			switch data := e.Data.(type) {
 			case *slack.ConnectedEvent:
+				botUserID = data.Info.User.ID
 				log.Printf("[INFO] Slack connection successful!")
 			case *slack.InvalidAuthEvent:
 				return fmt.Errorf("The bot's auth token is invalid")
@@ -196,10 +313,14 @@ func main() {
 					continue
 				}
 
+				// code blocks often contain unbalanced quotes, e.g. csv for '!candidate import'
 				args, err := shellquote.Split(text)
 				if err != nil {
-					m := rtm.NewOutgoingMessage(err.Error(), data.Channel)
-					rtm.SendMessage(m)
+					args, err = shellquote.Split(bot.StripCodeBlocks(text))
+				}
+
+				if err != nil {
+					reply(err.Error(), data.Channel)
 					continue
 				}
 
@@ -224,19 +345,23 @@ func main() {
 						aliasStore.Invalidate()
 						return nil
 					})),
-					bot.NewCandidateCommand(store, w),
+					bot.NewCandidateCommand(store, client, data.Msg, w),
+					bot.NewConfigCommand(store, data.Msg, w),
 					slackbot.NewDefineCommand(slackbot.DatamuseAPIEndpoint, w),
-					slackbot.NewDeleteCommand(client, info.User.ID, data.Channel),
+					slackbot.NewDeleteCommand(client, botUserID, data.Channel),
 					slackbot.NewEchoCommand(w),
 					slackbot.NewGIFCommand(slackbot.TenorAPIEndpoint, tenorKey, w),
-					bot.NewHireCommand(store, w),
-					bot.NewKarmaCommand(store, w),
+					bot.NewHireCommand(store, client, data.Msg, w),
+					bot.NewInterviewCommand(store, client, data.Msg, w),
+					bot.NewKarmaCommand(store, data.Msg, w),
 					slackbot.NewKVSCommand(kvsStore, w, slackbot.WithName("glossary"), slackbot.WithUsage("manage the glossary")),
-					slackbot.NewRepeatCommand(client, data.Channel, rtm.IncomingEvents, func(m slack.Message) bool {
+					bot.NewRemindCommand(store, client, data.Msg, w),
+					slackbot.NewRepeatCommand(client, data.Channel, events, func(m slack.Message) bool {
 						aliasBehavior(e)
 						text := data.Msg.Text
 					 	return strings.HasPrefix(text, "!") && !strings.HasPrefix(text, "!repeat")
 					}),
+					bot.NewRoleCommand(store, data.Msg, w),
 					slackbot.NewTriviaCommand(triviaStore, slackbot.OpenTDBAPIEndpoint, data.Channel, w),
 				}
 
@@ -244,13 +369,17 @@ func main() {
 					w.WriteString(err.Error())
 				}
 
+				// some commands, such as '!hire status', post their own messages
 				response := w.String()
+				if response == "" {
+					continue
+				}
+
 				if isDisplayingHelp {
 					response = fmt.Sprintf("```%s```", response)
 				}
 
-				m := rtm.NewOutgoingMessage(response, data.Channel)
-				rtm.SendMessage(m)
+				reply(response, data.Channel)
 			}
 		}
 
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// iCalendar methods, see https://tools.ietf.org/html/rfc5546#section-1.4
+const (
+	CalendarMethodPublish = "PUBLISH"
+	CalendarMethodCancel  = "CANCEL"
+)
+
+// TeamCalendarFeed is the owner of the calendar feed that contains every interview
+const TeamCalendarFeed = "team"
+
+// The format of UTC date-times in iCalendar objects
+const calendarTimeFormat = "20060102T150405Z"
+
+// CalendarFeeds maps the secret tokens in calendar feed urls to the user whose interviews are in the feed.
+// The team feed belongs to TeamCalendarFeed.
+type CalendarFeeds map[string]string
+
+// Token returns the token of the owner's feed, creating a new feed if the owner doesn't have one
+func (c CalendarFeeds) Token(owner string) (string, error) {
+	for token, o := range c {
+		if o == owner {
+			return token, nil
+		}
+	}
+
+	token, err := newCalendarToken()
+	if err != nil {
+		return "", err
+	}
+
+	c[token] = owner
+	return token, nil
+}
+
+// Reset replaces the token of the owner's feed, so the previous feed url stops working
+func (c CalendarFeeds) Reset(owner string) (string, error) {
+	for token, o := range c {
+		if o == owner {
+			delete(c, token)
+		}
+	}
+
+	return c.Token(owner)
+}
+
+// CalendarFeedURL returns the url of the feed with the specified token, given the public url of the bot's server
+func CalendarFeedURL(serverURL, token string) string {
+	return strings.TrimSuffix(serverURL, "/") + "/calendar/" + token + ".ics"
+}
+
+// newCalendarToken returns a random token that can't be guessed, since feed urls aren't authenticated
+func newCalendarToken() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("Failed to generate calendar token: %v", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// NewInterviewCalendar renders the interviews as an iCalendar object (RFC 5545) with the specified name.
+// Calendars with CalendarMethodCancel cancel the interviews in the calendar clients that imported them,
+// so their events have the next sequence number of each interview.
+// Interviews without a duration are assumed to last defaultDuration.
+func NewInterviewCalendar(name, method string, interviews Interviews, defaultDuration time.Duration, now time.Time) []byte {
+	w := &calendarWriter{}
+	w.Line("BEGIN:VCALENDAR")
+	w.Line("VERSION:2.0")
+	w.Line("PRODID:-//iqvbot//interviews//EN")
+	w.Line("CALSCALE:GREGORIAN")
+	w.Line("METHOD:" + method)
+	w.Line("X-WR-CALNAME:" + escapeCalendarText(name))
+
+	for _, interview := range interviews {
+		sequence, status := interview.Sequence, "CONFIRMED"
+		if method == CalendarMethodCancel {
+			sequence, status = interview.Sequence+1, "CANCELLED"
+		}
+
+		w.Line("BEGIN:VEVENT")
+		w.Line(fmt.Sprintf("UID:%s@iqvbot", interview.InterviewID))
+		w.Line("DTSTAMP:" + now.UTC().Format(calendarTimeFormat))
+		w.Line("DTSTART:" + interview.Time.UTC().Format(calendarTimeFormat))
+		w.Line("DTEND:" + interview.End(defaultDuration).UTC().Format(calendarTimeFormat))
+		w.Line(fmt.Sprintf("SEQUENCE:%d", sequence))
+		w.Line("STATUS:" + status)
+		w.Line("SUMMARY:" + escapeCalendarText(fmt.Sprintf("Interview with %s", interview.Candidate)))
+		w.Line("DESCRIPTION:" + escapeCalendarText(fmt.Sprintf("Use /interview in slack to view or change this interview (id: %s)", interview.InterviewID)))
+
+		if interview.Reminder > 0 && method != CalendarMethodCancel {
+			w.Line("BEGIN:VALARM")
+			w.Line("ACTION:DISPLAY")
+			w.Line(fmt.Sprintf("TRIGGER:-PT%dM", int(interview.Reminder.Minutes())))
+			w.Line("DESCRIPTION:" + escapeCalendarText(fmt.Sprintf("Interview with %s", interview.Candidate)))
+			w.Line("END:VALARM")
+		}
+
+		w.Line("END:VEVENT")
+	}
+
+	w.Line("END:VCALENDAR")
+	return w.Bytes()
+}
+
+// calendarWriter writes iCalendar content lines, folding lines longer than 75 octets
+type calendarWriter struct {
+	bytes.Buffer
+}
+
+func (w *calendarWriter) Line(line string) {
+	var length int
+	for _, r := range line {
+		// continuation lines start with a space, which counts towards their length
+		if size := len(string(r)); length+size > 75 {
+			w.WriteString("\r\n ")
+			length = 1
+		}
+
+		w.WriteRune(r)
+		length += len(string(r))
+	}
+
+	w.WriteString("\r\n")
+}
+
+func escapeCalendarText(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+	return replacer.Replace(text)
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendarFeedsToken(t *testing.T) {
+	feeds := CalendarFeeds{}
+	token, err := feeds.Token("uid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, token, 40)
+
+	again, err := feeds.Token("uid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, token, again)
+
+	reset, err := feeds.Reset("uid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NotEqual(t, token, reset)
+	assert.Equal(t, CalendarFeeds{reset: "uid"}, feeds)
+}
+
+func TestNewInterviewCalendar(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	interviews := Interviews{
+		{
+			InterviewID: "iid",
+			Candidate:   "Doe, John",
+			Time:        time.Date(2026, 10, 20, 14, 30, 0, 0, time.FixedZone("PDT", -7*60*60)),
+			Reminder:    time.Minute * 15,
+			Sequence:    2,
+		},
+	}
+
+	calendar := string(NewInterviewCalendar("Interviews", CalendarMethodPublish, interviews, time.Hour, now))
+	lines := strings.Split(strings.TrimSuffix(calendar, "\r\n"), "\r\n")
+
+	assert.Equal(t, "BEGIN:VCALENDAR", lines[0])
+	assert.Equal(t, "END:VCALENDAR", lines[len(lines)-1])
+	assert.Contains(t, lines, "METHOD:PUBLISH")
+	assert.Contains(t, lines, "UID:iid@iqvbot")
+	assert.Contains(t, lines, "DTSTAMP:20261019T120000Z")
+	assert.Contains(t, lines, "DTSTART:20261020T213000Z")
+	assert.Contains(t, lines, "DTEND:20261020T223000Z")
+	assert.Contains(t, lines, "SEQUENCE:2")
+	assert.Contains(t, lines, "STATUS:CONFIRMED")
+	assert.Contains(t, lines, `SUMMARY:Interview with Doe\, John`)
+	assert.Contains(t, lines, "TRIGGER:-PT15M")
+
+	// long lines are folded
+	for _, line := range lines {
+		assert.True(t, len(line) <= 75, "line is too long: %s", line)
+	}
+
+	assert.Contains(t, strings.Replace(calendar, "\r\n ", "", -1), "(id: iid)")
+
+	cancel := string(NewInterviewCalendar("Interviews", CalendarMethodCancel, interviews, time.Hour, now))
+	assert.Contains(t, cancel, "METHOD:CANCEL\r\n")
+	assert.Contains(t, cancel, "SEQUENCE:3\r\n")
+	assert.Contains(t, cancel, "STATUS:CANCELLED\r\n")
+	assert.NotContains(t, cancel, "VALARM")
+}
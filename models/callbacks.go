@@ -1,4 +1,35 @@
 package models
 
-// Callbacks track callback ids to slash command names
-type Callbacks map[string]string
+import (
+	"encoding/json"
+	"time"
+)
+
+// Callbacks track namespaced callback ids to the slash commands that created them
+type Callbacks map[string]Callback
+
+// A Callback records which slash command handles interactions with an attachment,
+// and when the attachment was last displayed
+type Callback struct {
+	Command string
+	Created time.Time
+}
+
+// UnmarshalJSON also accepts callbacks that were stored as just a command name.
+// Those don't have a creation time, so they are always expired.
+func (c *Callback) UnmarshalJSON(data []byte) error {
+	var command string
+	if err := json.Unmarshal(data, &command); err == nil {
+		*c = Callback{Command: command}
+		return nil
+	}
+
+	// the alias prevents json.Unmarshal from calling this method again
+	type callback Callback
+	return json.Unmarshal(data, (*callback)(c))
+}
+
+// IsExpired returns true if the callback was created at least expiry ago
+func (c Callback) IsExpired(now time.Time, expiry time.Duration) bool {
+	return now.Sub(c.Created) >= expiry
+}
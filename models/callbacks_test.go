@@ -0,0 +1,29 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCallbacksUnmarshal(t *testing.T) {
+	created := time.Date(2019, 1, 1, 9, 0, 0, 0, time.UTC)
+	data := `{"/interview:iid": {"Command": "/interview", "Created": "2019-01-01T09:00:00Z"}, "legacy": "/interview"}`
+
+	var callbacks Callbacks
+	if err := json.Unmarshal([]byte(data), &callbacks); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Callbacks{
+		"/interview:iid": {Command: "/interview", Created: created},
+		"legacy":         {Command: "/interview"},
+	}
+
+	assert.Equal(t, expected, callbacks)
+	assert.False(t, callbacks["/interview:iid"].IsExpired(created.Add(time.Minute), time.Hour))
+	assert.True(t, callbacks["/interview:iid"].IsExpired(created.Add(time.Hour), time.Hour))
+	assert.True(t, callbacks["legacy"].IsExpired(created, time.Hour))
+}
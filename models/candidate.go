@@ -3,6 +3,15 @@ package models
 import (
 	"sort"
 	"strings"
+	"time"
+)
+
+// different candidate event types
+const (
+	CandidateEventNote      = "note"
+	CandidateEventStage     = "stage"
+	CandidateEventStep      = "step"
+	CandidateEventInterview = "interview"
 )
 
 // Candidate models hold information about a specific candidate
@@ -10,6 +19,46 @@ type Candidate struct {
 	Name      string
 	ManagerID string
 	Meta      map[string]string
+	Events    CandidateEvents
+}
+
+// A CandidateEvent records something that happened to a candidate, such as a note being added
+type CandidateEvent struct {
+	Type   string
+	Time   time.Time
+	UserID string
+	Text   string
+}
+
+// CandidateEvents is a list of CandidateEvent objects
+type CandidateEvents []*CandidateEvent
+
+// Sort will sort the events chronologically, with the oldest events first
+func (c CandidateEvents) Sort() {
+	sort.SliceStable(c, func(i, j int) bool {
+		return c[i].Time.Before(c[j].Time)
+	})
+}
+
+// Page returns the events on the specified page, where pages start at 1.
+// The total number of pages is also returned.
+func (c CandidateEvents) Page(page, size int) (CandidateEvents, int) {
+	if size <= 0 {
+		size = 1
+	}
+
+	pages := (len(c) + size - 1) / size
+	start := (page - 1) * size
+	if page < 1 || start >= len(c) {
+		return CandidateEvents{}, pages
+	}
+
+	end := start + size
+	if end > len(c) {
+		end = len(c)
+	}
+
+	return c[start:end], pages
 }
 
 // The Candidates object is used to manage Candidate instances in a db.Store
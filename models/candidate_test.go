@@ -2,6 +2,7 @@ package models
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -87,3 +88,45 @@ func TestCandidateSort(t *testing.T) {
 	candidates.Sort(false)
 	assert.Equal(t, expected, candidates)
 }
+
+func TestCandidateEventsSort(t *testing.T) {
+	now := time.Now()
+	events := CandidateEvents{
+		{Text: "c", Time: now.Add(time.Hour)},
+		{Text: "a", Time: now.Add(-time.Hour)},
+		{Text: "b", Time: now},
+	}
+
+	events.Sort()
+	expected := CandidateEvents{
+		{Text: "a", Time: now.Add(-time.Hour)},
+		{Text: "b", Time: now},
+		{Text: "c", Time: now.Add(time.Hour)},
+	}
+
+	assert.Equal(t, expected, events)
+}
+
+func TestCandidateEventsPage(t *testing.T) {
+	events := CandidateEvents{
+		{Text: "a"},
+		{Text: "b"},
+		{Text: "c"},
+		{Text: "d"},
+		{Text: "e"},
+	}
+
+	cases := map[int]CandidateEvents{
+		0: {},
+		1: {{Text: "a"}, {Text: "b"}},
+		2: {{Text: "c"}, {Text: "d"}},
+		3: {{Text: "e"}},
+		4: {},
+	}
+
+	for page, expected := range cases {
+		result, pages := events.Page(page, 2)
+		assert.Equal(t, expected, result, "page %d", page)
+		assert.Equal(t, 3, pages)
+	}
+}
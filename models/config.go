@@ -0,0 +1,207 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// names of the settings that can be changed at runtime
+const (
+	SettingCallbackExpiry       = "callback-expiry"
+	SettingHiringReminderHour   = "hiring-reminder-hour"
+	SettingHiringReminderMinute = "hiring-reminder-minute"
+	SettingInterviewDuration    = "interview-duration"
+	SettingInterviewExpiry      = "interview-expiry"
+	SettingInterviewReminder    = "interview-reminder"
+	SettingListLimit            = "list-limit"
+	SettingTimeZone             = "time-zone"
+)
+
+// A Setting describes a configuration value that can be changed at runtime.
+// Validate returns an error if value is not allowed for the setting.
+type Setting struct {
+	Name     string
+	Usage    string
+	Default  string
+	Validate func(value string) error
+}
+
+// Settings lists the settings that can be changed at runtime, sorted by name
+var Settings = []Setting{
+	{
+		Name:     SettingCallbackExpiry,
+		Usage:    "How long the buttons and menus in slash command messages keep working",
+		Default:  "168h0m0s",
+		Validate: validateDurationBetween(time.Hour, time.Hour*24*365),
+	},
+	{
+		Name:     SettingHiringReminderHour,
+		Usage:    "The hour of the day (0-23) to remind managers about hiring pipelines",
+		Default:  "9",
+		Validate: validateIntBetween(0, 23),
+	},
+	{
+		Name:     SettingHiringReminderMinute,
+		Usage:    "The minute of the hour (0-59) to remind managers about hiring pipelines",
+		Default:  "0",
+		Validate: validateIntBetween(0, 59),
+	},
+	{
+		Name:     SettingInterviewDuration,
+		Usage:    "How long interviews last, unless specified otherwise",
+		Default:  "1h0m0s",
+		Validate: validateDurationBetween(time.Minute*15, MaxInterviewDuration),
+	},
+	{
+		Name:     SettingInterviewExpiry,
+		Usage:    "How long to keep interviews after they have happened",
+		Default:  "168h0m0s",
+		Validate: validateDurationBetween(time.Hour, time.Hour*24*365),
+	},
+	{
+		Name:     SettingInterviewReminder,
+		Usage:    "How long before an interview to remind the interviewers, unless specified otherwise",
+		Default:  "5m0s",
+		Validate: validateDurationBetween(0, MaxInterviewReminder),
+	},
+	{
+		Name:     SettingListLimit,
+		Usage:    "The maximum number of items to display in lists, unless specified otherwise",
+		Default:  "50",
+		Validate: validateIntBetween(1, 1000),
+	},
+	{
+		Name:     SettingTimeZone,
+		Usage:    "The IANA time zone used for users without a time zone in their slack profile, e.g. 'America/Los_Angeles'",
+		Default:  "America/Los_Angeles",
+		Validate: validateTimeZone,
+	},
+}
+
+// GetSetting returns the setting with the matching name.
+// A bool is also returned denoting if the setting exists or not.
+func GetSetting(name string) (Setting, bool) {
+	for _, setting := range Settings {
+		if setting.Name == name {
+			return setting, true
+		}
+	}
+
+	return Setting{}, false
+}
+
+// Config maps setting names to values that have been changed from their defaults
+type Config map[string]string
+
+// Get returns the current value of the setting, or its default if it hasn't been changed
+func (c Config) Get(name string) string {
+	if value, ok := c[name]; ok {
+		return value
+	}
+
+	setting, _ := GetSetting(name)
+	return setting.Default
+}
+
+// Set validates and changes the value of the setting.
+// Setting a value back to its default removes it from the config.
+func (c Config) Set(name, value string) error {
+	setting, ok := GetSetting(name)
+	if !ok {
+		return fmt.Errorf("'%s' is not a valid setting", name)
+	}
+
+	if err := setting.Validate(value); err != nil {
+		return fmt.Errorf("Invalid value for %s: %v", name, err)
+	}
+
+	if value == setting.Default {
+		delete(c, name)
+		return nil
+	}
+
+	c[name] = value
+	return nil
+}
+
+// Int returns the value of an integer setting.
+// The default is used if the current value cannot be parsed.
+func (c Config) Int(name string) int {
+	v, err := strconv.Atoi(c.Get(name))
+	if err != nil {
+		setting, _ := GetSetting(name)
+		v, _ = strconv.Atoi(setting.Default)
+	}
+
+	return v
+}
+
+// Duration returns the value of a duration setting.
+// The default is used if the current value cannot be parsed.
+func (c Config) Duration(name string) time.Duration {
+	d, err := time.ParseDuration(c.Get(name))
+	if err != nil {
+		setting, _ := GetSetting(name)
+		d, _ = time.ParseDuration(setting.Default)
+	}
+
+	return d
+}
+
+// Location returns the value of a time zone setting.
+// UTC is used if neither the current value nor the default can be loaded.
+func (c Config) Location(name string) *time.Location {
+	loc, err := time.LoadLocation(c.Get(name))
+	if err != nil {
+		setting, _ := GetSetting(name)
+		if loc, err = time.LoadLocation(setting.Default); err != nil {
+			return time.UTC
+		}
+	}
+
+	return loc
+}
+
+func validateIntBetween(min, max int) func(string) error {
+	return func(value string) error {
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("'%s' is not a whole number", value)
+		}
+
+		if v < min || v > max {
+			return fmt.Errorf("must be between %d and %d", min, max)
+		}
+
+		return nil
+	}
+}
+
+func validateDurationBetween(min, max time.Duration) func(string) error {
+	return func(value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("'%s' is not a valid duration, e.g. '30m' or '2h'", value)
+		}
+
+		if d < min || d > max {
+			return fmt.Errorf("must be between %s and %s", min, max)
+		}
+
+		return nil
+	}
+}
+
+func validateTimeZone(value string) error {
+	// time.LoadLocation treats an empty name as UTC and "Local" as the bot's own zone
+	if value == "" || value == "Local" {
+		return fmt.Errorf("'%s' is not a valid IANA time zone", value)
+	}
+
+	if _, err := time.LoadLocation(value); err != nil {
+		return fmt.Errorf("'%s' is not a valid IANA time zone", value)
+	}
+
+	return nil
+}
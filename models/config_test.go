@@ -0,0 +1,66 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigDefaults(t *testing.T) {
+	config := Config{}
+	assert.Equal(t, 9, config.Int(SettingHiringReminderHour))
+	assert.Equal(t, time.Hour*24*7, config.Duration(SettingInterviewExpiry))
+	assert.Equal(t, time.Minute*5, config.Duration(SettingInterviewReminder))
+	assert.Equal(t, 50, config.Int(SettingListLimit))
+	assert.Equal(t, "America/Los_Angeles", config.Location(SettingTimeZone).String())
+}
+
+func TestConfigSet(t *testing.T) {
+	config := Config{}
+	assert.NoError(t, config.Set(SettingHiringReminderHour, "10"))
+	assert.NoError(t, config.Set(SettingInterviewReminder, "15m"))
+	assert.NoError(t, config.Set(SettingTimeZone, "Europe/London"))
+
+	assert.Equal(t, 10, config.Int(SettingHiringReminderHour))
+	assert.Equal(t, time.Minute*15, config.Duration(SettingInterviewReminder))
+	assert.Equal(t, "Europe/London", config.Location(SettingTimeZone).String())
+
+	// setting a value back to its default removes it
+	assert.NoError(t, config.Set(SettingHiringReminderHour, "9"))
+	_, ok := config[SettingHiringReminderHour]
+	assert.False(t, ok)
+}
+
+func TestConfigSetErrors(t *testing.T) {
+	cases := map[string]string{
+		"unknown-setting":           "1",
+		SettingHiringReminderHour:   "24",
+		SettingHiringReminderMinute: "ten",
+		SettingInterviewExpiry:      "1m",
+		SettingInterviewReminder:    "25h",
+		SettingListLimit:            "0",
+		SettingTimeZone:             "Mars/Olympus_Mons",
+	}
+
+	for name, value := range cases {
+		config := Config{}
+		if err := config.Set(name, value); err == nil {
+			t.Errorf("%s=%s: error was nil!", name, value)
+		}
+
+		assert.Len(t, config, 0, name)
+	}
+}
+
+func TestConfigInvalidValuesUseDefaults(t *testing.T) {
+	config := Config{
+		SettingListLimit:       "many",
+		SettingInterviewExpiry: "forever",
+		SettingTimeZone:        "Nowhere/Special",
+	}
+
+	assert.Equal(t, 50, config.Int(SettingListLimit))
+	assert.Equal(t, time.Hour*24*7, config.Duration(SettingInterviewExpiry))
+	assert.Equal(t, "America/Los_Angeles", config.Location(SettingTimeZone).String())
+}
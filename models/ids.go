@@ -1,17 +1,16 @@
-package slash
+package models
 
 import (
 	"math/rand"
 	"time"
 )
 
-var PDT = time.FixedZone("PDT", -25200)
-
 func init() {
 	rand.Seed(time.Now().UTC().UnixNano())
 }
 
-func randomString(length int) string {
+// randomID returns a random alphanumeric string of the specified length
+func randomID(length int) string {
 	runes := []rune("0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
 
 	b := make([]rune, length)
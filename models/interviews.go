@@ -1,6 +1,16 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// The maximum amount of time before an interview that a reminder can be sent
+const MaxInterviewReminder = time.Hour * 24
+
+// The maximum length of an interview
+const MaxInterviewDuration = time.Hour * 8
 
 type Interview struct {
 	InterviewID    string
@@ -8,10 +18,111 @@ type Interview struct {
 	InterviewerIDs []string
 	Time           time.Time
 	Reminder       time.Duration
+
+	// Duration is zero for interviews scheduled before interviews had a duration
+	Duration time.Duration
+
+	// Sequence is incremented each time the interview changes, so calendar clients can tell which invite is the latest
+	Sequence int
+}
+
+// NewInterviewID returns a random id for a new interview
+func NewInterviewID() string {
+	return randomID(10)
+}
+
+// Validate returns an error if the interview cannot be scheduled
+func (i Interview) Validate() error {
+	if i.Candidate == "" {
+		return fmt.Errorf("The interview must have a candidate")
+	}
+
+	if i.Time.IsZero() {
+		return fmt.Errorf("The interview must have a time")
+	}
+
+	var hasInterviewer bool
+	for _, interviewerID := range i.InterviewerIDs {
+		if interviewerID != "" {
+			hasInterviewer = true
+		}
+	}
+
+	if !hasInterviewer {
+		return fmt.Errorf("The interview must have at least one interviewer")
+	}
+
+	if i.Reminder < 0 || i.Reminder > MaxInterviewReminder {
+		return fmt.Errorf("The reminder must be between 0 and %s before the interview", MaxInterviewReminder)
+	}
+
+	if i.Duration < 0 || i.Duration > MaxInterviewDuration {
+		return fmt.Errorf("The interview must last between 0 and %s", MaxInterviewDuration)
+	}
+
+	return nil
+}
+
+// End returns the time the interview finishes.
+// Interviews without a duration are assumed to last defaultDuration.
+func (i Interview) End(defaultDuration time.Duration) time.Time {
+	if i.Duration == 0 {
+		return i.Time.Add(defaultDuration)
+	}
+
+	return i.Time.Add(i.Duration)
+}
+
+// HasInterviewer returns true if the user is one of the interview's interviewers
+func (i Interview) HasInterviewer(userID string) bool {
+	for _, interviewerID := range i.InterviewerIDs {
+		if interviewerID == userID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SharedInterviewers returns the interviewers of i that also conduct the other interview
+func (i Interview) SharedInterviewers(other Interview) []string {
+	shared := []string{}
+	for _, interviewerID := range i.InterviewerIDs {
+		for _, otherID := range other.InterviewerIDs {
+			if interviewerID != "" && interviewerID == otherID {
+				shared = append(shared, interviewerID)
+				break
+			}
+		}
+	}
+
+	return shared
+}
+
+// Overlaps returns true if the interviews take place at the same time.
+// Interviews without a duration are assumed to last defaultDuration.
+func (i Interview) Overlaps(other Interview, defaultDuration time.Duration) bool {
+	return i.Time.Before(other.End(defaultDuration)) && other.Time.Before(i.End(defaultDuration))
 }
 
 type Interviews []*Interview
 
+// Conflicts returns the other interviews that overlap the interview and share at least one of its interviewers
+func (i Interviews) Conflicts(interview Interview, defaultDuration time.Duration) Interviews {
+	conflicts := Interviews{}
+	for _, other := range i {
+		if other.InterviewID == interview.InterviewID {
+			continue
+		}
+
+		if interview.Overlaps(*other, defaultDuration) && len(interview.SharedInterviewers(*other)) > 0 {
+			conflicts = append(conflicts, other)
+		}
+	}
+
+	return conflicts
+}
+
 func (i Interviews) Get(interviewID string) (*Interview, bool) {
 	for _, interview := range i {
 		if interview.InterviewID == interviewID {
@@ -21,3 +132,23 @@ func (i Interviews) Get(interviewID string) (*Interview, bool) {
 
 	return nil, false
 }
+
+// Delete will delete the interview with the matching id.
+// A bool is also returned denoting if the interview existed or not.
+func (i *Interviews) Delete(interviewID string) bool {
+	for j := 0; j < len(*i); j++ {
+		if (*i)[j].InterviewID == interviewID {
+			*i = append((*i)[:j], (*i)[j+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// Sort will sort the interviews chronologically, with the earliest interviews first
+func (i Interviews) Sort() {
+	sort.SliceStable(i, func(a, b int) bool {
+		return i[a].Time.Before(i[b].Time)
+	})
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterviewValidate(t *testing.T) {
+	valid := Interview{
+		Candidate:      "John Doe",
+		InterviewerIDs: []string{"uid"},
+		Time:           time.Now(),
+		Reminder:       time.Minute * 5,
+	}
+
+	assert.NoError(t, valid.Validate())
+
+	invalid := map[string]func(i *Interview){
+		"no candidate":         func(i *Interview) { i.Candidate = "" },
+		"no time":              func(i *Interview) { i.Time = time.Time{} },
+		"no interviewers":      func(i *Interview) { i.InterviewerIDs = nil },
+		"empty interviewer":    func(i *Interview) { i.InterviewerIDs = []string{""} },
+		"negative reminder":    func(i *Interview) { i.Reminder = -time.Minute },
+		"reminder is too long": func(i *Interview) { i.Reminder = MaxInterviewReminder + time.Minute },
+		"negative duration":    func(i *Interview) { i.Duration = -time.Minute },
+		"duration is too long": func(i *Interview) { i.Duration = MaxInterviewDuration + time.Minute },
+	}
+
+	for name, modify := range invalid {
+		interview := valid
+		modify(&interview)
+		if err := interview.Validate(); err == nil {
+			t.Errorf("%s: error was nil!", name)
+		}
+	}
+}
+
+func TestInterviewsConflicts(t *testing.T) {
+	start := time.Date(2026, 10, 20, 14, 0, 0, 0, time.UTC)
+	interviews := Interviews{
+		{InterviewID: "same", InterviewerIDs: []string{"uid1"}, Time: start},
+		{InterviewID: "overlaps", InterviewerIDs: []string{"uid2", "uid1"}, Time: start.Add(time.Minute * 30), Duration: time.Hour},
+		{InterviewID: "default duration", InterviewerIDs: []string{"uid1"}, Time: start.Add(-time.Minute * 45)},
+		{InterviewID: "adjacent", InterviewerIDs: []string{"uid1"}, Time: start.Add(time.Hour)},
+		{InterviewID: "ended", InterviewerIDs: []string{"uid1"}, Time: start.Add(-time.Hour), Duration: time.Minute * 30},
+		{InterviewID: "other interviewer", InterviewerIDs: []string{"uid3"}, Time: start},
+	}
+
+	interview := Interview{InterviewID: "same", InterviewerIDs: []string{"uid1"}, Time: start, Duration: time.Hour}
+	conflicts := interviews.Conflicts(interview, time.Hour)
+
+	ids := []string{}
+	for _, conflict := range conflicts {
+		ids = append(ids, conflict.InterviewID)
+	}
+
+	assert.Equal(t, []string{"overlaps", "default duration"}, ids)
+}
+
+func TestInterviewsDelete(t *testing.T) {
+	interviews := Interviews{
+		{InterviewID: "a"},
+		{InterviewID: "b"},
+	}
+
+	assert.True(t, interviews.Delete("a"))
+	assert.False(t, interviews.Delete("a"))
+	assert.Equal(t, Interviews{{InterviewID: "b"}}, interviews)
+}
+
+func TestInterviewsSort(t *testing.T) {
+	now := time.Now()
+	interviews := Interviews{
+		{InterviewID: "c", Time: now.Add(time.Hour * 2)},
+		{InterviewID: "a", Time: now},
+		{InterviewID: "b", Time: now.Add(time.Hour)},
+	}
+
+	interviews.Sort()
+	for i, id := range []string{"a", "b", "c"} {
+		assert.Equal(t, id, interviews[i].InterviewID)
+	}
+}
+
+func TestNewInterviewID(t *testing.T) {
+	id := NewInterviewID()
+	assert.Len(t, id, 10)
+	assert.NotEqual(t, id, NewInterviewID())
+}
@@ -1,23 +1,149 @@
 package models
 
 import (
+	"fmt"
+	"math"
 	"sort"
+	"strings"
+	"time"
 )
 
-// KarmaEntry holds information about a specific karma instance
+// MaxKarmaReasons is the maximum number of reasons stored for a single karma entry
+const MaxKarmaReasons = 10
+
+// Karma history is kept for thirty days; older messages can no longer revert their votes
+const KarmaHistoryExpiry = time.Hour * 24 * 30
+
+// KarmaHalfLife is the amount of time it takes for a vote to lose half of its decayed score
+const KarmaHalfLife = time.Hour * 24 * 30
+
+// Modes used to sort karma keys
+const (
+	KarmaSortTotal = "total"
+	KarmaSortDecay = "decay"
+)
+
+// DefaultKarmaSeasonName is the name given to karma archived before any season was started
+const DefaultKarmaSeasonName = "preseason"
+
+// KarmaEntry holds information about a specific karma instance.
+// Score holds the entry's time-decayed score as of Updated.
 type KarmaEntry struct {
 	Upvotes   int
 	Downvotes int
+	Reasons   []string
+	Score     float64
+	Updated   time.Time
+}
+
+// DecayedScore returns the entry's score at the specified time.
+// Each vote loses half of its weight every KarmaHalfLife.
+func (k KarmaEntry) DecayedScore(now time.Time) float64 {
+	if k.Updated.IsZero() {
+		return 0
+	}
+
+	elapsed := now.Sub(k.Updated)
+	return k.Score * math.Pow(0.5, float64(elapsed)/float64(KarmaHalfLife))
+}
+
+// KarmaVote holds information about a single change to a karma entry.
+// Applied is when the vote was first applied, so it can be reverted after its weight has decayed.
+type KarmaVote struct {
+	Key       string
+	Upvotes   int
+	Downvotes int
+	Reason    string
+	Applied   time.Time
+}
+
+// DecayedWeight returns the vote's share of an entry's score at the specified time.
+// Votes without an Applied time have their full weight.
+func (v KarmaVote) DecayedWeight(now time.Time) float64 {
+	weight := float64(v.Upvotes - v.Downvotes)
+	if v.Applied.IsZero() || !now.After(v.Applied) {
+		return weight
+	}
+
+	elapsed := now.Sub(v.Applied)
+	return weight * math.Pow(0.5, float64(elapsed)/float64(KarmaHalfLife))
+}
+
+// Same returns true if the votes change the same entry in the same way, regardless of when they were applied
+func (v KarmaVote) Same(other KarmaVote) bool {
+	return v.Key == other.Key &&
+		v.Upvotes == other.Upvotes &&
+		v.Downvotes == other.Downvotes &&
+		v.Reason == other.Reason
 }
 
 // The Karma object is used to manage KarmaEntrys in a db.Store
 type Karma map[string]KarmaEntry
 
-// SortKeys will return a slice of ordered keys.
+// KarmaMessage holds the votes produced by a single slack message
+type KarmaMessage struct {
+	Time      time.Time
+	Votes     []KarmaVote
+	Reactions []KarmaVote
+}
+
+// The KarmaHistory object is used to manage KarmaMessages in a db.Store
+// by using the messages' ids as keys
+type KarmaHistory map[string]*KarmaMessage
+
+// KarmaMessageID returns the id used to track the message with the specified channel and timestamp
+func KarmaMessageID(channelID, timestamp string) string {
+	return fmt.Sprintf("%s/%s", channelID, timestamp)
+}
+
+// Apply will add the upvotes, downvotes, and reason of the vote to the matching entry.
+// Votes that were applied in the past only add their decayed weight to the entry's score.
+// Only the most recent MaxKarmaReasons reasons are kept for each entry.
+func (k Karma) Apply(vote KarmaVote) KarmaEntry {
+	now := time.Now().UTC()
+	entry := k[vote.Key]
+	entry.Upvotes += vote.Upvotes
+	entry.Downvotes += vote.Downvotes
+	entry.Score = entry.DecayedScore(now) + vote.DecayedWeight(now)
+	entry.Updated = now
+	if vote.Reason != "" {
+		entry.Reasons = append(entry.Reasons, vote.Reason)
+		if len(entry.Reasons) > MaxKarmaReasons {
+			entry.Reasons = entry.Reasons[len(entry.Reasons)-MaxKarmaReasons:]
+		}
+	}
+
+	k[vote.Key] = entry
+	return entry
+}
+
+// Revert will remove the upvotes, downvotes, and reason of the vote from the matching entry.
+// Only the vote's decayed weight is removed from the entry's score, since the rest has already decayed.
+func (k Karma) Revert(vote KarmaVote) KarmaEntry {
+	now := time.Now().UTC()
+	entry := k[vote.Key]
+	entry.Upvotes -= vote.Upvotes
+	entry.Downvotes -= vote.Downvotes
+	entry.Score = entry.DecayedScore(now) - vote.DecayedWeight(now)
+	entry.Updated = now
+	for i := len(entry.Reasons) - 1; i >= 0 && vote.Reason != ""; i-- {
+		if entry.Reasons[i] == vote.Reason {
+			entry.Reasons = append(entry.Reasons[:i], entry.Reasons[i+1:]...)
+			break
+		}
+	}
+
+	k[vote.Key] = entry
+	return entry
+}
+
+// SortKeys will return a slice of keys ordered by the specified mode.
+// If mode is KarmaSortTotal, keys are ordered by their upvotes minus downvotes.
+// If mode is KarmaSortDecay, keys are ordered by their time-decayed scores.
 // If ascending is true, keys with the lowest karma are returned first.
 // If ascending is false, keys with the highest karma are returned first.
-func (k Karma) SortKeys(ascending bool) []string {
-	sorter := newKarmaSorter(k)
+func (k Karma) SortKeys(mode string, ascending bool) []string {
+	sorter := newKarmaSorter(k, mode)
 	if ascending {
 		sort.Sort(sorter)
 	} else {
@@ -28,19 +154,30 @@ func (k Karma) SortKeys(ascending bool) []string {
 }
 
 type karmaSorter struct {
-	karma Karma
-	keys  []string
+	karma  Karma
+	keys   []string
+	scores map[string]float64
 }
 
-func newKarmaSorter(karma Karma) *karmaSorter {
+func newKarmaSorter(karma Karma, mode string) *karmaSorter {
+	now := time.Now().UTC()
 	keys := make([]string, 0, len(karma))
-	for key := range karma {
+	scores := make(map[string]float64, len(karma))
+	for key, entry := range karma {
 		keys = append(keys, key)
+
+		switch mode {
+		case KarmaSortDecay:
+			scores[key] = entry.DecayedScore(now)
+		default:
+			scores[key] = float64(entry.Upvotes - entry.Downvotes)
+		}
 	}
 
 	return &karmaSorter{
-		karma: karma,
-		keys:  keys,
+		karma:  karma,
+		keys:   keys,
+		scores: scores,
 	}
 }
 
@@ -56,7 +193,42 @@ func (k *karmaSorter) Swap(i, j int) {
 
 // Less is a method to satisfy sort.Interface
 func (k *karmaSorter) Less(i, j int) bool {
-	entryI := k.karma[k.keys[i]]
-	entryJ := k.karma[k.keys[j]]
-	return (entryI.Upvotes - entryI.Downvotes) < (entryJ.Upvotes - entryJ.Downvotes)
+	return k.scores[k.keys[i]] < k.scores[k.keys[j]]
+}
+
+// KarmaSeason holds the karma entries of a past season
+type KarmaSeason struct {
+	Name  string
+	Start time.Time
+	End   time.Time
+	Karma Karma
+}
+
+// The KarmaSeasons object is used to manage KarmaSeasons in a db.Store.
+// The last season in the list is the current season, and does not have an End or Karma;
+// the current season's karma is stored separately.
+type KarmaSeasons []*KarmaSeason
+
+// Current returns the current season.
+// A bool is also returned denoting if a season has been started or not.
+func (k KarmaSeasons) Current() (*KarmaSeason, bool) {
+	if len(k) == 0 || !k[len(k)-1].End.IsZero() {
+		return nil, false
+	}
+
+	return k[len(k)-1], true
+}
+
+// Get will return the season with the matching name.
+// The name is not case sensitive.
+// A bool is also returned denoting if the season exists or not.
+func (k KarmaSeasons) Get(name string) (*KarmaSeason, bool) {
+	name = strings.ToLower(name)
+	for _, season := range k {
+		if strings.ToLower(season.Name) == name {
+			return season, true
+		}
+	}
+
+	return nil, false
 }
@@ -1,7 +1,9 @@
 package models
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -15,6 +17,146 @@ func TestKarmaSortKeys(t *testing.T) {
 		"four":  {Upvotes: 5, Downvotes: 0},
 	}
 
-	assert.Equal(t, []string{"one", "two", "three", "four", "five"}, karma.SortKeys(true))
-	assert.Equal(t, []string{"five", "four", "three", "two", "one"}, karma.SortKeys(false))
+	assert.Equal(t, []string{"one", "two", "three", "four", "five"}, karma.SortKeys(KarmaSortTotal, true))
+	assert.Equal(t, []string{"five", "four", "three", "two", "one"}, karma.SortKeys(KarmaSortTotal, false))
+}
+
+func TestKarmaSortKeysDecay(t *testing.T) {
+	now := time.Now().UTC()
+	karma := Karma{
+		"old":    {Upvotes: 100, Score: 100, Updated: now.Add(-KarmaHalfLife * 10)},
+		"recent": {Upvotes: 5, Score: 5, Updated: now},
+		"legacy": {Upvotes: 50},
+		"middle": {Upvotes: 8, Score: 8, Updated: now.Add(-KarmaHalfLife)},
+	}
+
+	assert.Equal(t, []string{"recent", "middle", "old", "legacy"}, karma.SortKeys(KarmaSortDecay, false))
+	assert.Equal(t, []string{"old", "legacy", "middle", "recent"}, karma.SortKeys(KarmaSortTotal, false))
+}
+
+func TestKarmaEntryDecayedScore(t *testing.T) {
+	now := time.Now().UTC()
+	entry := KarmaEntry{Score: 8, Updated: now}
+
+	assert.InDelta(t, 8, entry.DecayedScore(now), 0.001)
+	assert.InDelta(t, 4, entry.DecayedScore(now.Add(KarmaHalfLife)), 0.001)
+	assert.InDelta(t, 1, entry.DecayedScore(now.Add(KarmaHalfLife*3)), 0.001)
+	assert.Equal(t, float64(0), KarmaEntry{Upvotes: 10}.DecayedScore(now))
+}
+
+func TestKarmaApply(t *testing.T) {
+	karma := Karma{
+		"dogs": {Upvotes: 1, Downvotes: 1},
+	}
+
+	karma.Apply(KarmaVote{Key: "dogs", Upvotes: 1, Reason: "good boys"})
+	karma.Apply(KarmaVote{Key: "cats", Downvotes: 1})
+
+	dogs := karma["dogs"]
+	assert.Equal(t, 2, dogs.Upvotes)
+	assert.Equal(t, 1, dogs.Downvotes)
+	assert.Equal(t, []string{"good boys"}, dogs.Reasons)
+	assert.InDelta(t, 1, dogs.Score, 0.001)
+	assert.WithinDuration(t, time.Now(), dogs.Updated, time.Second)
+
+	cats := karma["cats"]
+	assert.Equal(t, 0, cats.Upvotes)
+	assert.Equal(t, 1, cats.Downvotes)
+	assert.Nil(t, cats.Reasons)
+	assert.InDelta(t, -1, cats.Score, 0.001)
+}
+
+func TestKarmaApplyLimitsReasons(t *testing.T) {
+	karma := Karma{}
+	for i := 0; i < MaxKarmaReasons+5; i++ {
+		karma.Apply(KarmaVote{Key: "dogs", Upvotes: 1, Reason: fmt.Sprintf("reason %d", i)})
+	}
+
+	reasons := karma["dogs"].Reasons
+	assert.Len(t, reasons, MaxKarmaReasons)
+	assert.Equal(t, fmt.Sprintf("reason %d", MaxKarmaReasons+4), reasons[len(reasons)-1])
+}
+
+func TestKarmaRevert(t *testing.T) {
+	karma := Karma{
+		"dogs": {Upvotes: 2, Downvotes: 1, Reasons: []string{"good boys", "fetch"}},
+	}
+
+	karma.Revert(KarmaVote{Key: "dogs", Upvotes: 1, Reason: "good boys"})
+	karma.Revert(KarmaVote{Key: "dogs", Downvotes: 1, Reason: "not a reason"})
+
+	dogs := karma["dogs"]
+	assert.Equal(t, 1, dogs.Upvotes)
+	assert.Equal(t, 0, dogs.Downvotes)
+	assert.Equal(t, []string{"fetch"}, dogs.Reasons)
+	assert.InDelta(t, 0, dogs.Score, 0.001)
+}
+
+func TestKarmaRevertDecayedVote(t *testing.T) {
+	now := time.Now().UTC()
+	vote := KarmaVote{Key: "dogs", Upvotes: 1, Applied: now.Add(-KarmaHalfLife)}
+	karma := Karma{
+		"dogs": {Upvotes: 1, Score: 1, Updated: vote.Applied},
+	}
+
+	karma.Revert(vote)
+
+	dogs := karma["dogs"]
+	assert.Equal(t, 0, dogs.Upvotes)
+	assert.InDelta(t, 0, dogs.Score, 0.001)
+}
+
+func TestKarmaRevertAndApplyKeepsDecay(t *testing.T) {
+	now := time.Now().UTC()
+	vote := KarmaVote{Key: "dogs", Upvotes: 2, Applied: now.Add(-KarmaHalfLife)}
+	karma := Karma{
+		"dogs": {Upvotes: 3, Score: 3, Updated: vote.Applied},
+	}
+
+	// re-applying a vote with its original time leaves the score as it was
+	karma.Revert(vote)
+	karma.Apply(vote)
+
+	dogs := karma["dogs"]
+	assert.Equal(t, 3, dogs.Upvotes)
+	assert.InDelta(t, 1.5, dogs.Score, 0.001)
+}
+
+func TestKarmaVoteDecayedWeight(t *testing.T) {
+	now := time.Now().UTC()
+
+	assert.InDelta(t, 4, KarmaVote{Upvotes: 4}.DecayedWeight(now), 0.001)
+	assert.InDelta(t, -1, KarmaVote{Downvotes: 2, Applied: now.Add(-KarmaHalfLife)}.DecayedWeight(now), 0.001)
+	assert.InDelta(t, 2, KarmaVote{Upvotes: 2, Applied: now}.DecayedWeight(now), 0.001)
+}
+
+func TestKarmaSeasons(t *testing.T) {
+	seasons := KarmaSeasons{}
+	if _, ok := seasons.Current(); ok {
+		t.Fatal("Empty seasons should not have a current season")
+	}
+
+	now := time.Now()
+	seasons = KarmaSeasons{
+		{Name: "preseason", End: now},
+		{Name: "Spring 2026", Start: now},
+	}
+
+	current, ok := seasons.Current()
+	if !ok {
+		t.Fatal("Current season was not found")
+	}
+
+	assert.Equal(t, "Spring 2026", current.Name)
+
+	season, ok := seasons.Get("PRESEASON")
+	if !ok {
+		t.Fatal("Season 'PRESEASON' was not found")
+	}
+
+	assert.Equal(t, "preseason", season.Name)
+
+	if _, ok := seasons.Get("summer"); ok {
+		t.Fatal("Season 'summer' should not exist")
+	}
 }
@@ -3,17 +3,118 @@ package models
 import (
 	"sort"
 	"strings"
+	"time"
 )
 
 // different pipeline types
 const HiringPipelineType = "hiring"
 
-// A Pipeline has a name and series of steps
+// A Pipeline has a name and series of steps.
+// CurrentStep is the index of the first incomplete step; steps after it
+// may have been completed out of order, which is tracked by Completed.
+// StepUpdated is the time CurrentStep last changed.
 type Pipeline struct {
 	Name        string
 	Type        string
 	CurrentStep int
 	Steps       []string
+	Completed   []bool
+	StepUpdated time.Time
+}
+
+// DaysOnStep returns the number of whole days the pipeline has been on its current step.
+// A bool is also returned denoting if the time the step started is known.
+func (p *Pipeline) DaysOnStep(now time.Time) (int, bool) {
+	if p.StepUpdated.IsZero() {
+		return 0, false
+	}
+
+	return int(now.Sub(p.StepUpdated).Hours() / 24), true
+}
+
+// CompletedSteps returns the number of steps that have been completed
+func (p *Pipeline) CompletedSteps() int {
+	var count int
+	for i := range p.Steps {
+		if p.IsComplete(i) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// IsComplete returns true if the step at index i has been completed
+func (p *Pipeline) IsComplete(i int) bool {
+	return i < p.CurrentStep || (i < len(p.Completed) && p.Completed[i])
+}
+
+// CompleteStep marks the step at index i as completed
+func (p *Pipeline) CompleteStep(i int) {
+	completed := p.completed()
+	completed[i] = true
+	p.update(completed)
+}
+
+// RevertStep marks the step at index i as incomplete
+func (p *Pipeline) RevertStep(i int) {
+	completed := p.completed()
+	completed[i] = false
+	p.update(completed)
+}
+
+// AddStep inserts an incomplete step at index i
+func (p *Pipeline) AddStep(i int, step string) {
+	completed := p.completed()
+	p.Steps = append(p.Steps[:i], append([]string{step}, p.Steps[i:]...)...)
+	p.update(append(completed[:i], append([]bool{false}, completed[i:]...)...))
+}
+
+// RemoveStep removes the step at index i
+func (p *Pipeline) RemoveStep(i int) {
+	completed := p.completed()
+	p.Steps = append(p.Steps[:i], p.Steps[i+1:]...)
+	p.update(append(completed[:i], completed[i+1:]...))
+}
+
+// MoveStep moves the step at index from to index to, keeping its completion state
+func (p *Pipeline) MoveStep(from, to int) {
+	completed := p.completed()
+	step, done := p.Steps[from], completed[from]
+
+	p.Steps = append(p.Steps[:from], p.Steps[from+1:]...)
+	completed = append(completed[:from], completed[from+1:]...)
+
+	p.Steps = append(p.Steps[:to], append([]string{step}, p.Steps[to:]...)...)
+	p.update(append(completed[:to], append([]bool{done}, completed[to:]...)...))
+}
+
+// completed returns a copy of the completion state of each step
+func (p *Pipeline) completed() []bool {
+	completed := make([]bool, len(p.Steps))
+	for i := range completed {
+		completed[i] = p.IsComplete(i)
+	}
+
+	return completed
+}
+
+// update stores the completion state of each step,
+// and moves CurrentStep to the first incomplete step
+func (p *Pipeline) update(completed []bool) {
+	previous := p.CurrentStep
+	p.Completed = completed
+	p.CurrentStep = len(p.Steps)
+	for i, done := range completed {
+		if !done {
+			p.CurrentStep = i
+			break
+		}
+	}
+
+	if p.CurrentStep != previous {
+		p.StepUpdated = time.Now()
+	}
 }
 
 // The Pipelines object is used to manage Pipelines in a db.Store
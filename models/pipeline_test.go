@@ -2,6 +2,7 @@ package models
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -103,3 +104,86 @@ func TestPipelineSort(t *testing.T) {
 	pipelines.Sort(false)
 	assert.Equal(t, expected, pipelines)
 }
+
+func TestPipelineCompleteStep(t *testing.T) {
+	pipeline := &Pipeline{Steps: []string{"a", "b", "c", "d"}}
+
+	pipeline.CompleteStep(2)
+	assert.Equal(t, 0, pipeline.CurrentStep)
+	assert.True(t, pipeline.IsComplete(2))
+
+	pipeline.CompleteStep(0)
+	assert.Equal(t, 1, pipeline.CurrentStep)
+
+	// completing the current step skips steps that were completed out of order
+	pipeline.CompleteStep(1)
+	assert.Equal(t, 3, pipeline.CurrentStep)
+
+	pipeline.RevertStep(pipeline.CurrentStep - 1)
+	assert.Equal(t, 2, pipeline.CurrentStep)
+	assert.Equal(t, []bool{true, true, false, false}, pipeline.Completed)
+}
+
+func TestPipelineAddStep(t *testing.T) {
+	// pipelines saved before steps could be completed out of order only have CurrentStep set
+	pipeline := &Pipeline{CurrentStep: 2, Steps: []string{"a", "b", "c"}}
+
+	pipeline.AddStep(1, "x")
+	assert.Equal(t, []string{"a", "x", "b", "c"}, pipeline.Steps)
+	assert.Equal(t, []bool{true, false, true, false}, pipeline.Completed)
+	assert.Equal(t, 1, pipeline.CurrentStep)
+
+	pipeline.AddStep(4, "y")
+	assert.Equal(t, []string{"a", "x", "b", "c", "y"}, pipeline.Steps)
+	assert.Equal(t, 1, pipeline.CurrentStep)
+}
+
+func TestPipelineRemoveStep(t *testing.T) {
+	pipeline := &Pipeline{CurrentStep: 1, Steps: []string{"a", "b", "c"}, Completed: []bool{true, false, true}}
+
+	pipeline.RemoveStep(1)
+	assert.Equal(t, []string{"a", "c"}, pipeline.Steps)
+	assert.Equal(t, 2, pipeline.CurrentStep)
+}
+
+func TestPipelineMoveStep(t *testing.T) {
+	pipeline := &Pipeline{CurrentStep: 1, Steps: []string{"a", "b", "c", "d"}}
+
+	pipeline.MoveStep(0, 2)
+	assert.Equal(t, []string{"b", "c", "a", "d"}, pipeline.Steps)
+	assert.Equal(t, []bool{false, false, true, false}, pipeline.Completed)
+	assert.Equal(t, 0, pipeline.CurrentStep)
+
+	pipeline.MoveStep(3, 0)
+	assert.Equal(t, []string{"d", "b", "c", "a"}, pipeline.Steps)
+	assert.Equal(t, []bool{false, false, false, true}, pipeline.Completed)
+}
+
+func TestPipelineStepUpdated(t *testing.T) {
+	pipeline := &Pipeline{Steps: []string{"a", "b", "c"}}
+
+	pipeline.CompleteStep(1)
+	assert.True(t, pipeline.StepUpdated.IsZero())
+
+	pipeline.CompleteStep(0)
+	assert.Equal(t, 2, pipeline.CurrentStep)
+	assert.False(t, pipeline.StepUpdated.IsZero())
+}
+
+func TestPipelineDaysOnStep(t *testing.T) {
+	now := time.Now()
+	pipeline := &Pipeline{}
+
+	_, ok := pipeline.DaysOnStep(now)
+	assert.False(t, ok)
+
+	pipeline.StepUpdated = now.Add(-time.Hour * 24 * 3).Add(-time.Hour)
+	days, ok := pipeline.DaysOnStep(now)
+	assert.True(t, ok)
+	assert.Equal(t, 3, days)
+}
+
+func TestPipelineCompletedSteps(t *testing.T) {
+	pipeline := &Pipeline{CurrentStep: 1, Steps: []string{"a", "b", "c", "d"}, Completed: []bool{true, false, false, true}}
+	assert.Equal(t, 2, pipeline.CompletedSteps())
+}
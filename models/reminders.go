@@ -0,0 +1,117 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// A Reminder is a message sent to a user or channel at a specific time.
+// If Weekdays is set, the reminder repeats on those days at the same time of day in TimeZone,
+// which is the IANA time zone of the reminder's creator.
+type Reminder struct {
+	ReminderID string
+	CreatorID  string
+	UserID     string
+	ChannelID  string
+	Text       string
+	Time       time.Time
+	TimeZone   string
+	Weekdays   []time.Weekday
+}
+
+// NewReminderID returns a random id for a new reminder.
+// Reminder ids are short since users type them to remove reminders.
+func NewReminderID() string {
+	return randomID(6)
+}
+
+// IsRecurring returns true if the reminder repeats
+func (r *Reminder) IsRecurring() bool {
+	return len(r.Weekdays) > 0
+}
+
+// Location returns the reminder's TimeZone.
+// If TimeZone is unset or invalid, the location of the reminder's Time is returned.
+func (r *Reminder) Location() *time.Location {
+	if r.TimeZone != "" {
+		if loc, err := time.LoadLocation(r.TimeZone); err == nil {
+			return loc
+		}
+	}
+
+	return r.Time.Location()
+}
+
+// Advance moves a recurring reminder's Time to its next occurrence after now.
+// Occurrences keep the same time of day in the reminder's Location, even across daylight saving changes.
+// A bool is also returned denoting if the reminder is recurring.
+func (r *Reminder) Advance(now time.Time) bool {
+	if !r.IsRecurring() {
+		return false
+	}
+
+	loc := r.Location()
+	scheduled := r.Time.In(loc)
+	after := scheduled
+	if now.After(after) {
+		after = now.In(loc)
+	}
+
+	r.Time = NextOccurrence(after, r.Weekdays, scheduled.Hour(), scheduled.Minute())
+	return true
+}
+
+// NextOccurrence returns the first time after the specified time that falls on one of the weekdays
+// at the specified hour and minute, in the location of after
+func NextOccurrence(after time.Time, weekdays []time.Weekday, hour, minute int) time.Time {
+	for i := 0; i <= 7; i++ {
+		d := after.AddDate(0, 0, i)
+		t := time.Date(d.Year(), d.Month(), d.Day(), hour, minute, 0, 0, after.Location())
+		if !t.After(after) {
+			continue
+		}
+
+		for _, weekday := range weekdays {
+			if t.Weekday() == weekday {
+				return t
+			}
+		}
+	}
+
+	return time.Time{}
+}
+
+// Reminders is a list of Reminder objects
+type Reminders []*Reminder
+
+// Get will return the reminder with the matching id.
+// A bool is also returned denoting if the reminder exists or not.
+func (r Reminders) Get(reminderID string) (*Reminder, bool) {
+	for _, reminder := range r {
+		if reminder.ReminderID == reminderID {
+			return reminder, true
+		}
+	}
+
+	return nil, false
+}
+
+// Delete will delete the reminder with the matching id.
+// A bool is also returned denoting if the reminder existed or not.
+func (r *Reminders) Delete(reminderID string) bool {
+	for i := 0; i < len(*r); i++ {
+		if (*r)[i].ReminderID == reminderID {
+			*r = append((*r)[:i], (*r)[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// Sort will sort the reminders chronologically, with the earliest reminders first
+func (r Reminders) Sort() {
+	sort.SliceStable(r, func(i, j int) bool {
+		return r[i].Time.Before(r[j].Time)
+	})
+}
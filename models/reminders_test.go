@@ -0,0 +1,94 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextOccurrence(t *testing.T) {
+	// 2018-01-03 is a Wednesday
+	after := time.Date(2018, 1, 3, 10, 0, 0, 0, time.UTC)
+	weekdays := []time.Weekday{time.Monday, time.Wednesday}
+
+	cases := map[string]struct {
+		Hour     int
+		Minute   int
+		Expected time.Time
+	}{
+		"later today":     {11, 30, time.Date(2018, 1, 3, 11, 30, 0, 0, time.UTC)},
+		"same time":       {10, 0, time.Date(2018, 1, 8, 10, 0, 0, 0, time.UTC)},
+		"earlier today":   {9, 0, time.Date(2018, 1, 8, 9, 0, 0, 0, time.UTC)},
+		"start of monday": {0, 0, time.Date(2018, 1, 8, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for name, c := range cases {
+		assert.Equal(t, c.Expected, NextOccurrence(after, weekdays, c.Hour, c.Minute), name)
+	}
+
+	assert.True(t, NextOccurrence(after, nil, 9, 0).IsZero())
+}
+
+func TestReminderAdvance(t *testing.T) {
+	scheduled := time.Date(2018, 1, 3, 9, 0, 0, 0, time.UTC)
+	reminder := &Reminder{Time: scheduled}
+	assert.False(t, reminder.Advance(scheduled))
+	assert.Equal(t, scheduled, reminder.Time)
+
+	reminder.Weekdays = []time.Weekday{time.Wednesday, time.Friday}
+	assert.True(t, reminder.Advance(scheduled))
+	assert.Equal(t, time.Date(2018, 1, 5, 9, 0, 0, 0, time.UTC), reminder.Time)
+
+	// reminders that were missed skip to the first occurrence after now
+	assert.True(t, reminder.Advance(time.Date(2018, 1, 11, 12, 0, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2018, 1, 12, 9, 0, 0, 0, time.UTC), reminder.Time)
+
+	// recurring reminders keep the same time of day in the creator's time zone
+	cases := map[string]struct {
+		TimeZone  string
+		Scheduled time.Time
+		Weekdays  []time.Weekday
+		Expected  time.Time
+	}{
+		// 9:00 AM on Wednesday in Tokyo is still Tuesday in UTC
+		"cross zone": {
+			TimeZone:  "Asia/Tokyo",
+			Scheduled: time.Date(2018, 1, 3, 0, 0, 0, 0, time.UTC),
+			Weekdays:  []time.Weekday{time.Wednesday, time.Friday},
+			Expected:  time.Date(2018, 1, 5, 0, 0, 0, 0, time.UTC),
+		},
+		// daylight saving time starts in New York on 2018-03-11
+		"daylight saving": {
+			TimeZone:  "America/New_York",
+			Scheduled: time.Date(2018, 3, 5, 14, 0, 0, 0, time.UTC),
+			Weekdays:  []time.Weekday{time.Monday},
+			Expected:  time.Date(2018, 3, 12, 13, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			loc, err := time.LoadLocation(c.TimeZone)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			reminder := &Reminder{Time: c.Scheduled, TimeZone: c.TimeZone, Weekdays: c.Weekdays}
+			assert.True(t, reminder.Advance(c.Scheduled))
+			assert.Equal(t, c.Expected, reminder.Time.UTC())
+			assert.Equal(t, c.Scheduled.In(loc).Hour(), reminder.Time.In(loc).Hour())
+		})
+	}
+}
+
+func TestRemindersDelete(t *testing.T) {
+	reminders := Reminders{
+		{ReminderID: "a"},
+		{ReminderID: "b"},
+	}
+
+	assert.True(t, reminders.Delete("a"))
+	assert.False(t, reminders.Delete("a"))
+	assert.Equal(t, Reminders{{ReminderID: "b"}}, reminders)
+}
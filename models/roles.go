@@ -0,0 +1,76 @@
+package models
+
+import "sort"
+
+// different user roles
+const (
+	RoleAdmin         = "admin"
+	RoleRecruiter     = "recruiter"
+	RoleHiringManager = "hiring-manager"
+	RoleInterviewer   = "interviewer"
+)
+
+// ValidRoles lists the roles that can be granted to users
+var ValidRoles = []string{
+	RoleAdmin,
+	RoleRecruiter,
+	RoleHiringManager,
+	RoleInterviewer,
+}
+
+// IsValidRole returns true if role is one of the ValidRoles
+func IsValidRole(role string) bool {
+	for _, r := range ValidRoles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Roles maps slack user ids to the roles granted to that user
+type Roles map[string][]string
+
+// Has returns true if the user has been granted the role
+func (r Roles) Has(userID, role string) bool {
+	for _, granted := range r[userID] {
+		if granted == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Grant gives the role to the user.
+// A bool is also returned denoting if the user did not already have the role.
+func (r Roles) Grant(userID, role string) bool {
+	if r.Has(userID, role) {
+		return false
+	}
+
+	r[userID] = append(r[userID], role)
+	sort.Strings(r[userID])
+	return true
+}
+
+// Revoke takes the role away from the user.
+// A bool is also returned denoting if the user had the role.
+func (r Roles) Revoke(userID, role string) bool {
+	roles := r[userID]
+	for i, granted := range roles {
+		if granted == role {
+			roles = append(roles[:i], roles[i+1:]...)
+			if len(roles) == 0 {
+				delete(r, userID)
+			} else {
+				r[userID] = roles
+			}
+
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRolesGrant(t *testing.T) {
+	roles := Roles{}
+
+	assert.True(t, roles.Grant("uid", RoleRecruiter))
+	assert.True(t, roles.Grant("uid", RoleAdmin))
+	assert.False(t, roles.Grant("uid", RoleAdmin))
+
+	assert.Equal(t, Roles{"uid": {RoleAdmin, RoleRecruiter}}, roles)
+	assert.True(t, roles.Has("uid", RoleAdmin))
+	assert.False(t, roles.Has("uid", RoleInterviewer))
+	assert.False(t, roles.Has("other", RoleAdmin))
+}
+
+func TestRolesRevoke(t *testing.T) {
+	roles := Roles{
+		"uid1": {RoleAdmin, RoleRecruiter},
+		"uid2": {RoleInterviewer},
+	}
+
+	assert.True(t, roles.Revoke("uid1", RoleAdmin))
+	assert.True(t, roles.Revoke("uid2", RoleInterviewer))
+	assert.False(t, roles.Revoke("uid1", RoleAdmin))
+	assert.False(t, roles.Revoke("uid3", RoleAdmin))
+
+	assert.Equal(t, Roles{"uid1": {RoleRecruiter}}, roles)
+}
+
+func TestIsValidRole(t *testing.T) {
+	for _, role := range ValidRoles {
+		assert.True(t, IsValidRole(role))
+	}
+
+	assert.False(t, IsValidRole("owner"))
+	assert.False(t, IsValidRole("Admin"))
+}
@@ -6,13 +6,13 @@ import (
 
 	"github.com/quintilesims/iqvbot/db"
 	"github.com/quintilesims/iqvbot/models"
+	"github.com/quintilesims/iqvbot/slash"
 )
 
-// Interviews expire after one week
-const InterviewExpiry = time.Hour * 24 * 7
-
 // NewCleanupRunner returns a runner that removes old data from the specified store.
-// This includes deleting interviews that are older than one week.
+// This includes deleting interviews that are older than the interview-expiry setting,
+// karma history that is older than models.KarmaHistoryExpiry,
+// and slash command callbacks that are older than the callback-expiry setting.
 func NewCleanupRunner(store db.Store) *Runner {
 	return &Runner{
 		Name: "Cleanup",
@@ -21,6 +21,14 @@ func NewCleanupRunner(store db.Store) *Runner {
 				return err
 			}
 
+			if err := cleanupKarmaHistory(store); err != nil {
+				return err
+			}
+
+			if err := slash.SweepCallbacks(store); err != nil {
+				return err
+			}
+
 			return nil
 		},
 	}
@@ -32,8 +40,14 @@ func cleanupInterviews(store db.Store) error {
 		return err
 	}
 
+	config := models.Config{}
+	if err := store.Read(db.ConfigKey, &config); err != nil {
+		return err
+	}
+
+	expiry := config.Duration(models.SettingInterviewExpiry)
 	for i := 0; i < len(interviews); i++ {
-		if time.Now().UTC().Sub(interviews[i].Time.UTC()) >= InterviewExpiry {
+		if time.Now().UTC().Sub(interviews[i].Time.UTC()) >= expiry {
 			log.Printf("[DEBUG] [Cleanup] Removing interview %s", interviews[i].InterviewID)
 			interviews = append(interviews[:i], interviews[i+1:]...)
 			i--
@@ -42,3 +56,19 @@ func cleanupInterviews(store db.Store) error {
 
 	return store.Write(db.InterviewsKey, interviews)
 }
+
+func cleanupKarmaHistory(store db.Store) error {
+	history := models.KarmaHistory{}
+	if err := store.Read(db.KarmaHistoryKey, &history); err != nil {
+		return err
+	}
+
+	for messageID, message := range history {
+		if time.Now().UTC().Sub(message.Time.UTC()) >= models.KarmaHistoryExpiry {
+			log.Printf("[DEBUG] [Cleanup] Removing karma history for message %s", messageID)
+			delete(history, messageID)
+		}
+	}
+
+	return store.Write(db.KarmaHistoryKey, history)
+}
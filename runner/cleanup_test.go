@@ -10,12 +10,13 @@ import (
 )
 
 func TestCleanupInterviews(t *testing.T) {
+	expiry := time.Hour * 24
 	now := time.Now().UTC()
 	interviews := models.Interviews{
-		{Candidate: "old1", Time: now.Add(-InterviewExpiry).UTC()},
-		{Candidate: "old2", Time: now.Add(-InterviewExpiry * 2).UTC()},
+		{Candidate: "old1", Time: now.Add(-expiry).UTC()},
+		{Candidate: "old2", Time: now.Add(-expiry * 2).UTC()},
 		{Candidate: "new1", Time: now.UTC()},
-		{Candidate: "new2", Time: now.Add(InterviewExpiry).UTC()},
+		{Candidate: "new2", Time: now.Add(expiry).UTC()},
 	}
 
 	store := newMemoryStore(t)
@@ -23,6 +24,11 @@ func TestCleanupInterviews(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	config := models.Config{models.SettingInterviewExpiry: expiry.String()}
+	if err := store.Write(db.ConfigKey, config); err != nil {
+		t.Fatal(err)
+	}
+
 	if err := cleanupInterviews(store); err != nil {
 		t.Fatal(err)
 	}
@@ -34,7 +40,36 @@ func TestCleanupInterviews(t *testing.T) {
 
 	expected := models.Interviews{
 		{Candidate: "new1", Time: now.UTC()},
-		{Candidate: "new2", Time: now.Add(InterviewExpiry).UTC()},
+		{Candidate: "new2", Time: now.Add(expiry).UTC()},
+	}
+
+	assert.Equal(t, expected, result)
+}
+
+func TestCleanupKarmaHistory(t *testing.T) {
+	now := time.Now().UTC()
+	history := models.KarmaHistory{
+		"c/old1": {Time: now.Add(-models.KarmaHistoryExpiry)},
+		"c/old2": {Time: now.Add(-models.KarmaHistoryExpiry * 2)},
+		"c/new1": {Time: now},
+	}
+
+	store := newMemoryStore(t)
+	if err := store.Write(db.KarmaHistoryKey, history); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cleanupKarmaHistory(store); err != nil {
+		t.Fatal(err)
+	}
+
+	result := models.KarmaHistory{}
+	if err := store.Read(db.KarmaHistoryKey, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := models.KarmaHistory{
+		"c/new1": {Time: now},
 	}
 
 	assert.Equal(t, expected, result)
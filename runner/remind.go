@@ -4,23 +4,20 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/nlopes/slack"
 	"github.com/quintilesims/iqvbot/db"
 	"github.com/quintilesims/iqvbot/models"
+	"github.com/quintilesims/iqvbot/slash"
+	"github.com/quintilesims/iqvbot/utils"
 	"github.com/zpatrick/slackbot"
 )
 
-// The hour and minute to send hiring pipeline reminders
-const (
-	HiringPipelineReminderHour   = 9
-	HiringPipelineReminderMinute = 0
-)
-
 // NewReminderRunner will return a runner that will send reminders to slack users.
 // Each time the runner executes, it will read from the store and
-func NewReminderRunner(store db.Store, client slackbot.SlackClient) *Runner {
+func NewReminderRunner(store db.Store, client utils.SlackClient) *Runner {
 	timers := []*time.Timer{}
 	return &Runner{
 		Name: "Remind",
@@ -35,18 +32,24 @@ func NewReminderRunner(store db.Store, client slackbot.SlackClient) *Runner {
 				return err
 			}
 
+			userReminderTimers, err := getUserReminderTimers(store, client)
+			if err != nil {
+				return err
+			}
+
 			// stop all of our timers before overwriting them
 			for i := 0; i < len(timers); i++ {
 				timers[i].Stop()
 			}
 
 			timers = append(hiringPipelineTimers, interviewTimers...)
+			timers = append(timers, userReminderTimers...)
 			return nil
 		},
 	}
 }
 
-func getHiringPipelineTimers(store db.Store, client slackbot.SlackClient) ([]*time.Timer, error) {
+func getHiringPipelineTimers(store db.Store, client utils.SlackClient) ([]*time.Timer, error) {
 	pipelines := models.Pipelines{}
 	if err := store.Read(db.PipelinesKey, &pipelines); err != nil {
 		return nil, err
@@ -57,6 +60,11 @@ func getHiringPipelineTimers(store db.Store, client slackbot.SlackClient) ([]*ti
 		return nil, err
 	}
 
+	config := models.Config{}
+	if err := store.Read(db.ConfigKey, &config); err != nil {
+		return nil, err
+	}
+
 	pipelines.FilterByType(models.HiringPipelineType)
 	for i := 0; i < len(pipelines); i++ {
 		if pipelines[i].CurrentStep >= len(pipelines[i].Steps) {
@@ -65,14 +73,9 @@ func getHiringPipelineTimers(store db.Store, client slackbot.SlackClient) ([]*ti
 		}
 	}
 
-	// set a reminder for today or tomorrow if the remind time has already passed
-	now := time.Now()
-	remindTime := time.Date(now.Year(), now.Month(), now.Day(), HiringPipelineReminderHour, HiringPipelineReminderMinute, 0, 0, time.Local)
-	if now.After(remindTime) {
-		remindTime.AddDate(0, 0, 1)
-	}
-
-	d := time.Until(remindTime)
+	hour := config.Int(models.SettingHiringReminderHour)
+	minute := config.Int(models.SettingHiringReminderMinute)
+	fallback := config.Location(models.SettingTimeZone)
 
 	timers := make([]*time.Timer, len(pipelines))
 	for i := 0; i < len(pipelines); i++ {
@@ -82,6 +85,10 @@ func getHiringPipelineTimers(store db.Store, client slackbot.SlackClient) ([]*ti
 			return nil, fmt.Errorf("could not find candidate %s", pipeline.Name)
 		}
 
+		// managers are reminded at the same time of day in their own time zone
+		loc := utils.UserLocation(client, candidate.ManagerID, fallback)
+		d := time.Until(nextRemindTime(time.Now().In(loc), hour, minute))
+
 		timer := time.AfterFunc(d, func() {
 			text := "Hello! Just reminding you to "
 			text += fmt.Sprintf("finish the hiring pipeline for *%s*. \n", strings.Title(candidate.Name))
@@ -104,12 +111,30 @@ func getHiringPipelineTimers(store db.Store, client slackbot.SlackClient) ([]*ti
 	return timers, nil
 }
 
-func getInterviewTimers(store db.Store, client slackbot.SlackClient) ([]*time.Timer, error) {
+// nextRemindTime returns the next time after now that is at hour:minute in now's location
+func nextRemindTime(now time.Time, hour, minute int) time.Time {
+	remindTime := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+	if now.After(remindTime) {
+		remindTime = remindTime.AddDate(0, 0, 1)
+	}
+
+	return remindTime
+}
+
+func getInterviewTimers(store db.Store, client utils.SlackClient) ([]*time.Timer, error) {
 	interviews := models.Interviews{}
 	if err := store.Read(db.InterviewsKey, &interviews); err != nil {
 		return nil, err
 	}
 
+	config := models.Config{}
+	if err := store.Read(db.ConfigKey, &config); err != nil {
+		return nil, err
+	}
+
+	// slack displays the interview time in each interviewer's time zone
+	fallback := config.Location(models.SettingTimeZone)
+
 	timers := []*time.Timer{}
 	for i := 0; i < len(interviews); i++ {
 		interview := interviews[i]
@@ -122,7 +147,9 @@ func getInterviewTimers(store db.Store, client slackbot.SlackClient) ([]*time.Ti
 			for _, interviewerID := range interview.InterviewerIDs {
 				text := "Hello! Just reminding you that "
 				text += fmt.Sprintf("you have an interview with *%s* ", interview.Candidate)
-				text += fmt.Sprintf(" in %d minutes", int(interview.Reminder.Minutes()))
+				text += fmt.Sprintf("at %s (in %d minutes)",
+					slash.FormatSlackDate(interview.Time, slash.SlackTimeToken, slash.TimeDisplayFormat, fallback),
+					int(interview.Reminder.Minutes()))
 
 				_, _, channelID, err := client.OpenIMChannel(interviewerID)
 				if err != nil {
@@ -140,3 +167,94 @@ func getInterviewTimers(store db.Store, client slackbot.SlackClient) ([]*time.Ti
 
 	return timers, nil
 }
+
+// userReminderMutex prevents a reminder from being sent twice when the runner
+// reschedules a reminder while its timer is firing
+var userReminderMutex sync.Mutex
+
+func getUserReminderTimers(store db.Store, client utils.SlackClient) ([]*time.Timer, error) {
+	reminders := models.Reminders{}
+	if err := store.Read(db.RemindersKey, &reminders); err != nil {
+		return nil, err
+	}
+
+	timers := make([]*time.Timer, len(reminders))
+	for i := 0; i < len(reminders); i++ {
+		timers[i] = ScheduleUserReminder(store, client, reminders[i])
+	}
+
+	return timers, nil
+}
+
+// ScheduleUserReminder creates a timer that sends the reminder at its scheduled time.
+// New reminders are scheduled as soon as they are created, since the runner may not execute again before they are due.
+// A reminder is only sent once, even if it is scheduled more than once.
+func ScheduleUserReminder(store db.Store, client utils.SlackClient, reminder *models.Reminder) *time.Timer {
+	reminderID := reminder.ReminderID
+	scheduled := reminder.Time
+
+	// reminders that were due while the bot was offline are sent immediately
+	d := time.Until(scheduled)
+	if d < 0 {
+		d = 0
+	}
+
+	return time.AfterFunc(d, func() {
+		if err := sendUserReminder(store, client, reminderID, scheduled); err != nil {
+			log.Printf("[ERROR] [Reminder] %v", err)
+		}
+	})
+}
+
+// sendUserReminder sends the reminder if it is still scheduled for the specified time.
+// Afterwards, recurring reminders are moved to their next occurrence and other reminders are deleted.
+func sendUserReminder(store db.Store, client utils.SlackClient, reminderID string, scheduled time.Time) error {
+	userReminderMutex.Lock()
+	defer userReminderMutex.Unlock()
+
+	reminders := models.Reminders{}
+	if err := store.Read(db.RemindersKey, &reminders); err != nil {
+		return err
+	}
+
+	// the reminder may have been removed or already sent since the timer was created
+	reminder, ok := reminders.Get(reminderID)
+	if !ok || !reminder.Time.Equal(scheduled) {
+		return nil
+	}
+
+	channelID := reminder.ChannelID
+	if reminder.UserID != "" {
+		_, _, imChannelID, err := client.OpenIMChannel(reminder.UserID)
+		if err != nil {
+			return err
+		}
+
+		channelID = imChannelID
+	}
+
+	text := fmt.Sprintf("Hello! %s asked me to remind you: %s", slackbot.EscapeUserID(reminder.CreatorID), reminder.Text)
+	if reminder.CreatorID == reminder.UserID {
+		text = fmt.Sprintf("Hello! Just reminding you: %s", reminder.Text)
+	}
+
+	if _, _, _, err := client.SendMessage(channelID, slack.MsgOptionText(text, false)); err != nil {
+		return err
+	}
+
+	// reminders created before time zones were stored repeat in the configured time zone
+	if reminder.TimeZone == "" {
+		config := models.Config{}
+		if err := store.Read(db.ConfigKey, &config); err != nil {
+			return err
+		}
+
+		reminder.TimeZone = config.Location(models.SettingTimeZone).String()
+	}
+
+	if !reminder.Advance(time.Now()) {
+		reminders.Delete(reminderID)
+	}
+
+	return store.Write(db.RemindersKey, reminders)
+}
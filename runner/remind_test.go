@@ -7,14 +7,15 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/nlopes/slack"
 	"github.com/quintilesims/iqvbot/db"
+	"github.com/quintilesims/iqvbot/mock"
 	"github.com/quintilesims/iqvbot/models"
-	"github.com/zpatrick/slackbot/mock_slack"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestGetHiringPipelineTimers(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
-	mockSlackClient := mock_slack.NewMockSlackClient(ctrl)
+	mockSlackClient := mock.NewMockSlackClient(ctrl)
 
 	candidates := models.Candidates{
 		{
@@ -45,6 +46,10 @@ func TestGetHiringPipelineTimers(t *testing.T) {
 		c <- true
 	}
 
+	mockSlackClient.EXPECT().
+		GetUserInfo("uid").
+		Return(&slack.User{ID: "uid", TZ: "Europe/London"}, nil)
+
 	mockSlackClient.EXPECT().
 		OpenIMChannel("uid").
 		Return(false, false, "cid", nil)
@@ -71,10 +76,49 @@ func TestGetHiringPipelineTimers(t *testing.T) {
 	}
 }
 
+func TestNextRemindTime(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		Now      time.Time
+		Expected time.Time
+	}{
+		"later today": {
+			Now:      time.Date(2026, 10, 19, 8, 0, 0, 0, loc),
+			Expected: time.Date(2026, 10, 19, 9, 30, 0, 0, loc),
+		},
+		"tomorrow": {
+			Now:      time.Date(2026, 10, 19, 10, 0, 0, 0, loc),
+			Expected: time.Date(2026, 10, 20, 9, 30, 0, 0, loc),
+		},
+		// clocks go back an hour at 2am on 2026-11-01
+		"after daylight saving time ends": {
+			Now:      time.Date(2026, 10, 31, 10, 0, 0, 0, loc),
+			Expected: time.Date(2026, 11, 1, 17, 30, 0, 0, time.UTC),
+		},
+		// clocks go forward an hour at 2am on 2027-03-14
+		"after daylight saving time starts": {
+			Now:      time.Date(2027, 3, 13, 10, 0, 0, 0, loc),
+			Expected: time.Date(2027, 3, 14, 16, 30, 0, 0, time.UTC),
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			result := nextRemindTime(c.Now, 9, 30)
+			assert.True(t, c.Expected.Equal(result), "expected %v, got %v", c.Expected, result)
+			assert.Equal(t, 9, result.Hour())
+		})
+	}
+}
+
 func TestGetInterviewTimers(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
-	mockSlackClient := mock_slack.NewMockSlackClient(ctrl)
+	mockSlackClient := mock.NewMockSlackClient(ctrl)
 
 	now := time.Now()
 	interviews := models.Interviews{
@@ -124,3 +168,69 @@ func TestGetInterviewTimers(t *testing.T) {
 		}
 	}
 }
+
+func TestGetUserReminderTimers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockSlackClient := mock.NewMockSlackClient(ctrl)
+
+	now := time.Now().UTC()
+	reminders := models.Reminders{
+		{ReminderID: "user", CreatorID: "uid", UserID: "uid", Text: "one", Time: now.Add(-time.Minute)},
+		{ReminderID: "channel", CreatorID: "uid", ChannelID: "cid", Text: "two", Time: now.Add(-time.Minute), Weekdays: []time.Weekday{now.Weekday()}},
+	}
+
+	store := newMemoryStore(t)
+	if err := store.Write(db.RemindersKey, reminders); err != nil {
+		t.Fatal(err)
+	}
+
+	config := models.Config{models.SettingTimeZone: "UTC"}
+	if err := store.Write(db.ConfigKey, config); err != nil {
+		t.Fatal(err)
+	}
+
+	c := make(chan bool)
+	record := func(channel string, options ...slack.MsgOption) {
+		c <- true
+	}
+
+	mockSlackClient.EXPECT().
+		OpenIMChannel("uid").
+		Return(false, false, "duid", nil)
+
+	for _, channelID := range []string{"duid", "cid"} {
+		mockSlackClient.EXPECT().
+			SendMessage(channelID, gomock.Any()).
+			Do(record).
+			Return("", "", "", nil)
+	}
+
+	if _, err := getUserReminderTimers(store, mockSlackClient); err != nil {
+		t.Fatal(err)
+	}
+
+	// past due reminders are sent immediately
+	for i := 0; i < 2; i++ {
+		select {
+		case <-c:
+		case <-time.After(time.Second):
+			t.Fatalf("Timeout on index %d", i)
+		}
+	}
+
+	// wait for the store to be updated
+	userReminderMutex.Lock()
+	defer userReminderMutex.Unlock()
+
+	result := models.Reminders{}
+	if err := store.Read(db.RemindersKey, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	// the one-off reminder is deleted and the recurring reminder moves to next week
+	if assert.Len(t, result, 1) {
+		assert.Equal(t, "channel", result[0].ReminderID)
+		assert.True(t, result[0].Time.After(now.AddDate(0, 0, 6)))
+	}
+}
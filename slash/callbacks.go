@@ -0,0 +1,125 @@
+package slash
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/quintilesims/iqvbot/db"
+	"github.com/quintilesims/iqvbot/models"
+)
+
+// callbacksMutex guards the read-modify-write of the callbacks in the store,
+// since slash commands, callbacks, and the cleanup runner all update them
+var callbacksMutex sync.Mutex
+
+// NamespaceCallbackID prefixes a command's callback id with the command's name,
+// so different commands can use the same callback ids
+func NamespaceCallbackID(commandName, callbackID string) string {
+	return commandName + ":" + callbackID
+}
+
+// RegisterCallbacks namespaces the callback ids of the attachments in msg with the command's name,
+// and records them in the store so interactions with those attachments are handled by the same command.
+// Registering a callback id again resets its expiry.
+func RegisterCallbacks(store db.Store, commandName string, msg *slack.Message) error {
+	if msg == nil {
+		return nil
+	}
+
+	callbacksMutex.Lock()
+	defer callbacksMutex.Unlock()
+
+	callbacks := models.Callbacks{}
+	if err := store.Read(db.CallbacksKey, &callbacks); err != nil {
+		return err
+	}
+
+	var changed bool
+	for i, a := range msg.Attachments {
+		if a.CallbackID == "" {
+			continue
+		}
+
+		callbackID := NamespaceCallbackID(commandName, a.CallbackID)
+		msg.Attachments[i].CallbackID = callbackID
+		callbacks[callbackID] = models.Callback{
+			Command: commandName,
+			Created: time.Now(),
+		}
+
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+
+	return store.Write(db.CallbacksKey, callbacks)
+}
+
+// LookupCallback returns the name of the command that handles the namespaced callback id,
+// and the callback id the command originally used.
+// A bool is also returned denoting if the callback exists and hasn't expired.
+func LookupCallback(store db.Store, callbackID string) (string, string, bool, error) {
+	callbacksMutex.Lock()
+	defer callbacksMutex.Unlock()
+
+	callbacks := models.Callbacks{}
+	if err := store.Read(db.CallbacksKey, &callbacks); err != nil {
+		return "", "", false, err
+	}
+
+	callback, ok := callbacks[callbackID]
+	if !ok {
+		return "", "", false, nil
+	}
+
+	expiry, err := readCallbackExpiry(store)
+	if err != nil {
+		return "", "", false, err
+	}
+
+	if callback.IsExpired(time.Now(), expiry) {
+		return "", "", false, nil
+	}
+
+	return callback.Command, strings.TrimPrefix(callbackID, callback.Command+":"), true, nil
+}
+
+// SweepCallbacks removes the callbacks that are older than the callback-expiry setting
+func SweepCallbacks(store db.Store) error {
+	callbacksMutex.Lock()
+	defer callbacksMutex.Unlock()
+
+	callbacks := models.Callbacks{}
+	if err := store.Read(db.CallbacksKey, &callbacks); err != nil {
+		return err
+	}
+
+	expiry, err := readCallbackExpiry(store)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for callbackID, callback := range callbacks {
+		if callback.IsExpired(now, expiry) {
+			log.Printf("[DEBUG] Removing expired callback %s", callbackID)
+			delete(callbacks, callbackID)
+		}
+	}
+
+	return store.Write(db.CallbacksKey, callbacks)
+}
+
+func readCallbackExpiry(store db.Store) (time.Duration, error) {
+	config := models.Config{}
+	if err := store.Read(db.ConfigKey, &config); err != nil {
+		return 0, err
+	}
+
+	return config.Duration(models.SettingCallbackExpiry), nil
+}
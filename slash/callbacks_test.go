@@ -0,0 +1,115 @@
+package slash
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/quintilesims/iqvbot/db"
+	"github.com/quintilesims/iqvbot/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterAndLookupCallbacks(t *testing.T) {
+	store := newMemoryStore(t)
+	msg := &slack.Message{
+		Msg: slack.Msg{
+			Attachments: []slack.Attachment{
+				{CallbackID: "list"},
+				{},
+				{CallbackID: "candidate:Alice"},
+			},
+		},
+	}
+
+	if err := RegisterCallbacks(store, "/candidate", msg); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "/candidate:list", msg.Attachments[0].CallbackID)
+	assert.Equal(t, "", msg.Attachments[1].CallbackID)
+	assert.Equal(t, "/candidate:candidate:Alice", msg.Attachments[2].CallbackID)
+
+	commandName, callbackID, ok, err := LookupCallback(store, "/candidate:candidate:Alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, ok)
+	assert.Equal(t, "/candidate", commandName)
+	assert.Equal(t, "candidate:Alice", callbackID)
+
+	// the same id can be used by a different command
+	if err := RegisterCallbacks(store, "/hire", &slack.Message{Msg: slack.Msg{Attachments: []slack.Attachment{{CallbackID: "list"}}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	commandName, _, _, err = LookupCallback(store, "/candidate:list")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "/candidate", commandName)
+
+	if _, _, ok, err := LookupCallback(store, "/candidate:unknown"); err != nil {
+		t.Fatal(err)
+	} else if ok {
+		t.Fatal("Unknown callback was found")
+	}
+}
+
+func TestLookupCallbackExpired(t *testing.T) {
+	store := newMemoryStore(t)
+	callbacks := models.Callbacks{
+		"/interview:old": {Command: "/interview", Created: time.Now().Add(-time.Hour * 2)},
+	}
+
+	if err := store.Write(db.CallbacksKey, callbacks); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, ok, err := LookupCallback(store, "/interview:old"); err != nil {
+		t.Fatal(err)
+	} else if !ok {
+		t.Fatal("Callback should not have expired yet")
+	}
+
+	if err := store.Write(db.ConfigKey, models.Config{models.SettingCallbackExpiry: "1h"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, ok, err := LookupCallback(store, "/interview:old"); err != nil {
+		t.Fatal(err)
+	} else if ok {
+		t.Fatal("Callback should have expired")
+	}
+}
+
+func TestSweepCallbacks(t *testing.T) {
+	now := time.Now().UTC()
+	callbacks := models.Callbacks{
+		"/interview:old": {Command: "/interview", Created: now.Add(-time.Hour * 24 * 7)},
+		"/interview:new": {Command: "/interview", Created: now},
+		"legacy":         {Command: "/interview"},
+	}
+
+	store := newMemoryStore(t)
+	if err := store.Write(db.CallbacksKey, callbacks); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SweepCallbacks(store); err != nil {
+		t.Fatal(err)
+	}
+
+	result := models.Callbacks{}
+	if err := store.Read(db.CallbacksKey, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := models.Callbacks{
+		"/interview:new": {Command: "/interview", Created: now},
+	}
+
+	assert.Equal(t, expected, result)
+}
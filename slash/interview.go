@@ -2,84 +2,256 @@ package slash
 
 import (
 	"fmt"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/nlopes/slack"
+	"github.com/quintilesims/iqvbot/auth"
 	"github.com/quintilesims/iqvbot/db"
 	"github.com/quintilesims/iqvbot/models"
+	"github.com/quintilesims/iqvbot/utils"
 )
 
 type InterviewCommand struct {
-	store db.Store
+	store     db.Store
+	views     ViewsClient
+	client    utils.SlackClient
+	serverURL string
 }
 
-func NewInterviewCommand(store db.Store) *InterviewCommand {
+// NewInterviewCommand returns the /interview command.
+// The serverURL is the public url of the bot's server, used in links to calendar feeds.
+func NewInterviewCommand(store db.Store, views ViewsClient, client utils.SlackClient, serverURL string) *InterviewCommand {
 	return &InterviewCommand{
-		store: store,
+		store:     store,
+		views:     views,
+		client:    client,
+		serverURL: serverURL,
 	}
 }
 
 func (cmd *InterviewCommand) Schema() *CommandSchema {
-	return &CommandSchema{
-		Name:     "/interview",
-		Help:     "View/Manage interviews with `/interview`, or add an interview with `/interview add`",
-		Run:      cmd.run,
-		Callback: cmd.callback,
+	root := &Command{
+		Name:  "/interview",
+		Usage: "view/manage interviews",
+		Action: func(c *Context) (*slack.Message, error) {
+			return cmd.list(c.Request.UserID)
+		},
+		Subcommands: []*Command{
+			{
+				Name:  "add",
+				Usage: "schedule an interview",
+				Args: []Arg{
+					{Name: "NAME", Usage: "The name of the candidate", Optional: true, Variadic: true},
+				},
+				Action: cmd.add,
+			},
+			{
+				Name:  "edit",
+				Usage: "edit an interview",
+				Args: []Arg{
+					{Name: "ID", Usage: "The id of the interview"},
+				},
+				Action: cmd.edit,
+			},
+			{
+				Name:  "calendar",
+				Usage: "get links to calendar feeds of your interviews and the team's interviews",
+				Flags: []Flag{
+					{Name: "reset", Usage: "Replace the link to your feed, e.g. if it was shared by mistake", Type: TypeBool},
+					{Name: "reset-team", Usage: "Replace the link to the team's feed (admins only)", Type: TypeBool},
+				},
+				Action: cmd.calendar,
+			},
+		},
 	}
+
+	schema := root.Schema()
+	schema.Callback = cmd.callback
+	schema.ViewSubmission = cmd.submit
+	return schema
 }
 
-func (cmd *InterviewCommand) run(req slack.SlashCommand) (*slack.Message, error) {
-	args := strings.Split(req.Text, " ")
-	switch {
-	case len(args) == 0 || args[0] == "":
-		return cmd.list()
-	case args[0] == "add":
-		candidate := strings.Join(args[1:], " ")
-		if candidate == "" {
-			return nil, NewSlackMessageError("Invalid usage: please specify the candidate's name using `/interview add NAME`")
-		}
+// add opens a modal to schedule a new interview.
+// The interview isn't saved until the modal is submitted.
+func (cmd *InterviewCommand) add(c *Context) (*slack.Message, error) {
+	req, candidate := c.Request, strings.Title(c.String("NAME"))
+	if err := cmd.authorize(req.UserID, auth.ActionInterviewAdd); err != nil {
+		return nil, err
+	}
 
-		return cmd.add(req, strings.Title(candidate))
-	default:
-		return nil, NewSlackMessageError("Invalid usage: please use `/interview help` for more information")
+	config, err := cmd.config()
+	if err != nil {
+		return nil, err
+	}
+
+	// new interviews are entered in the organiser's time zone
+	loc := cmd.location(config, req.UserID)
+	n := time.Now().In(loc)
+	interview := models.Interview{
+		Candidate:      candidate,
+		InterviewerIDs: []string{req.UserID},
+		Time:           time.Date(n.Year(), n.Month(), n.Day(), 9, 0, 0, 0, n.Location()),
+		Reminder:       config.Duration(models.SettingInterviewReminder),
+		Duration:       config.Duration(models.SettingInterviewDuration),
+	}
+
+	metadata := ViewMetadata{ResponseURL: req.ResponseURL}
+	if err := cmd.views.OpenView(req.TriggerID, interviewModal(interview, loc, metadata)); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// edit opens a modal to change an existing interview
+func (cmd *InterviewCommand) edit(c *Context) (*slack.Message, error) {
+	req := c.Request
+	if err := cmd.openEditModal(req.UserID, req.TriggerID, req.ResponseURL, c.String("ID"), ActionEdit); err != nil {
+		return nil, err
 	}
+
+	return nil, nil
 }
 
-func (cmd *InterviewCommand) add(req slack.SlashCommand, candidate string) (*slack.Message, error) {
+// openEditModal opens a modal to change an existing interview.
+// The action is ActionEdit to change every field, or ActionReschedule to only change the date and time.
+func (cmd *InterviewCommand) openEditModal(userID, triggerID, responseURL, interviewID, action string) error {
 	interviews := models.Interviews{}
 	if err := cmd.store.Read(db.InterviewsKey, &interviews); err != nil {
+		return err
+	}
+
+	interview, ok := interviews.Get(interviewID)
+	if !ok {
+		return NewSlackMessageErrorf("There isn't an interview with the id `%s`", interviewID)
+	}
+
+	if err := cmd.authorize(userID, auth.ActionInterviewEdit, interview.InterviewerIDs...); err != nil {
+		return err
+	}
+
+	config, err := cmd.config()
+	if err != nil {
+		return err
+	}
+
+	current := *interview
+	if current.Duration == 0 {
+		current.Duration = config.Duration(models.SettingInterviewDuration)
+	}
+
+	loc := cmd.location(config, userID)
+	metadata := ViewMetadata{ResponseURL: responseURL, ID: interview.InterviewID, Action: action}
+	return cmd.views.OpenView(triggerID, interviewModal(current, loc, metadata))
+}
+
+// calendar displays links to the user's calendar feed and, if the user is allowed to see it, the team's calendar feed.
+// Anyone with a link can read the feed, so the links are only shown to the user.
+func (cmd *InterviewCommand) calendar(c *Context) (*slack.Message, error) {
+	if cmd.serverURL == "" {
+		return nil, NewSlackMessageError("Calendar feeds aren't available: the bot's url isn't set")
+	}
+
+	userID := c.Request.UserID
+	if c.Bool("reset-team") {
+		if err := cmd.authorize(userID, auth.ActionCalendarTeamReset); err != nil {
+			return nil, err
+		}
+	}
+
+	showTeam := true
+	if err := auth.Authorize(cmd.store, userID, auth.ActionCalendarTeam); err != nil {
+		if _, ok := err.(*auth.PermissionDeniedError); !ok {
+			return nil, err
+		}
+
+		showTeam = false
+	}
+
+	feeds := models.CalendarFeeds{}
+	if err := cmd.store.Read(db.CalendarFeedsKey, &feeds); err != nil {
 		return nil, err
 	}
 
-	n := time.Now().In(PDT)
-	interview := &models.Interview{
-		InterviewID:    randomString(10),
-		Candidate:      candidate,
-		InterviewerIDs: []string{req.UserID},
-		Time:           time.Date(n.Year(), n.Month(), n.Day(), 9, 0, 0, 0, n.Location()),
-		Reminder:       time.Minute * 5,
+	userToken, err := feeds.Token(userID)
+	if c.Bool("reset") {
+		userToken, err = feeds.Reset(userID)
 	}
 
-	interviews = append(interviews, interview)
-	if err := cmd.store.Write(db.InterviewsKey, interviews); err != nil {
+	if err != nil {
 		return nil, err
 	}
 
-	return AddInterviewView(*interview), nil
+	var teamToken string
+	switch {
+	case c.Bool("reset-team"):
+		teamToken, err = feeds.Reset(models.TeamCalendarFeed)
+	case showTeam:
+		teamToken, err = feeds.Token(models.TeamCalendarFeed)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.store.Write(db.CalendarFeedsKey, feeds); err != nil {
+		return nil, err
+	}
+
+	text := "Subscribe to these links in your calendar app:\n"
+	text += fmt.Sprintf("Your interviews: %s\n", models.CalendarFeedURL(cmd.serverURL, userToken))
+	if teamToken != "" {
+		text += fmt.Sprintf("All interviews: %s\n", models.CalendarFeedURL(cmd.serverURL, teamToken))
+	}
+
+	text += "Anyone with a link can see its interviews: use `/interview calendar --reset` to replace your link"
+	if teamToken != "" {
+		text += ", or ask an admin to run `/interview calendar --reset-team` to replace the team's link"
+	}
+
+	msg := &slack.Message{
+		Msg: slack.Msg{
+			ResponseType: "ephemeral",
+			Text:         text,
+		},
+	}
+
+	return msg, nil
 }
 
-func (cmd *InterviewCommand) list() (*slack.Message, error) {
+// list displays the interviews, with fallback times in the time zone of the user that requested them
+func (cmd *InterviewCommand) list(userID string) (*slack.Message, error) {
 	interviews := models.Interviews{}
 	if err := cmd.store.Read(db.InterviewsKey, &interviews); err != nil {
 		return nil, err
 	}
 
-	return ListInterviewsView(interviews), nil
+	config, err := cmd.config()
+	if err != nil {
+		return nil, err
+	}
+
+	return ListInterviewsView(interviews, cmd.location(config, userID)), nil
 }
 
 func (cmd *InterviewCommand) callback(req slack.AttachmentActionCallback) (*slack.Message, error) {
+	switch name := req.Actions[0].Name; name {
+	case ActionDelete:
+		return cmd.delete(req)
+	case ActionEdit, ActionReschedule:
+		if err := cmd.openEditModal(req.User.ID, req.TriggerID, req.ResponseURL, req.CallbackID, name); err != nil {
+			return nil, err
+		}
+
+		// the list is left as it is until the modal is submitted
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("Unexpected callback action name '%s'", name)
+	}
+}
+
+func (cmd *InterviewCommand) delete(req slack.AttachmentActionCallback) (*slack.Message, error) {
 	interviews := models.Interviews{}
 	if err := cmd.store.Read(db.InterviewsKey, &interviews); err != nil {
 		return nil, err
@@ -91,96 +263,203 @@ func (cmd *InterviewCommand) callback(req slack.AttachmentActionCallback) (*slac
 		return nil, NewSlackMessageError("This interview no longer exists!")
 	}
 
-	switch actionName := req.Actions[0].Name; {
-	case actionName == ActionAddInterviewer:
-		interview.InterviewerIDs = append(interview.InterviewerIDs, "")
-	case actionName == ActionSelectDate:
-		updated, err := time.Parse(TimeValueFormat, req.Actions[0].SelectedOptions[0].Value)
-		if err != nil {
-			return nil, err
-		}
+	if err := cmd.authorize(req.User.ID, auth.ActionInterviewRemove, interview.InterviewerIDs...); err != nil {
+		return nil, err
+	}
 
-		// only update the date, not the time
-		interview.Time = time.Date(
-			updated.Year(),
-			updated.Month(),
-			updated.Day(),
-			interview.Time.Hour(),
-			interview.Time.Minute(),
-			interview.Time.Second(),
-			interview.Time.Nanosecond(),
-			interview.Time.Location())
-	case actionName == ActionSelectTime:
-		updated, err := time.Parse(TimeValueFormat, req.Actions[0].SelectedOptions[0].Value)
-		if err != nil {
+	interviews.Delete(interviewID)
+	if err := cmd.store.Write(db.InterviewsKey, interviews); err != nil {
+		return nil, err
+	}
+
+	config, err := cmd.config()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := RecordInterviewEvent(cmd.store, req.User.ID, interview, nil); err != nil {
+		return nil, err
+	}
+
+	loc := cmd.location(config, req.User.ID)
+	NotifyInterviewers(cmd.client, interview, nil, config.Duration(models.SettingInterviewDuration), loc)
+	return ListInterviewsView(interviews, loc), nil
+}
+
+// submit validates a submitted interview modal, and returns a func that schedules the new interview
+// or saves the changes to the existing one. Slack requires a response to the submission within 3 seconds,
+// so the interview is saved and the interviewers are notified after the modal has been closed.
+func (cmd *InterviewCommand) submit(req ViewSubmission) (func() (*slack.Message, error), error) {
+	metadata, err := ParseViewMetadata(req.View.PrivateMetadata)
+	if err != nil {
+		return nil, err
+	}
+
+	interviews := models.Interviews{}
+	if err := cmd.store.Read(db.InterviewsKey, &interviews); err != nil {
+		return nil, err
+	}
+
+	existing, ok := interviews.Get(metadata.ID)
+	switch {
+	case ok:
+		if err := cmd.authorize(req.User.ID, auth.ActionInterviewEdit, existing.InterviewerIDs...); err != nil {
 			return nil, err
 		}
-
-		// only update the time, not the date
-		interview.Time = time.Date(
-			interview.Time.Year(),
-			interview.Time.Month(),
-			interview.Time.Day(),
-			updated.Hour(),
-			updated.Minute(),
-			updated.Second(),
-			updated.Nanosecond(),
-			updated.Location())
-	case actionName == ActionSelectReminder:
-		d, err := time.ParseDuration(req.Actions[0].SelectedOptions[0].Value)
-		if err != nil {
+	case metadata.ID != "":
+		return nil, NewSlackMessageError("This interview no longer exists!")
+	default:
+		if err := cmd.authorize(req.User.ID, auth.ActionInterviewAdd); err != nil {
 			return nil, err
 		}
+	}
 
-		interview.Reminder = d
-	case strings.HasPrefix(actionName, ActionSelectInterviewer):
-		index, err := strconv.Atoi(actionName[len(actionName)-1:])
+	config, err := cmd.config()
+	if err != nil {
+		return nil, err
+	}
+
+	// the modal was filled in using the time zone of the user that opened it
+	loc := cmd.location(config, req.User.ID)
+	var interview *models.Interview
+	if ok && metadata.Action == ActionReschedule {
+		t, err := ParseRescheduleModal(req.View.State, loc)
 		if err != nil {
 			return nil, err
 		}
 
-		interview.InterviewerIDs[index] = req.Actions[0].SelectedOptions[0].Value
-	case actionName == ActionSchedule:
-		if err := cmd.store.Write(db.InterviewsKey, interviews); err != nil {
-			return nil, err
-		}
+		rescheduled := *existing
+		rescheduled.Time = t
+		interview = &rescheduled
+	} else if interview, err = ParseInterviewModal(req.View.State, loc); err != nil {
+		return nil, err
+	}
 
-		msg := slack.Msg{
-			Text: fmt.Sprintf("Interview for *%s* on *%s* at *%s* has been scheduled!",
-				interview.Candidate,
-				interview.Time.Format(DateDisplayFormat),
-				interview.Time.Format(TimeDisplayFormat)),
-		}
+	interview.Time = interview.Time.UTC()
+	if ok {
+		interview.InterviewID = existing.InterviewID
+		interview.Sequence = existing.Sequence + 1
+	}
 
-		return &slack.Message{Msg: msg}, nil
-	case actionName == ActionCancel || actionName == ActionDelete:
-		for i := 0; i < len(interviews); i++ {
-			if interviews[i].InterviewID == interviewID {
-				interviews = append(interviews[:i], interviews[i+1:]...)
-				i--
-			}
+	if !ParseOverride(req.View.State) {
+		if warnings := ConflictWarnings(interviews, *interview, existing, config, loc); len(warnings) > 0 {
+			view := WithConflictWarnings(interviewModal(*interview, loc, metadata), warnings)
+			return nil, &ViewUpdateError{View: view, Reason: "The interview has conflicts"}
 		}
+	}
 
-		if err := cmd.store.Write(db.InterviewsKey, interviews); err != nil {
-			return nil, err
-		}
+	return func() (*slack.Message, error) {
+		return cmd.save(req.User.ID, interview, config, loc)
+	}, nil
+}
 
-		if actionName == ActionDelete {
-			return ListInterviewsView(interviews), nil
-		}
+// save schedules the interview if it doesn't have an id, or replaces the existing interview with the same id.
+// The interviews are read again, since they may have changed while the modal was being submitted.
+func (cmd *InterviewCommand) save(userID string, interview *models.Interview, config models.Config, loc *time.Location) (*slack.Message, error) {
+	interviews := models.Interviews{}
+	if err := cmd.store.Read(db.InterviewsKey, &interviews); err != nil {
+		return nil, err
+	}
 
-		msg := slack.Msg{
-			Text: fmt.Sprintf("Interview for *%s* has been cancelled", interview.Candidate),
+	verb := "updated"
+	var previous *models.Interview
+	if interview.InterviewID != "" {
+		existing, ok := interviews.Get(interview.InterviewID)
+		if !ok {
+			return nil, NewSlackMessageError("This interview no longer exists!")
 		}
 
-		return &slack.Message{Msg: msg}, nil
-	default:
-		return nil, fmt.Errorf("Unexpected callback action name '%s'", actionName)
+		saved := *existing
+		previous = &saved
+		*existing = *interview
+	} else {
+		verb = "scheduled"
+		interview.InterviewID = models.NewInterviewID()
+		interviews = append(interviews, interview)
 	}
 
 	if err := cmd.store.Write(db.InterviewsKey, interviews); err != nil {
 		return nil, err
 	}
 
-	return AddInterviewView(*interview), nil
+	if err := RecordInterviewEvent(cmd.store, userID, previous, interview); err != nil {
+		return nil, err
+	}
+
+	NotifyInterviewers(cmd.client, previous, interview, config.Duration(models.SettingInterviewDuration), loc)
+	msg := slack.Msg{
+		ResponseType: "in_channel",
+		Text: fmt.Sprintf("Interview for *%s* on *%s* at *%s* has been %s!",
+			interview.Candidate,
+			FormatSlackDate(interview.Time, SlackDateToken, DateDisplayFormat, loc),
+			FormatSlackDate(interview.Time, SlackTimeToken, TimeDisplayFormat, loc),
+			verb),
+	}
+
+	return &slack.Message{Msg: msg}, nil
+}
+
+// interviewModal renders the modal that the metadata's action opened
+func interviewModal(interview models.Interview, loc *time.Location, metadata ViewMetadata) *ModalView {
+	switch {
+	case metadata.Action == ActionReschedule:
+		return RescheduleModal(interview, loc, metadata)
+	case metadata.ID != "":
+		return InterviewModal(interview, loc, "Edit interview", metadata)
+	default:
+		return InterviewModal(interview, loc, "Schedule an interview", metadata)
+	}
+}
+
+// ConflictWarnings describes why the interview shouldn't be saved without the user's confirmation:
+// it is in the past, or one of its interviewers has another interview at the same time.
+// The existing interview is nil if the interview is new.
+func ConflictWarnings(interviews models.Interviews, interview models.Interview, existing *models.Interview, config models.Config, loc *time.Location) []string {
+	warnings := []string{}
+
+	// interviews that already happened can be edited without moving them
+	if interview.Time.Before(time.Now()) && (existing == nil || !existing.Time.Equal(interview.Time)) {
+		warnings = append(warnings, "• This interview is in the past")
+	}
+
+	defaultDuration := config.Duration(models.SettingInterviewDuration)
+	for _, conflict := range interviews.Conflicts(interview, defaultDuration) {
+		for _, interviewerID := range interview.SharedInterviewers(*conflict) {
+			warnings = append(warnings, fmt.Sprintf("• <@%s> has an interview with *%s* from %s to %s",
+				interviewerID,
+				conflict.Candidate,
+				FormatSlackDate(conflict.Time, SlackTimeToken, TimeDisplayFormat, loc),
+				FormatSlackDate(conflict.End(defaultDuration), SlackTimeToken, TimeDisplayFormat, loc)))
+		}
+	}
+
+	return warnings
+}
+
+// config reads the bot's runtime settings from the store
+func (cmd *InterviewCommand) config() (models.Config, error) {
+	config := models.Config{}
+	if err := cmd.store.Read(db.ConfigKey, &config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// location returns the time zone from the user's slack profile, or the time-zone setting if it is unknown
+func (cmd *InterviewCommand) location(config models.Config, userID string) *time.Location {
+	return utils.UserLocation(cmd.client, userID, config.Location(models.SettingTimeZone))
+}
+
+// authorize converts permission errors from auth.Authorize into messages slack can display
+func (cmd *InterviewCommand) authorize(userID, action string, ownerIDs ...string) error {
+	if err := auth.Authorize(cmd.store, userID, action, ownerIDs...); err != nil {
+		if _, ok := err.(*auth.PermissionDeniedError); ok {
+			return NewSlackMessageError(err.Error())
+		}
+
+		return err
+	}
+
+	return nil
 }
@@ -0,0 +1,95 @@
+package slash
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/quintilesims/iqvbot/db"
+	"github.com/quintilesims/iqvbot/models"
+	"github.com/zpatrick/slackbot"
+)
+
+// RecordInterviewEvent adds an event to the timeline of each candidate affected by a change to an interview.
+// The cleanup runner removes interviews once they expire, so candidate timelines can't be built from the interviews themselves.
+// The previous interview is nil if the interview was just scheduled, and the interview is nil if it was cancelled.
+// Interviews for people that aren't candidates are ignored.
+func RecordInterviewEvent(store db.Store, userID string, previous, interview *models.Interview) error {
+	candidates := models.Candidates{}
+	if err := store.Read(db.CandidatesKey, &candidates); err != nil {
+		return err
+	}
+
+	var changed bool
+	record := func(candidateName, format string, tokens ...interface{}) {
+		candidate, ok := candidates.Get(candidateName)
+		if !ok {
+			return
+		}
+
+		candidate.Events = append(candidate.Events, &models.CandidateEvent{
+			Type:   models.CandidateEventInterview,
+			Time:   time.Now().UTC(),
+			UserID: userID,
+			Text:   fmt.Sprintf(format, tokens...),
+		})
+
+		changed = true
+	}
+
+	switch {
+	case interview == nil:
+		record(previous.Candidate, "Cancelled the interview on %s", describeInterview(previous))
+	case previous == nil:
+		record(interview.Candidate, "Scheduled an interview on %s", describeInterview(interview))
+	case !strings.EqualFold(previous.Candidate, interview.Candidate):
+		record(previous.Candidate, "Cancelled the interview on %s", describeInterview(previous))
+		record(interview.Candidate, "Scheduled an interview on %s", describeInterview(interview))
+	case !previous.Time.Equal(interview.Time):
+		record(interview.Candidate, "Rescheduled the interview on %s to %s",
+			formatInterviewEventTime(previous.Time),
+			describeInterview(interview))
+	case !sameInterviewers(previous, interview):
+		record(interview.Candidate, "Changed the interviewers of the interview on %s", describeInterview(interview))
+	}
+
+	if !changed {
+		return nil
+	}
+
+	return store.Write(db.CandidatesKey, candidates)
+}
+
+// describeInterview describes the time and interviewers of an interview
+func describeInterview(interview *models.Interview) string {
+	interviewers := []string{}
+	for _, interviewerID := range interview.InterviewerIDs {
+		if interviewerID != "" {
+			interviewers = append(interviewers, slackbot.EscapeUserID(interviewerID))
+		}
+	}
+
+	return fmt.Sprintf("%s with %s",
+		formatInterviewEventTime(interview.Time),
+		strings.Join(interviewers, ", "))
+}
+
+// formatInterviewEventTime displays the date and time of an interview in each user's own time zone,
+// falling back to UTC in clients that can't
+func formatInterviewEventTime(t time.Time) string {
+	return FormatSlackDate(t, SlackDateToken+" at "+SlackTimeToken, DateDisplayFormat+" at "+TimeDisplayFormat+" MST", time.UTC)
+}
+
+func sameInterviewers(previous, interview *models.Interview) bool {
+	if len(previous.InterviewerIDs) != len(interview.InterviewerIDs) {
+		return false
+	}
+
+	for _, interviewerID := range interview.InterviewerIDs {
+		if !previous.HasInterviewer(interviewerID) {
+			return false
+		}
+	}
+
+	return true
+}
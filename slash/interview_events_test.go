@@ -0,0 +1,65 @@
+package slash
+
+import (
+	"testing"
+	"time"
+
+	"github.com/quintilesims/iqvbot/db"
+	"github.com/quintilesims/iqvbot/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordInterviewEvent(t *testing.T) {
+	store := newMemoryStore(t)
+	candidates := models.Candidates{
+		{Name: "John Doe"},
+		{Name: "Jane Doe"},
+	}
+
+	if err := store.Write(db.CandidatesKey, candidates); err != nil {
+		t.Fatal(err)
+	}
+
+	scheduled := &models.Interview{Candidate: "john doe", InterviewerIDs: []string{"uid1"}, Time: time.Now()}
+	rescheduled := &models.Interview{Candidate: "john doe", InterviewerIDs: []string{"uid1"}, Time: time.Now().Add(time.Hour)}
+	reassigned := &models.Interview{Candidate: "john doe", InterviewerIDs: []string{"uid2"}, Time: rescheduled.Time}
+	reminded := &models.Interview{Candidate: "john doe", InterviewerIDs: []string{"uid2"}, Time: rescheduled.Time, Reminder: time.Hour}
+	moved := &models.Interview{Candidate: "Jane Doe", InterviewerIDs: []string{"uid2"}, Time: rescheduled.Time}
+	stranger := &models.Interview{Candidate: "Someone Else", InterviewerIDs: []string{"uid1"}, Time: time.Now()}
+
+	changes := [][2]*models.Interview{
+		{nil, scheduled},
+		{scheduled, rescheduled},
+		{rescheduled, reassigned},
+		{reassigned, reminded},
+		{reminded, moved},
+		{moved, nil},
+		{nil, stranger},
+	}
+
+	for _, change := range changes {
+		if err := RecordInterviewEvent(store, "uid", change[0], change[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := store.Read(db.CandidatesKey, &candidates); err != nil {
+		t.Fatal(err)
+	}
+
+	texts := func(events models.CandidateEvents) []string {
+		result := []string{}
+		for _, event := range events {
+			assert.Equal(t, models.CandidateEventInterview, event.Type)
+			assert.Equal(t, "uid", event.UserID)
+			result = append(result, event.Text[:10])
+		}
+
+		return result
+	}
+
+	// changes to the reminder aren't on the timeline
+	assert.Equal(t, []string{"Scheduled ", "Reschedule", "Changed th", "Cancelled "}, texts(candidates[0].Events))
+	assert.Equal(t, []string{"Scheduled ", "Cancelled "}, texts(candidates[1].Events))
+	assert.Contains(t, candidates[0].Events[2].Text, "<@uid2>")
+}
@@ -0,0 +1,81 @@
+package slash
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/quintilesims/iqvbot/models"
+	"github.com/quintilesims/iqvbot/utils"
+)
+
+// NotifyInterviewers sends a direct message with a calendar invite to each interviewer affected by a change to an interview.
+// The previous interview is nil if the interview was just scheduled, and the interview is nil if it was cancelled.
+// If the time didn't change, only the interviewers that were added or removed are notified.
+// Times are displayed in each interviewer's time zone by slack, falling back to loc.
+// Failures are logged, since the change has already been saved.
+func NotifyInterviewers(client utils.SlackClient, previous, interview *models.Interview, defaultDuration time.Duration, loc *time.Location) {
+	if previous != nil {
+		for _, interviewerID := range previous.InterviewerIDs {
+			if interview != nil && interview.HasInterviewer(interviewerID) {
+				continue
+			}
+
+			text := fmt.Sprintf("Hello! Your interview with *%s* on *%s* at *%s* has been cancelled",
+				previous.Candidate,
+				FormatSlackDate(previous.Time, SlackDateToken, DateDisplayFormat, loc),
+				FormatSlackDate(previous.Time, SlackTimeToken, TimeDisplayFormat, loc))
+
+			calendar := models.NewInterviewCalendar("Interviews", models.CalendarMethodCancel, models.Interviews{previous}, defaultDuration, time.Now())
+			sendInterviewInvite(client, interviewerID, previous, text, calendar)
+		}
+	}
+
+	if interview == nil {
+		return
+	}
+
+	date := FormatSlackDate(interview.Time, SlackDateToken, DateDisplayFormat, loc)
+	clock := FormatSlackDate(interview.Time, SlackTimeToken, TimeDisplayFormat, loc)
+	rescheduled := previous != nil && (!previous.Time.Equal(interview.Time) || !previous.End(defaultDuration).Equal(interview.End(defaultDuration)))
+	calendar := models.NewInterviewCalendar("Interviews", models.CalendarMethodPublish, models.Interviews{interview}, defaultDuration, time.Now())
+
+	for _, interviewerID := range interview.InterviewerIDs {
+		var text string
+		switch {
+		case previous == nil:
+			text = fmt.Sprintf("Hello! You've been scheduled for an interview with *%s* on *%s* at *%s*", interview.Candidate, date, clock)
+		case !previous.HasInterviewer(interviewerID):
+			text = fmt.Sprintf("Hello! You've been added to the interview with *%s* on *%s* at *%s*", interview.Candidate, date, clock)
+		case rescheduled:
+			text = fmt.Sprintf("Hello! Your interview with *%s* has been rescheduled to *%s* at *%s*", interview.Candidate, date, clock)
+		default:
+			continue
+		}
+
+		sendInterviewInvite(client, interviewerID, interview, text, calendar)
+	}
+}
+
+// sendInterviewInvite sends text to the interviewer with the calendar attached as an .ics file
+func sendInterviewInvite(client utils.SlackClient, interviewerID string, interview *models.Interview, text string, calendar []byte) {
+	_, _, channelID, err := client.OpenIMChannel(interviewerID)
+	if err != nil {
+		log.Printf("[ERROR] Failed to notify %s about interview %s: %v", interviewerID, interview.InterviewID, err)
+		return
+	}
+
+	params := slack.FileUploadParameters{
+		Content:        string(calendar),
+		Filetype:       "text",
+		Filename:       fmt.Sprintf("interview-%s.ics", interview.InterviewID),
+		Title:          fmt.Sprintf("Interview with %s", interview.Candidate),
+		InitialComment: text,
+		Channels:       []string{channelID},
+	}
+
+	if _, err := client.UploadFile(params); err != nil {
+		log.Printf("[ERROR] Failed to notify %s about interview %s: %v", interviewerID, interview.InterviewID, err)
+	}
+}
@@ -0,0 +1,705 @@
+package slash
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/nlopes/slack"
+	"github.com/quintilesims/iqvbot/db"
+	"github.com/quintilesims/iqvbot/mock"
+	"github.com/quintilesims/iqvbot/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingViewsClient struct {
+	triggerIDs []string
+	views      []*ModalView
+}
+
+func (r *recordingViewsClient) OpenView(triggerID string, view *ModalView) error {
+	r.triggerIDs = append(r.triggerIDs, triggerID)
+	r.views = append(r.views, view)
+	return nil
+}
+
+func newInterviewTestCommand(t *testing.T, ctrl *gomock.Controller) (*InterviewCommand, *recordingViewsClient, *mock.MockSlackClient, *db.MemoryStore) {
+	store := newMemoryStore(t)
+	if err := store.Write(db.RolesKey, models.Roles{"admin": {models.RoleAdmin}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Write(db.ConfigKey, models.Config{models.SettingTimeZone: "UTC"}); err != nil {
+		t.Fatal(err)
+	}
+
+	views := &recordingViewsClient{}
+	mockSlackClient := mock.NewMockSlackClient(ctrl)
+	return NewInterviewCommand(store, views, mockSlackClient, "https://iqvbot.test"), views, mockSlackClient, store
+}
+
+// submitInterview submits the modal, then saves the interview as the controller does once the modal is closed
+func submitInterview(cmd *InterviewCommand, req ViewSubmission) (*slack.Message, error) {
+	save, err := cmd.submit(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return save()
+}
+
+// expectTimeZone sets the time zone in the user's slack profile
+func expectTimeZone(mockSlackClient *mock.MockSlackClient, userID, tz string) {
+	mockSlackClient.EXPECT().
+		GetUserInfo(userID).
+		Return(&slack.User{ID: userID, TZ: tz}, nil).
+		AnyTimes()
+}
+
+// inviteMatcher matches calendar invites that are uploaded to a channel
+type inviteMatcher struct {
+	channelID string
+	method    string
+}
+
+func (m inviteMatcher) Matches(x interface{}) bool {
+	params, ok := x.(slack.FileUploadParameters)
+	if !ok || len(params.Channels) != 1 || params.Channels[0] != m.channelID {
+		return false
+	}
+
+	return strings.Contains(params.Content, fmt.Sprintf("METHOD:%s\r\n", m.method))
+}
+
+func (m inviteMatcher) String() string {
+	return fmt.Sprintf("is a %s invite uploaded to %s", m.method, m.channelID)
+}
+
+// expectInvite expects the interviewer to be sent a calendar invite with the specified method
+func expectInvite(mockSlackClient *mock.MockSlackClient, interviewerID, method string) {
+	mockSlackClient.EXPECT().
+		OpenIMChannel(interviewerID).
+		Return(false, false, "cid_"+interviewerID, nil)
+
+	mockSlackClient.EXPECT().
+		UploadFile(inviteMatcher{channelID: "cid_" + interviewerID, method: method}).
+		Return(&slack.File{}, nil)
+}
+
+func newInterviewModalSubmission(userID string, metadata ViewMetadata, candidate, date, clock string, interviewerIDs ...string) ViewSubmission {
+	var req ViewSubmission
+	req.Type = InteractionTypeViewSubmission
+	req.User.ID = userID
+	req.View.CallbackID = "/interview"
+	req.View.PrivateMetadata = metadata.Encode()
+	req.View.State.Values = map[string]map[string]ViewStateValue{
+		BlockInterviewCandidate:    {BlockInterviewCandidate: {Value: candidate}},
+		BlockInterviewDate:         {BlockInterviewDate: {SelectedDate: date}},
+		BlockInterviewTime:         {BlockInterviewTime: {SelectedTime: clock}},
+		BlockInterviewInterviewers: {BlockInterviewInterviewers: {SelectedUsers: interviewerIDs}},
+		BlockInterviewReminder: {BlockInterviewReminder: {
+			SelectedOption: slack.NewOptionBlockObject("15m0s", nil),
+		}},
+		BlockInterviewDuration: {BlockInterviewDuration: {
+			SelectedOption: slack.NewOptionBlockObject("1h0m0s", nil),
+		}},
+	}
+
+	return req
+}
+
+func TestInterviewCommandAddOpensModal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cmd, views, mockSlackClient, store := newInterviewTestCommand(t, ctrl)
+	expectTimeZone(mockSlackClient, "admin", "UTC")
+	req := slack.SlashCommand{
+		UserID:      "admin",
+		Text:        "add john doe",
+		TriggerID:   "trigger_id",
+		ResponseURL: "https://example.com/response",
+	}
+
+	msg, err := cmd.Schema().Run(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Nil(t, msg)
+	assert.Equal(t, []string{"trigger_id"}, views.triggerIDs)
+
+	view := views.views[0]
+	assert.Equal(t, "/interview", view.CallbackID)
+	assert.Len(t, view.Blocks.BlockSet, 6)
+
+	metadata, err := ParseViewMetadata(view.PrivateMetadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, ViewMetadata{ResponseURL: "https://example.com/response"}, metadata)
+
+	candidate := view.Blocks.BlockSet[0].(*InputBlock).Element.(*PlainTextInputBlockElement)
+	assert.Equal(t, "John Doe", candidate.InitialValue)
+
+	// interviews aren't saved until the modal is submitted
+	interviews := models.Interviews{}
+	if err := store.Read(db.InterviewsKey, &interviews); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, interviews, 0)
+}
+
+func TestInterviewCommandAddUnauthorized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cmd, views, _, _ := newInterviewTestCommand(t, ctrl)
+	if _, err := cmd.Schema().Run(slack.SlashCommand{UserID: "nobody", Text: "add john doe"}); err == nil {
+		t.Fatal("Error was nil!")
+	}
+
+	assert.Len(t, views.views, 0)
+}
+
+func TestInterviewCommandCalendar(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cmd, _, _, store := newInterviewTestCommand(t, ctrl)
+	msg, err := cmd.Schema().Run(slack.SlashCommand{UserID: "admin", Text: "calendar"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	feeds := models.CalendarFeeds{}
+	if err := store.Read(db.CalendarFeedsKey, &feeds); err != nil {
+		t.Fatal(err)
+	}
+
+	if !assert.Len(t, feeds, 2) {
+		return
+	}
+
+	userToken, _ := feeds.Token("admin")
+	teamToken, _ := feeds.Token(models.TeamCalendarFeed)
+	assert.Equal(t, "ephemeral", msg.ResponseType)
+	assert.Contains(t, msg.Text, "https://iqvbot.test/calendar/"+userToken+".ics")
+	assert.Contains(t, msg.Text, "https://iqvbot.test/calendar/"+teamToken+".ics")
+
+	// the same links are displayed until the user resets theirs
+	again, err := cmd.Schema().Run(slack.SlashCommand{UserID: "admin", Text: "calendar"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, msg.Text, again.Text)
+
+	reset, err := cmd.Schema().Run(slack.SlashCommand{UserID: "admin", Text: "calendar --reset"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NotContains(t, reset.Text, userToken)
+	assert.Contains(t, reset.Text, teamToken)
+
+	resetTeam, err := cmd.Schema().Run(slack.SlashCommand{UserID: "admin", Text: "calendar --reset-team"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NotContains(t, resetTeam.Text, teamToken)
+	assert.Contains(t, resetTeam.Text, "https://iqvbot.test/calendar/")
+}
+
+func TestInterviewCommandCalendarWithoutRole(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cmd, _, _, store := newInterviewTestCommand(t, ctrl)
+	msg, err := cmd.Schema().Run(slack.SlashCommand{UserID: "uid1", Text: "calendar"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	feeds := models.CalendarFeeds{}
+	if err := store.Read(db.CalendarFeedsKey, &feeds); err != nil {
+		t.Fatal(err)
+	}
+
+	// the team's feed isn't created for users that can't see it
+	if assert.Len(t, feeds, 1) {
+		userToken, _ := feeds.Token("uid1")
+		assert.Contains(t, msg.Text, "https://iqvbot.test/calendar/"+userToken+".ics")
+		assert.NotContains(t, msg.Text, "All interviews")
+	}
+
+	if _, err := cmd.Schema().Run(slack.SlashCommand{UserID: "uid1", Text: "calendar --reset-team"}); err == nil {
+		t.Fatal("Error was nil!")
+	} else if _, ok := err.(*SlackMessageError); !ok {
+		t.Fatalf("Error was not SlackMessageError: %#v", err)
+	}
+}
+
+func TestInterviewCommandSubmit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cmd, _, mockSlackClient, store := newInterviewTestCommand(t, ctrl)
+	expectTimeZone(mockSlackClient, "admin", "UTC")
+	expectInvite(mockSlackClient, "uid1", models.CalendarMethodPublish)
+	expectInvite(mockSlackClient, "uid2", models.CalendarMethodPublish)
+	req := newInterviewModalSubmission("admin", ViewMetadata{}, "john doe", "2030-10-20", "14:30", "uid1", "uid2")
+
+	save, err := cmd.submit(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the interview isn't saved until the modal has been closed
+	interviews := models.Interviews{}
+	if err := store.Read(db.InterviewsKey, &interviews); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, interviews, 0)
+
+	msg, err := save()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Contains(t, msg.Text, "has been scheduled")
+
+	if err := store.Read(db.InterviewsKey, &interviews); err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.Len(t, interviews, 1) {
+		interview := interviews[0]
+		assert.NotEmpty(t, interview.InterviewID)
+		assert.Equal(t, "John Doe", interview.Candidate)
+		assert.Equal(t, []string{"uid1", "uid2"}, interview.InterviewerIDs)
+		assert.Equal(t, time.Date(2030, 10, 20, 14, 30, 0, 0, time.UTC), interview.Time.UTC())
+		assert.Equal(t, time.Minute*15, interview.Reminder)
+	}
+}
+
+func TestInterviewCommandSubmitEdit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cmd, views, mockSlackClient, store := newInterviewTestCommand(t, ctrl)
+	expectTimeZone(mockSlackClient, "uid1", "UTC")
+	interviews := models.Interviews{
+		{InterviewID: "iid", Candidate: "John Doe", InterviewerIDs: []string{"uid1"}, Time: time.Now(), Reminder: time.Minute},
+	}
+
+	if err := store.Write(db.InterviewsKey, interviews); err != nil {
+		t.Fatal(err)
+	}
+
+	// interviewers can edit their own interviews
+	if _, err := cmd.Schema().Run(slack.SlashCommand{UserID: "uid1", Text: "edit iid", TriggerID: "trigger_id"}); err != nil {
+		t.Fatal(err)
+	}
+
+	metadata, err := ParseViewMetadata(views.views[0].PrivateMetadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "iid", metadata.ID)
+
+	// the time changed, so every interviewer is notified
+	for _, interviewerID := range []string{"uid1", "uid3"} {
+		expectInvite(mockSlackClient, interviewerID, models.CalendarMethodPublish)
+	}
+
+	req := newInterviewModalSubmission("uid1", metadata, "Jane Doe", "2030-10-21", "09:00", "uid1", "uid3")
+	if _, err := submitInterview(cmd, req); err != nil {
+		t.Fatal(err)
+	}
+
+	result := models.Interviews{}
+	if err := store.Read(db.InterviewsKey, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.Len(t, result, 1) {
+		assert.Equal(t, "iid", result[0].InterviewID)
+		assert.Equal(t, "Jane Doe", result[0].Candidate)
+		assert.Equal(t, []string{"uid1", "uid3"}, result[0].InterviewerIDs)
+		assert.Equal(t, 1, result[0].Sequence)
+	}
+
+	// other users cannot
+	req = newInterviewModalSubmission("nobody", metadata, "Jane Doe", "2030-10-21", "09:00", "nobody")
+	if _, err := submitInterview(cmd, req); err == nil {
+		t.Fatal("Error was nil!")
+	}
+}
+
+func TestInterviewCommandSubmitEditNotifiesChangedInterviewers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cmd, _, mockSlackClient, store := newInterviewTestCommand(t, ctrl)
+	expectTimeZone(mockSlackClient, "uid1", "UTC")
+
+	interviews := models.Interviews{
+		{
+			InterviewID:    "iid",
+			Candidate:      "John Doe",
+			InterviewerIDs: []string{"uid1", "uid3"},
+			Time:           time.Date(2030, 10, 20, 14, 30, 0, 0, time.UTC),
+			Reminder:       time.Minute * 15,
+		},
+	}
+
+	if err := store.Write(db.InterviewsKey, interviews); err != nil {
+		t.Fatal(err)
+	}
+
+	// the time didn't change, so only uid2 and uid3 are notified
+	expectInvite(mockSlackClient, "uid2", models.CalendarMethodPublish)
+	expectInvite(mockSlackClient, "uid3", models.CalendarMethodCancel)
+
+	metadata := ViewMetadata{ID: "iid", Action: ActionEdit}
+	req := newInterviewModalSubmission("uid1", metadata, "John Doe", "2030-10-20", "14:30", "uid1", "uid2")
+	if _, err := submitInterview(cmd, req); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInterviewCommandCallbackOpensModals(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cmd, views, mockSlackClient, store := newInterviewTestCommand(t, ctrl)
+	expectTimeZone(mockSlackClient, "uid1", "UTC")
+	interviews := models.Interviews{
+		{InterviewID: "iid", Candidate: "John Doe", InterviewerIDs: []string{"uid1"}, Time: time.Now(), Reminder: time.Minute},
+	}
+
+	if err := store.Write(db.InterviewsKey, interviews); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, action := range []string{ActionEdit, ActionReschedule} {
+		var req slack.AttachmentActionCallback
+		req.CallbackID = "iid"
+		req.TriggerID = "trigger_id"
+		req.ResponseURL = "https://example.com/response"
+		req.User.ID = "uid1"
+		req.Actions = []slack.AttachmentAction{{Name: action}}
+
+		msg, err := cmd.callback(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Nil(t, msg)
+	}
+
+	if assert.Len(t, views.views, 2) {
+		assert.Len(t, views.views[0].Blocks.BlockSet, 6)
+		assert.Len(t, views.views[1].Blocks.BlockSet, 3)
+	}
+
+	metadata, err := ParseViewMetadata(views.views[1].PrivateMetadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := ViewMetadata{ResponseURL: "https://example.com/response", ID: "iid", Action: ActionReschedule}
+	assert.Equal(t, expected, metadata)
+
+	// other users cannot open them
+	var req slack.AttachmentActionCallback
+	req.CallbackID = "iid"
+	req.User.ID = "nobody"
+	req.Actions = []slack.AttachmentAction{{Name: ActionEdit}}
+	if _, err := cmd.callback(req); err == nil {
+		t.Fatal("Error was nil!")
+	}
+}
+
+func TestInterviewCommandCallbackDelete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cmd, _, mockSlackClient, store := newInterviewTestCommand(t, ctrl)
+	expectTimeZone(mockSlackClient, "uid1", "UTC")
+
+	interviews := models.Interviews{
+		{InterviewID: "iid", Candidate: "John Doe", InterviewerIDs: []string{"uid1", "uid2"}, Time: time.Now().Add(time.Hour)},
+	}
+
+	if err := store.Write(db.InterviewsKey, interviews); err != nil {
+		t.Fatal(err)
+	}
+
+	// the interviewers' calendars are updated
+	expectInvite(mockSlackClient, "uid1", models.CalendarMethodCancel)
+	expectInvite(mockSlackClient, "uid2", models.CalendarMethodCancel)
+
+	var req slack.AttachmentActionCallback
+	req.CallbackID = "iid"
+	req.User.ID = "uid1"
+	req.Actions = []slack.AttachmentAction{{Name: ActionDelete}}
+	if _, err := cmd.callback(req); err != nil {
+		t.Fatal(err)
+	}
+
+	result := models.Interviews{}
+	if err := store.Read(db.InterviewsKey, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, result, 0)
+}
+
+func TestInterviewCommandSubmitReschedule(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cmd, _, mockSlackClient, store := newInterviewTestCommand(t, ctrl)
+	expectTimeZone(mockSlackClient, "uid1", "UTC")
+
+	interviews := models.Interviews{
+		{
+			InterviewID:    "iid",
+			Candidate:      "John Doe",
+			InterviewerIDs: []string{"uid1", "uid2"},
+			Time:           time.Date(2030, 10, 20, 14, 30, 0, 0, time.UTC),
+			Reminder:       time.Minute * 15,
+		},
+	}
+
+	if err := store.Write(db.InterviewsKey, interviews); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, interviewerID := range []string{"uid1", "uid2"} {
+		expectInvite(mockSlackClient, interviewerID, models.CalendarMethodPublish)
+	}
+
+	// the reschedule modal only has date and time inputs
+	var req ViewSubmission
+	req.Type = InteractionTypeViewSubmission
+	req.User.ID = "uid1"
+	req.View.CallbackID = "/interview"
+	req.View.PrivateMetadata = ViewMetadata{ID: "iid", Action: ActionReschedule}.Encode()
+	req.View.State.Values = map[string]map[string]ViewStateValue{
+		BlockInterviewDate: {BlockInterviewDate: {SelectedDate: "2030-10-22"}},
+		BlockInterviewTime: {BlockInterviewTime: {SelectedTime: "10:00"}},
+	}
+
+	msg, err := submitInterview(cmd, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Contains(t, msg.Text, "has been updated")
+
+	result := models.Interviews{}
+	if err := store.Read(db.InterviewsKey, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.Len(t, result, 1) {
+		interview := result[0]
+		assert.Equal(t, "John Doe", interview.Candidate)
+		assert.Equal(t, []string{"uid1", "uid2"}, interview.InterviewerIDs)
+		assert.Equal(t, time.Date(2030, 10, 22, 10, 0, 0, 0, time.UTC), interview.Time.UTC())
+		assert.Equal(t, time.Minute*15, interview.Reminder)
+	}
+}
+
+func TestInterviewCommandSubmitInOrganiserTimeZone(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cmd, _, mockSlackClient, store := newInterviewTestCommand(t, ctrl)
+	expectTimeZone(mockSlackClient, "admin", "America/New_York")
+
+	expectInvite(mockSlackClient, "uid1", models.CalendarMethodPublish)
+	expectInvite(mockSlackClient, "uid1", models.CalendarMethodPublish)
+
+	// clocks go back an hour at 2am on 2030-11-03
+	for _, date := range []string{"2030-11-01", "2030-11-04"} {
+		req := newInterviewModalSubmission("admin", ViewMetadata{}, "john doe", date, "09:00", "uid1")
+		if _, err := submitInterview(cmd, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	interviews := models.Interviews{}
+	if err := store.Read(db.InterviewsKey, &interviews); err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.Len(t, interviews, 2) {
+		assert.Equal(t, time.Date(2030, 11, 1, 13, 0, 0, 0, time.UTC), interviews[0].Time)
+		assert.Equal(t, time.Date(2030, 11, 4, 14, 0, 0, 0, time.UTC), interviews[1].Time)
+	}
+}
+
+func TestInterviewCommandTimeZoneFallback(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cmd, views, mockSlackClient, store := newInterviewTestCommand(t, ctrl)
+	if err := store.Write(db.ConfigKey, models.Config{models.SettingTimeZone: "Europe/London"}); err != nil {
+		t.Fatal(err)
+	}
+
+	mockSlackClient.EXPECT().
+		GetUserInfo("admin").
+		Return(nil, fmt.Errorf("some error"))
+
+	if _, err := cmd.Schema().Run(slack.SlashCommand{UserID: "admin", Text: "add john doe"}); err != nil {
+		t.Fatal(err)
+	}
+
+	hint := views.views[0].Blocks.BlockSet[2].(*InputBlock).Hint
+	assert.Equal(t, "Time is in Europe/London", hint.Text)
+}
+
+func TestListInterviewsViewUsesSlackDates(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	interviews := models.Interviews{
+		{InterviewID: "iid", Candidate: "John Doe", Time: time.Date(2026, 11, 2, 17, 0, 0, 0, time.UTC)},
+	}
+
+	msg := ListInterviewsView(interviews, loc)
+	fields := msg.Attachments[0].Fields
+	assert.Equal(t, "<!date^1793638800^{date_long}|Monday, November 2>", fields[0].Value)
+	assert.Equal(t, "<!date^1793638800^{time}|9:00 AM>", fields[1].Value)
+}
+
+func TestInterviewCommandSubmitConflicts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cmd, _, mockSlackClient, store := newInterviewTestCommand(t, ctrl)
+	expectTimeZone(mockSlackClient, "admin", "UTC")
+
+	interviews := models.Interviews{
+		{
+			InterviewID:    "iid",
+			Candidate:      "Jane Doe",
+			InterviewerIDs: []string{"uid1"},
+			Time:           time.Date(2030, 10, 20, 14, 0, 0, 0, time.UTC),
+			Reminder:       time.Minute * 15,
+		},
+	}
+
+	if err := store.Write(db.InterviewsKey, interviews); err != nil {
+		t.Fatal(err)
+	}
+
+	// uid1 is still in the interview with Jane Doe at 14:30
+	req := newInterviewModalSubmission("admin", ViewMetadata{}, "john doe", "2030-10-20", "14:30", "uid1", "uid2")
+	_, err := cmd.submit(req)
+	uerr, ok := err.(*ViewUpdateError)
+	if !ok {
+		t.Fatalf("Error was not a ViewUpdateError: %#v", err)
+	}
+
+	blocks := uerr.View.Blocks.BlockSet
+	if assert.Len(t, blocks, 8) {
+		warning := blocks[0].(*slack.SectionBlock).Text.Text
+		assert.Contains(t, warning, "<@uid1> has an interview with *Jane Doe*")
+		assert.NotContains(t, warning, "uid2")
+		assert.Equal(t, BlockInterviewOverride, blocks[7].(*InputBlock).BlockID)
+	}
+
+	result := models.Interviews{}
+	if err := store.Read(db.InterviewsKey, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, result, 1)
+
+	// the interview is saved once the user overrides the conflicts
+	req.View.State.Values[BlockInterviewOverride] = map[string]ViewStateValue{
+		BlockInterviewOverride: {SelectedOptions: []*slack.OptionBlockObject{slack.NewOptionBlockObject(BlockInterviewOverride, nil)}},
+	}
+
+	expectInvite(mockSlackClient, "uid1", models.CalendarMethodPublish)
+	expectInvite(mockSlackClient, "uid2", models.CalendarMethodPublish)
+
+	if _, err := submitInterview(cmd, req); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Read(db.InterviewsKey, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, result, 2)
+}
+
+func TestInterviewCommandSubmitInPast(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cmd, _, mockSlackClient, _ := newInterviewTestCommand(t, ctrl)
+	expectTimeZone(mockSlackClient, "admin", "UTC")
+
+	req := newInterviewModalSubmission("admin", ViewMetadata{}, "john doe", "2020-10-20", "14:30", "uid1")
+	_, err := cmd.submit(req)
+	uerr, ok := err.(*ViewUpdateError)
+	if !ok {
+		t.Fatalf("Error was not a ViewUpdateError: %#v", err)
+	}
+
+	warning := uerr.View.Blocks.BlockSet[0].(*slack.SectionBlock).Text.Text
+	assert.Contains(t, warning, "This interview is in the past")
+}
+
+func TestInterviewCommandSubmitValidation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cmd, _, mockSlackClient, store := newInterviewTestCommand(t, ctrl)
+	expectTimeZone(mockSlackClient, "admin", "UTC")
+	req := newInterviewModalSubmission("admin", ViewMetadata{}, " ", "", "14:30")
+
+	_, err := cmd.submit(req)
+	verr, ok := err.(*ViewValidationError)
+	if !ok {
+		t.Fatalf("Error was not a ViewValidationError: %#v", err)
+	}
+
+	assert.Len(t, verr.Errors, 3)
+	assert.Contains(t, verr.Errors, BlockInterviewCandidate)
+	assert.Contains(t, verr.Errors, BlockInterviewDate)
+	assert.Contains(t, verr.Errors, BlockInterviewInterviewers)
+
+	interviews := models.Interviews{}
+	if err := store.Read(db.InterviewsKey, &interviews); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, interviews, 0)
+}
+
+func TestInterviewModalKeepsUnusualReminder(t *testing.T) {
+	interview := models.Interview{Time: time.Now(), Reminder: time.Minute * 10}
+	view := InterviewModal(interview, time.UTC, "Test", ViewMetadata{})
+
+	element := view.Blocks.BlockSet[5].(*InputBlock).Element.(*slack.SelectBlockElement)
+	assert.Len(t, element.Options, 5)
+	assert.Equal(t, "10m0s", element.InitialOption.Value)
+}
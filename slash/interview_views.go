@@ -2,6 +2,7 @@ package slash
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/nlopes/slack"
@@ -9,214 +10,241 @@ import (
 )
 
 const (
-	ActionSelectDate        = "select_date"
-	ActionSelectTime        = "select_time"
-	ActionAddInterviewer    = "add_interviewer"
-	ActionSelectReminder    = "select_reminder"
-	ActionSelectInterviewer = "select_interviewer"
-	ActionSchedule          = "schedule"
-	ActionCancel            = "cancel"
-	ActionDelete            = "delete"
-	DateDisplayFormat       = "Monday, January 2"
-	TimeDisplayFormat       = "3:04 PM"
-	TimeValueFormat         = "2006-01-02 15:04:05 -0700 MST"
+	ActionDelete      = "delete"
+	ActionEdit        = "edit"
+	ActionReschedule  = "reschedule"
+	DateDisplayFormat = "Monday, January 2"
+	TimeDisplayFormat = "3:04 PM"
 )
 
-func AddInterviewView(interview models.Interview) *slack.Message {
-	view := slack.Msg{
-		ResponseType: "in_channel",
-		Text:         fmt.Sprintf("Schedule an interview for *%s*", interview.Candidate),
-		Attachments: []slack.Attachment{
-			selectDateAttachment(interview),
-			selectTimeAttachment(interview),
-			selectReminderAttachment(interview),
-			selectInterviewersAttachment(interview),
-			addInterviewerAttachment(interview),
-			scheduleAttachment(interview),
-		},
-	}
+// block and action ids of the inputs in the interview modal
+const (
+	BlockInterviewCandidate    = "interview_candidate"
+	BlockInterviewDate         = "interview_date"
+	BlockInterviewTime         = "interview_time"
+	BlockInterviewDuration     = "interview_duration"
+	BlockInterviewInterviewers = "interview_interviewers"
+	BlockInterviewReminder     = "interview_reminder"
+	BlockInterviewOverride     = "interview_override"
+)
 
-	return &slack.Message{Msg: view}
+// the formats used by the datepicker and timepicker elements
+const (
+	DatePickerFormat = "2006-01-02"
+	TimePickerFormat = "15:04"
+)
+
+// tokens used by FormatSlackDate, see https://api.slack.com/reference/surfaces/formatting#date-formatting
+const (
+	SlackDateToken = "{date_long}"
+	SlackTimeToken = "{time}"
+)
+
+// FormatSlackDate formats t with a slack date token, so each user sees it in their own time zone.
+// Clients that can't display the token show the fallback layout in the specified location.
+func FormatSlackDate(t time.Time, token, layout string, loc *time.Location) string {
+	return fmt.Sprintf("<!date^%d^%s|%s>", t.Unix(), token, t.In(loc).Format(layout))
 }
 
-func selectDateAttachment(interview models.Interview) slack.Attachment {
-	options := make([]slack.AttachmentActionOption, 14)
-	for i := 0; i < len(options); i++ {
-		t := time.Now().In(PDT).AddDate(0, 0, i)
-		options[i] = slack.AttachmentActionOption{
-			Text:  t.Format(DateDisplayFormat),
-			Value: t.Format(TimeValueFormat),
+// InterviewModal renders a modal to schedule or edit the interview.
+// Dates and times are shown in the specified location.
+// The metadata is returned to the command when the modal is submitted.
+func InterviewModal(interview models.Interview, loc *time.Location, title string, metadata ViewMetadata) *ModalView {
+	t := interview.Time.In(loc)
+
+	dateBlock, timeBlock := interviewTimeBlocks(t)
+
+	interviewerIDs := []string{}
+	for _, interviewerID := range interview.InterviewerIDs {
+		if interviewerID != "" {
+			interviewerIDs = append(interviewerIDs, interviewerID)
 		}
 	}
 
-	selectedOptions := []slack.AttachmentActionOption{
-		{
-			Text:  interview.Time.Format(DateDisplayFormat),
-			Value: interview.Time.Format(TimeValueFormat),
-		},
-	}
+	view := NewModalView("/interview", title,
+		NewInputBlock(BlockInterviewCandidate, "Candidate", NewPlainTextInputBlockElement(BlockInterviewCandidate, interview.Candidate)),
+		dateBlock,
+		timeBlock,
+		NewInputBlock(BlockInterviewDuration, "Duration", lengthSelectElement(interview.Duration)),
+		NewInputBlock(BlockInterviewInterviewers, "Interviewers", NewMultiUsersSelectBlockElement(BlockInterviewInterviewers, interviewerIDs...)),
+		NewInputBlock(BlockInterviewReminder, "Remind the interviewers", reminderSelectElement(interview.Reminder)),
+	)
 
-	return slack.Attachment{
-		Text:       "*What day is the interview?*",
-		Fallback:   "You are currently unable to specify the date of the interview. Please try again later.",
-		Color:      "good",
-		CallbackID: interview.InterviewID,
-		Actions: []slack.AttachmentAction{
-			{
-				Name:            ActionSelectDate,
-				Text:            "select a date",
-				Type:            "select",
-				Options:         options,
-				SelectedOptions: selectedOptions,
-			},
-		},
-	}
+	view.Submit = slack.NewTextBlockObject(slack.PlainTextType, "Schedule", false, false)
+	view.PrivateMetadata = metadata.Encode()
+	return view
 }
 
-func selectTimeAttachment(interview models.Interview) slack.Attachment {
-	minutes := []int{0, 15, 30, 45}
-	options := make([]slack.AttachmentActionOption, 24*len(minutes))
+// RescheduleModal renders a modal to change only the date and time of the interview.
+// Dates and times are shown in the specified location.
+// The metadata is returned to the command when the modal is submitted.
+func RescheduleModal(interview models.Interview, loc *time.Location, metadata ViewMetadata) *ModalView {
+	text := slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf("Interview for *%s*", interview.Candidate), false, false)
+	dateBlock, timeBlock := interviewTimeBlocks(interview.Time.In(loc))
+
+	view := NewModalView("/interview", "Reschedule interview", slack.NewSectionBlock(text, nil, nil), dateBlock, timeBlock)
+	view.Submit = slack.NewTextBlockObject(slack.PlainTextType, "Reschedule", false, false)
+	view.PrivateMetadata = metadata.Encode()
+	return view
+}
 
-	for hour := 0; hour < 24; hour++ {
-		for j, minute := range minutes {
-			t := time.Date(interview.Time.Year(), interview.Time.Month(),
-				interview.Time.Day(), hour, minute, 0, 0, PDT)
+// interviewTimeBlocks returns the date and time inputs of the interview modals, set to t
+func interviewTimeBlocks(t time.Time) (*InputBlock, *InputBlock) {
+	date := slack.NewDatePickerBlockElement(BlockInterviewDate)
+	date.InitialDate = t.Format(DatePickerFormat)
 
-			options[(hour*len(minutes))+j] = slack.AttachmentActionOption{
-				Text:  t.Format(TimeDisplayFormat),
-				Value: t.Format(TimeValueFormat),
-			}
-		}
+	timeBlock := NewInputBlock(BlockInterviewTime, "Time", NewTimePickerBlockElement(BlockInterviewTime, t.Format(TimePickerFormat)))
+	timeBlock.Hint = slack.NewTextBlockObject(slack.PlainTextType, fmt.Sprintf("Time is in %s", t.Location()), false, false)
+
+	return NewInputBlock(BlockInterviewDate, "Date", date), timeBlock
+}
+
+func reminderSelectElement(selected time.Duration) *slack.SelectBlockElement {
+	durations := []time.Duration{time.Minute * 5, time.Minute * 15, time.Minute * 30, time.Minute * 60}
+	return durationSelectElement(BlockInterviewReminder, durations, selected, "%d minutes before")
+}
+
+func lengthSelectElement(selected time.Duration) *slack.SelectBlockElement {
+	durations := []time.Duration{time.Minute * 30, time.Minute * 45, time.Minute * 60, time.Minute * 90, time.Minute * 120}
+	return durationSelectElement(BlockInterviewDuration, durations, selected, "%d minutes")
+}
+
+// durationSelectElement renders a select of durations, labelled by their number of minutes using format
+func durationSelectElement(actionID string, durations []time.Duration, selected time.Duration, format string) *slack.SelectBlockElement {
+	// keep the current value if it isn't one of the usual choices
+	var found bool
+	for _, d := range durations {
+		found = found || d == selected
 	}
 
-	selectedOptions := []slack.AttachmentActionOption{
-		{
-			Text:  interview.Time.Format(TimeDisplayFormat),
-			Value: interview.Time.Format(TimeValueFormat),
-		},
+	if !found {
+		durations = append([]time.Duration{selected}, durations...)
 	}
 
-	return slack.Attachment{
-		Text:       "*What time is the interview?* (time is in PDT)",
-		Fallback:   "You are currently unable to specify the time of the interview. Please try again later.",
-		Color:      "good",
-		CallbackID: interview.InterviewID,
-		Actions: []slack.AttachmentAction{
-			{
-				Name:            ActionSelectTime,
-				Text:            "select a time",
-				Type:            "select",
-				Options:         options,
-				SelectedOptions: selectedOptions,
-			},
-		},
+	options := make([]*slack.OptionBlockObject, len(durations))
+	var initial *slack.OptionBlockObject
+	for i, d := range durations {
+		text := slack.NewTextBlockObject(slack.PlainTextType, fmt.Sprintf(format, int(d.Minutes())), false, false)
+		options[i] = slack.NewOptionBlockObject(d.String(), text)
+		if d == selected {
+			initial = options[i]
+		}
 	}
+
+	element := slack.NewOptionsSelectBlockElement(slack.OptTypeStatic, nil, actionID, options...)
+	element.InitialOption = initial
+	return element
 }
 
-func selectReminderAttachment(interview models.Interview) slack.Attachment {
-	minutes := []int{5, 15, 30, 60}
-	options := make([]slack.AttachmentActionOption, len(minutes))
-	for i := 0; i < len(options); i++ {
-		options[i] = slack.AttachmentActionOption{
-			Text:  fmt.Sprintf("%d minutes before", minutes[i]),
-			Value: (time.Minute * time.Duration(minutes[i])).String(),
-		}
+// ParseInterviewModal parses the submitted values of an interview modal in the specified location.
+// A *ViewValidationError is returned if any of the values are invalid.
+func ParseInterviewModal(state ViewState, loc *time.Location) (*models.Interview, error) {
+	verr := NewViewValidationError()
+
+	candidate := strings.TrimSpace(state.Get(BlockInterviewCandidate, BlockInterviewCandidate).Value)
+	if candidate == "" {
+		verr.Add(BlockInterviewCandidate, "Please enter the candidate's name")
 	}
 
-	selectedOptions := []slack.AttachmentActionOption{
-		{
-			Text:  fmt.Sprintf("%d minutes before", int(interview.Reminder.Minutes())),
-			Value: interview.Reminder.String(),
-		},
+	t := parseInterviewTime(state, loc, verr)
+
+	interviewerIDs := state.Get(BlockInterviewInterviewers, BlockInterviewInterviewers).SelectedUsers
+	if len(interviewerIDs) == 0 {
+		verr.Add(BlockInterviewInterviewers, "Please select at least one interviewer")
 	}
 
-	return slack.Attachment{
-		Text:       "*When should I send a reminder?*",
-		Fallback:   "You are currently unable to specify when to send a reminder. Please try again later.",
-		Color:      "good",
-		CallbackID: interview.InterviewID,
-		Actions: []slack.AttachmentAction{
-			{
-				Name:            ActionSelectReminder,
-				Text:            "select a time",
-				Type:            "select",
-				Options:         options,
-				SelectedOptions: selectedOptions,
-			},
-		},
+	var reminder time.Duration
+	var err error
+	if option := state.Get(BlockInterviewReminder, BlockInterviewReminder).SelectedOption; option == nil {
+		verr.Add(BlockInterviewReminder, "Please select when to remind the interviewers")
+	} else if reminder, err = time.ParseDuration(option.Value); err != nil {
+		verr.Add(BlockInterviewReminder, fmt.Sprintf("'%s' is not a valid reminder", option.Value))
 	}
-}
 
-func selectInterviewersAttachment(interview models.Interview) slack.Attachment {
-	actions := make([]slack.AttachmentAction, len(interview.InterviewerIDs))
-	for i := 0; i < len(actions); i++ {
-		actions[i] = slack.AttachmentAction{
-			Name:       fmt.Sprintf("%s_%d", ActionSelectInterviewer, i),
-			Text:       "select an interviewer",
-			Type:       "select",
-			DataSource: "users",
-			SelectedOptions: []slack.AttachmentActionOption{
-				{
-					Text:  interview.InterviewerIDs[i],
-					Value: interview.InterviewerIDs[i],
-				},
-			},
+	// modals opened before interviews had a duration use the interview-duration setting
+	var duration time.Duration
+	if option := state.Get(BlockInterviewDuration, BlockInterviewDuration).SelectedOption; option != nil {
+		if duration, err = time.ParseDuration(option.Value); err != nil {
+			verr.Add(BlockInterviewDuration, fmt.Sprintf("'%s' is not a valid duration", option.Value))
 		}
 	}
 
-	return slack.Attachment{
-		Text:       "*Who is performing the interview?*",
-		Fallback:   "You are currently unable to select who performs the interview. Please try again later.",
-		Color:      "good",
-		CallbackID: interview.InterviewID,
-		Actions:    actions,
+	if len(verr.Errors) > 0 {
+		return nil, verr
+	}
+
+	interview := &models.Interview{
+		Candidate:      strings.Title(candidate),
+		InterviewerIDs: interviewerIDs,
+		Time:           t,
+		Reminder:       reminder,
+		Duration:       duration,
 	}
+
+	if err = interview.Validate(); err != nil {
+		verr.Add(BlockInterviewCandidate, err.Error())
+		return nil, verr
+	}
+
+	return interview, nil
 }
 
-func addInterviewerAttachment(interview models.Interview) slack.Attachment {
-	return slack.Attachment{
-		Fallback:   "You are currently unable to add more interviewers. Please try again later.",
-		Color:      "good",
-		CallbackID: interview.InterviewID,
-		Actions: []slack.AttachmentAction{
-			{
-				Name:  ActionAddInterviewer,
-				Text:  "+ Add Interviewer",
-				Type:  "button",
-				Style: "primary",
-			},
-		},
+// ParseRescheduleModal parses the submitted date and time of a reschedule modal in the specified location.
+// A *ViewValidationError is returned if either of the values are invalid.
+func ParseRescheduleModal(state ViewState, loc *time.Location) (time.Time, error) {
+	verr := NewViewValidationError()
+	t := parseInterviewTime(state, loc, verr)
+	if len(verr.Errors) > 0 {
+		return time.Time{}, verr
 	}
+
+	return t, nil
 }
 
-func scheduleAttachment(interview models.Interview) slack.Attachment {
-	return slack.Attachment{
-		Text:       "*Schedule*",
-		Fallback:   "You are currently unable to schedule the interview. Please try again later.",
-		Color:      "good",
-		CallbackID: interview.InterviewID,
-		Actions: []slack.AttachmentAction{
-			{
-				Name:  ActionSchedule,
-				Text:  "Schedule",
-				Type:  "button",
-				Style: "primary",
-			},
-			{
-				Name:  ActionCancel,
-				Text:  "Cancel",
-				Type:  "button",
-				Style: "danger",
-				Confirm: &slack.ConfirmationField{
-					Title: "Are you sure?",
-					Text:  "This interview will be cancelled.",
-				},
-			},
-		},
+// WithConflictWarnings adds the warnings to the top of an interview modal,
+// and adds a checkbox to the bottom that lets the user save the interview anyway.
+func WithConflictWarnings(view *ModalView, warnings []string) *ModalView {
+	text := ":warning: *This interview has conflicts:*\n" + strings.Join(warnings, "\n")
+	warning := slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil)
+
+	option := slack.NewOptionBlockObject(BlockInterviewOverride, slack.NewTextBlockObject(slack.PlainTextType, "Save the interview anyway", false, false))
+	override := NewInputBlock(BlockInterviewOverride, "Override", NewCheckboxesBlockElement(BlockInterviewOverride, option))
+	override.Optional = true
+
+	view.Blocks.BlockSet = append([]slack.Block{warning}, view.Blocks.BlockSet...)
+	view.Blocks.BlockSet = append(view.Blocks.BlockSet, override)
+	return view
+}
+
+// ParseOverride returns true if the user chose to save an interview modal despite its conflicts
+func ParseOverride(state ViewState) bool {
+	for _, option := range state.Get(BlockInterviewOverride, BlockInterviewOverride).SelectedOptions {
+		if option.Value == BlockInterviewOverride {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseInterviewTime combines the date and time inputs of an interview modal, adding any errors to verr
+func parseInterviewTime(state ViewState, loc *time.Location, verr *ViewValidationError) time.Time {
+	date, err := time.ParseInLocation(DatePickerFormat, state.Get(BlockInterviewDate, BlockInterviewDate).SelectedDate, loc)
+	if err != nil {
+		verr.Add(BlockInterviewDate, "Please select a date")
+	}
+
+	clock, err := time.Parse(TimePickerFormat, state.Get(BlockInterviewTime, BlockInterviewTime).SelectedTime)
+	if err != nil {
+		verr.Add(BlockInterviewTime, "Please select a time")
 	}
+
+	return time.Date(date.Year(), date.Month(), date.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
 }
 
-func ListInterviewsView(interviews models.Interviews) *slack.Message {
+// ListInterviewsView renders the interviews with their times in each user's time zone.
+// The location is used by clients that can't display times in the user's time zone.
+func ListInterviewsView(interviews models.Interviews, loc *time.Location) *slack.Message {
 	if len(interviews) == 0 {
 		view := slack.Msg{
 			ResponseType: "in_channel",
@@ -240,15 +268,16 @@ func ListInterviewsView(interviews models.Interviews) *slack.Message {
 			Fallback:   "You are currently unable to view this interview. Please try again later.",
 			Color:      "good",
 			CallbackID: interview.InterviewID,
+			MarkdownIn: []string{"fields"},
 			Fields: []slack.AttachmentField{
 				{
 					Title: "Date",
-					Value: interview.Time.Format(DateDisplayFormat),
+					Value: FormatSlackDate(interview.Time, SlackDateToken, DateDisplayFormat, loc),
 					Short: true,
 				},
 				{
-					Title: "Time (PDT)",
-					Value: interview.Time.Format(TimeDisplayFormat),
+					Title: "Time",
+					Value: FormatSlackDate(interview.Time, SlackTimeToken, TimeDisplayFormat, loc),
 					Short: true,
 				},
 				{
@@ -256,8 +285,17 @@ func ListInterviewsView(interviews models.Interviews) *slack.Message {
 					Value: interviewersText,
 				},
 			},
-			Footer: "Careful! This cannot be undone!",
 			Actions: []slack.AttachmentAction{
+				{
+					Name: ActionEdit,
+					Text: "Edit",
+					Type: "button",
+				},
+				{
+					Name: ActionReschedule,
+					Text: "Reschedule",
+					Type: "button",
+				},
 				{
 					Name:  ActionDelete,
 					Text:  "Delete",
@@ -265,7 +303,7 @@ func ListInterviewsView(interviews models.Interviews) *slack.Message {
 					Style: "danger",
 					Confirm: &slack.ConfirmationField{
 						Title: "Are you sure?",
-						Text:  "This interview will be deleted.",
+						Text:  "This interview will be deleted. This cannot be undone!",
 					},
 				},
 			},
@@ -0,0 +1,263 @@
+package slash
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nlopes/slack"
+)
+
+// The version of github.com/nlopes/slack used by iqvbot predates modals,
+// so the parts of the views api used by slash commands are defined here.
+// See https://api.slack.com/surfaces/modals for more information.
+
+// InteractionTypeViewSubmission is the type of the payload sent when a user submits a modal
+const InteractionTypeViewSubmission = "view_submission"
+
+// MBTInput is the type of blocks that collect user input in modals
+const MBTInput slack.MessageBlockType = "input"
+
+// ModalView is a view that can be opened with ViewsClient.OpenView.
+// The CallbackID must be the name of the slash command that handles the submission.
+type ModalView struct {
+	Type            string                 `json:"type"`
+	CallbackID      string                 `json:"callback_id,omitempty"`
+	PrivateMetadata string                 `json:"private_metadata,omitempty"`
+	Title           *slack.TextBlockObject `json:"title"`
+	Submit          *slack.TextBlockObject `json:"submit,omitempty"`
+	Close           *slack.TextBlockObject `json:"close,omitempty"`
+	Blocks          slack.Blocks           `json:"blocks"`
+}
+
+// NewModalView creates a new modal view with the specified title and blocks.
+// Titles are limited to 24 characters by slack.
+func NewModalView(callbackID, title string, blocks ...slack.Block) *ModalView {
+	return &ModalView{
+		Type:       "modal",
+		CallbackID: callbackID,
+		Title:      slack.NewTextBlockObject(slack.PlainTextType, title, false, false),
+		Close:      slack.NewTextBlockObject(slack.PlainTextType, "Cancel", false, false),
+		Blocks:     slack.Blocks{BlockSet: blocks},
+	}
+}
+
+// InputBlock collects a single value from the user in a modal
+type InputBlock struct {
+	Type     slack.MessageBlockType `json:"type"`
+	BlockID  string                 `json:"block_id"`
+	Label    *slack.TextBlockObject `json:"label"`
+	Element  interface{}            `json:"element"`
+	Hint     *slack.TextBlockObject `json:"hint,omitempty"`
+	Optional bool                   `json:"optional,omitempty"`
+}
+
+// BlockType returns the type of the block
+func (b InputBlock) BlockType() slack.MessageBlockType {
+	return b.Type
+}
+
+// NewInputBlock returns a new input block with a plain text label
+func NewInputBlock(blockID, label string, element interface{}) *InputBlock {
+	return &InputBlock{
+		Type:    MBTInput,
+		BlockID: blockID,
+		Label:   slack.NewTextBlockObject(slack.PlainTextType, label, false, false),
+		Element: element,
+	}
+}
+
+// PlainTextInputBlockElement lets users enter free-form text
+type PlainTextInputBlockElement struct {
+	Type         string `json:"type"`
+	ActionID     string `json:"action_id"`
+	InitialValue string `json:"initial_value,omitempty"`
+}
+
+// NewPlainTextInputBlockElement returns a new plain text input element
+func NewPlainTextInputBlockElement(actionID, initialValue string) *PlainTextInputBlockElement {
+	return &PlainTextInputBlockElement{
+		Type:         "plain_text_input",
+		ActionID:     actionID,
+		InitialValue: initialValue,
+	}
+}
+
+// TimePickerBlockElement lets users select a time of day in 'HH:mm' format
+type TimePickerBlockElement struct {
+	Type        string `json:"type"`
+	ActionID    string `json:"action_id"`
+	InitialTime string `json:"initial_time,omitempty"`
+}
+
+// NewTimePickerBlockElement returns a new time picker element
+func NewTimePickerBlockElement(actionID, initialTime string) *TimePickerBlockElement {
+	return &TimePickerBlockElement{
+		Type:        "timepicker",
+		ActionID:    actionID,
+		InitialTime: initialTime,
+	}
+}
+
+// MultiUsersSelectBlockElement lets users select one or more slack users
+type MultiUsersSelectBlockElement struct {
+	Type         string   `json:"type"`
+	ActionID     string   `json:"action_id"`
+	InitialUsers []string `json:"initial_users,omitempty"`
+}
+
+// NewMultiUsersSelectBlockElement returns a new multi-user select element
+func NewMultiUsersSelectBlockElement(actionID string, initialUsers ...string) *MultiUsersSelectBlockElement {
+	return &MultiUsersSelectBlockElement{
+		Type:         "multi_users_select",
+		ActionID:     actionID,
+		InitialUsers: initialUsers,
+	}
+}
+
+// CheckboxesBlockElement lets users select any number of options
+type CheckboxesBlockElement struct {
+	Type     string                     `json:"type"`
+	ActionID string                     `json:"action_id"`
+	Options  []*slack.OptionBlockObject `json:"options"`
+}
+
+// NewCheckboxesBlockElement returns a new checkboxes element
+func NewCheckboxesBlockElement(actionID string, options ...*slack.OptionBlockObject) *CheckboxesBlockElement {
+	return &CheckboxesBlockElement{
+		Type:     "checkboxes",
+		ActionID: actionID,
+		Options:  options,
+	}
+}
+
+// ViewSubmission is the payload slack sends when a user submits a modal
+type ViewSubmission struct {
+	Type string     `json:"type"`
+	User slack.User `json:"user"`
+	View struct {
+		ID              string    `json:"id"`
+		CallbackID      string    `json:"callback_id"`
+		PrivateMetadata string    `json:"private_metadata"`
+		State           ViewState `json:"state"`
+	} `json:"view"`
+}
+
+// ViewState holds the values of the input blocks in a submitted modal, keyed by block id and action id
+type ViewState struct {
+	Values map[string]map[string]ViewStateValue `json:"values"`
+}
+
+// Get returns the value of the input block's element
+func (v ViewState) Get(blockID, actionID string) ViewStateValue {
+	return v.Values[blockID][actionID]
+}
+
+// ViewStateValue is the value of a single input element; which field is set depends on the element's type
+type ViewStateValue struct {
+	Type            string                     `json:"type"`
+	Value           string                     `json:"value"`
+	SelectedDate    string                     `json:"selected_date"`
+	SelectedTime    string                     `json:"selected_time"`
+	SelectedUsers   []string                   `json:"selected_users"`
+	SelectedOption  *slack.OptionBlockObject   `json:"selected_option"`
+	SelectedOptions []*slack.OptionBlockObject `json:"selected_options"`
+}
+
+// ViewMetadata is stored in the private metadata of modals opened by slash commands.
+// ResponseURL is the response url of the interaction that opened the modal;
+// the message returned by a view submission handler is posted to it.
+// Action tells commands that open more than one kind of modal which one was submitted.
+type ViewMetadata struct {
+	ResponseURL string `json:"response_url,omitempty"`
+	ID          string `json:"id,omitempty"`
+	Action      string `json:"action,omitempty"`
+}
+
+// Encode returns the metadata in a format that can be stored in ModalView.PrivateMetadata
+func (m ViewMetadata) Encode() string {
+	// marshalling a struct of strings cannot fail
+	b, _ := json.Marshal(m)
+	return string(b)
+}
+
+// ParseViewMetadata parses the private metadata of a submitted modal
+func ParseViewMetadata(privateMetadata string) (ViewMetadata, error) {
+	var m ViewMetadata
+	if err := json.Unmarshal([]byte(privateMetadata), &m); err != nil {
+		return m, fmt.Errorf("Failed to parse view metadata: %v", err)
+	}
+
+	return m, nil
+}
+
+// ViewValidationError occurs when a submitted modal has invalid inputs.
+// Errors maps input block ids to the message displayed under that block.
+type ViewValidationError struct {
+	Errors map[string]string
+}
+
+// NewViewValidationError creates a new, empty ViewValidationError object
+func NewViewValidationError() *ViewValidationError {
+	return &ViewValidationError{
+		Errors: map[string]string{},
+	}
+}
+
+// Add sets the error message for an input block, unless it already has one
+func (v *ViewValidationError) Add(blockID, text string) {
+	if _, ok := v.Errors[blockID]; !ok {
+		v.Errors[blockID] = text
+	}
+}
+
+func (v *ViewValidationError) Error() string {
+	blockIDs := make([]string, 0, len(v.Errors))
+	for blockID := range v.Errors {
+		blockIDs = append(blockIDs, blockID)
+	}
+
+	sort.Strings(blockIDs)
+	messages := make([]string, len(blockIDs))
+	for i, blockID := range blockIDs {
+		messages[i] = fmt.Sprintf("%s: %s", blockID, v.Errors[blockID])
+	}
+
+	return strings.Join(messages, ", ")
+}
+
+// ViewUpdateError occurs when a submitted modal can't be saved until the user has reviewed it.
+// The submitted modal is replaced with View, which should explain why.
+type ViewUpdateError struct {
+	View   *ModalView
+	Reason string
+}
+
+func (v *ViewUpdateError) Error() string {
+	return v.Reason
+}
+
+// ViewSubmissionResponse is the body returned to slack after a modal has been submitted.
+// See https://api.slack.com/surfaces/modals/using#responding_to_submissions for more information.
+type ViewSubmissionResponse struct {
+	ResponseAction string            `json:"response_action"`
+	Errors         map[string]string `json:"errors,omitempty"`
+	View           *ModalView        `json:"view,omitempty"`
+}
+
+// NewViewErrorsResponse returns a response that displays the errors under their input blocks
+func NewViewErrorsResponse(err *ViewValidationError) *ViewSubmissionResponse {
+	return &ViewSubmissionResponse{
+		ResponseAction: "errors",
+		Errors:         err.Errors,
+	}
+}
+
+// NewViewUpdateResponse returns a response that replaces the submitted modal with view
+func NewViewUpdateResponse(view *ModalView) *ViewSubmissionResponse {
+	return &ViewSubmissionResponse{
+		ResponseAction: "update",
+		View:           view,
+	}
+}
@@ -0,0 +1,321 @@
+package slash
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+	"github.com/nlopes/slack"
+	"github.com/zpatrick/slackbot"
+)
+
+// the types of values that args and flags can have
+const (
+	TypeString = "string"
+	TypeInt    = "int"
+	TypeBool   = "bool"
+	TypeUser   = "user"
+)
+
+// A Command is a slash command, or one of its subcommands, with typed args and flags.
+// The text of a slash command is split like a shell command, so values with spaces can be quoted.
+// Running 'help' after any command displays help that is generated from its fields.
+type Command struct {
+	Name        string
+	Usage       string
+	Args        []Arg
+	Flags       []Flag
+	Subcommands []*Command
+
+	// Action runs the command once its args and flags have been validated.
+	// Commands without an Action display their help instead.
+	Action func(*Context) (*slack.Message, error)
+}
+
+// An Arg is a positional argument of a command.
+// The last arg of a command may be Variadic, in which case it is set to the remaining args joined by spaces.
+type Arg struct {
+	Name     string
+	Usage    string
+	Type     string
+	Optional bool
+	Variadic bool
+}
+
+// A Flag is an optional '--name value' or '--name=value' argument of a command.
+// Bool flags don't take a value.
+type Flag struct {
+	Name    string
+	Usage   string
+	Type    string
+	Default string
+}
+
+// Context holds the validated args and flags of a command.
+// Args and flags are looked up by name; user args and flags hold the parsed user id.
+type Context struct {
+	Request slack.SlashCommand
+	values  map[string]string
+}
+
+// IsSet returns true if the arg or flag was specified, or has a default
+func (c *Context) IsSet(name string) bool {
+	_, ok := c.values[name]
+	return ok
+}
+
+// String returns the value of the arg or flag
+func (c *Context) String(name string) string {
+	return c.values[name]
+}
+
+// Int returns the value of an int arg or flag, or 0 if it isn't set
+func (c *Context) Int(name string) int {
+	v, _ := strconv.Atoi(c.values[name])
+	return v
+}
+
+// Bool returns the value of a bool flag
+func (c *Context) Bool(name string) bool {
+	v, _ := strconv.ParseBool(c.values[name])
+	return v
+}
+
+// Schema returns a CommandSchema that runs the command
+func (c *Command) Schema() *CommandSchema {
+	return &CommandSchema{
+		Name: c.Name,
+		Help: c.help(c.Name),
+		Run:  c.Run,
+	}
+}
+
+// Run finds the subcommand in the request's text, validates its args and flags, and runs it.
+// Invalid input is returned as an ephemeral *SlackMessageError.
+func (c *Command) Run(req slack.SlashCommand) (*slack.Message, error) {
+	tokens, err := shellquote.Split(req.Text)
+	if err != nil {
+		return nil, NewSlackMessageErrorf("Failed to parse `%s %s`: %v", c.Name, req.Text, err)
+	}
+
+	cmd, name := c, c.Name
+	for len(tokens) > 0 {
+		sub := cmd.subcommand(tokens[0])
+		if sub == nil {
+			break
+		}
+
+		cmd, name, tokens = sub, name+" "+sub.Name, tokens[1:]
+	}
+
+	if cmd.Action == nil && len(tokens) > 0 && tokens[0] != "help" {
+		return nil, NewSlackMessageErrorf("Command '%s %s' does not exist\nUse `%s help` for more information", name, tokens[0], name)
+	}
+
+	if cmd.Action == nil || (len(tokens) == 1 && tokens[0] == "help") {
+		msg := &slack.Message{
+			Msg: slack.Msg{
+				ResponseType: "ephemeral",
+				Text:         cmd.help(name),
+			},
+		}
+
+		return msg, nil
+	}
+
+	values, err := cmd.parse(tokens)
+	if err != nil {
+		return nil, NewSlackMessageErrorf("%s\nUsage: `%s`\nUse `%s help` for more information", err.Error(), cmd.usage(name), name)
+	}
+
+	return cmd.Action(&Context{Request: req, values: values})
+}
+
+func (c *Command) subcommand(name string) *Command {
+	for _, sub := range c.Subcommands {
+		if sub.Name == name {
+			return sub
+		}
+	}
+
+	return nil
+}
+
+func (c *Command) flag(name string) (Flag, bool) {
+	for _, flag := range c.Flags {
+		if flag.Name == name {
+			return flag, true
+		}
+	}
+
+	return Flag{}, false
+}
+
+// parse validates the tokens against the command's args and flags
+func (c *Command) parse(tokens []string) (map[string]string, error) {
+	values := map[string]string{}
+	positional := []string{}
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		if !strings.HasPrefix(token, "--") || len(token) == 2 {
+			positional = append(positional, token)
+			continue
+		}
+
+		name := strings.TrimPrefix(token, "--")
+		var value string
+		hasValue := false
+		if j := strings.Index(name, "="); j >= 0 {
+			name, value, hasValue = name[:j], name[j+1:], true
+		}
+
+		flag, ok := c.flag(name)
+		if !ok {
+			return nil, fmt.Errorf("Flag '--%s' does not exist", name)
+		}
+
+		switch {
+		case hasValue:
+		case flag.Type == TypeBool:
+			value = "true"
+		case i+1 < len(tokens):
+			i++
+			value = tokens[i]
+		default:
+			return nil, fmt.Errorf("Flag '--%s' requires a value", name)
+		}
+
+		parsed, err := parseValue(flag.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid value for '--%s': %v", name, err)
+		}
+
+		values[name] = parsed
+	}
+
+	for _, flag := range c.Flags {
+		if _, ok := values[flag.Name]; !ok && flag.Default != "" {
+			values[flag.Name] = flag.Default
+		}
+	}
+
+	for i, arg := range c.Args {
+		if i >= len(positional) {
+			if !arg.Optional {
+				return nil, fmt.Errorf("Argument %s is required", arg.Name)
+			}
+
+			break
+		}
+
+		value := positional[i]
+		if arg.Variadic {
+			value = strings.Join(positional[i:], " ")
+			positional = positional[:i+1]
+		}
+
+		parsed, err := parseValue(arg.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid value for %s: %v", arg.Name, err)
+		}
+
+		values[arg.Name] = parsed
+	}
+
+	if len(positional) > len(c.Args) {
+		return nil, fmt.Errorf("Unexpected argument '%s'", positional[len(c.Args)])
+	}
+
+	return values, nil
+}
+
+// parseValue validates value against the type, returning the value that is stored in a Context
+func parseValue(valueType, value string) (string, error) {
+	switch valueType {
+	case TypeInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return "", fmt.Errorf("'%s' is not a whole number", value)
+		}
+	case TypeBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return "", fmt.Errorf("'%s' is not 'true' or 'false'", value)
+		}
+	case TypeUser:
+		userID, err := slackbot.ParseUserID(value)
+		if err != nil {
+			return "", fmt.Errorf("'%s' is not in valid @username format", value)
+		}
+
+		return userID, nil
+	}
+
+	return value, nil
+}
+
+// usage returns a line describing how to run the command, e.g. '/hire status [--mine]'
+func (c *Command) usage(name string) string {
+	parts := []string{name}
+	for _, flag := range c.Flags {
+		if flag.Type == TypeBool {
+			parts = append(parts, fmt.Sprintf("[--%s]", flag.Name))
+			continue
+		}
+
+		parts = append(parts, fmt.Sprintf("[--%s %s]", flag.Name, strings.ToUpper(flag.Name)))
+	}
+
+	for _, arg := range c.Args {
+		part := arg.Name
+		if arg.Variadic {
+			part += "..."
+		}
+
+		if arg.Optional {
+			part = fmt.Sprintf("[%s]", part)
+		}
+
+		parts = append(parts, part)
+	}
+
+	if c.Action == nil && len(c.Subcommands) > 0 {
+		parts = append(parts, "COMMAND")
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// help describes the command, its args and flags, and its subcommands
+func (c *Command) help(name string) string {
+	text := fmt.Sprintf("*%s*", name)
+	if c.Usage != "" {
+		text += fmt.Sprintf(" - %s", c.Usage)
+	}
+
+	text += fmt.Sprintf("\nUsage: `%s`\n", c.usage(name))
+	for _, arg := range c.Args {
+		if arg.Usage != "" {
+			text += fmt.Sprintf("`%s`: %s\n", arg.Name, arg.Usage)
+		}
+	}
+
+	for _, flag := range c.Flags {
+		text += fmt.Sprintf("`--%s`: %s", flag.Name, flag.Usage)
+		if flag.Default != "" {
+			text += fmt.Sprintf(" (default: %s)", flag.Default)
+		}
+
+		text += "\n"
+	}
+
+	if len(c.Subcommands) == 0 {
+		return text
+	}
+
+	text += "Commands:\n"
+	for _, sub := range c.Subcommands {
+		text += fmt.Sprintf("`%s` - %s\n", sub.usage(name+" "+sub.Name), sub.Usage)
+	}
+
+	return text + fmt.Sprintf("Use `%s COMMAND help` for more information about a command", name)
+}
@@ -0,0 +1,138 @@
+package slash
+
+import (
+	"testing"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestRouter returns a command that records the context of the last action it ran
+func newTestRouter(result **Context) *Command {
+	record := func(c *Context) (*slack.Message, error) {
+		*result = c
+		return &slack.Message{}, nil
+	}
+
+	return &Command{
+		Name:  "/test",
+		Usage: "test the router",
+		Subcommands: []*Command{
+			{
+				Name:  "add",
+				Usage: "add something",
+				Args: []Arg{
+					{Name: "USER", Type: TypeUser},
+					{Name: "TEXT", Usage: "Some text", Optional: true, Variadic: true},
+				},
+				Flags: []Flag{
+					{Name: "count", Usage: "How many", Type: TypeInt, Default: "1"},
+					{Name: "force", Usage: "Do it anyway", Type: TypeBool},
+				},
+				Action: record,
+			},
+			{
+				Name:  "rm",
+				Usage: "remove something",
+				Args: []Arg{
+					{Name: "ID"},
+				},
+				Action: record,
+			},
+		},
+	}
+}
+
+func assertSlackMessageError(t *testing.T, err error) *SlackMessageError {
+	e, ok := err.(*SlackMessageError)
+	if !ok {
+		t.Fatalf("Error was not SlackMessageError: %#v", err)
+	}
+
+	assert.Equal(t, "ephemeral", e.ResponseType)
+	return e
+}
+
+func TestCommandRun(t *testing.T) {
+	var c *Context
+	router := newTestRouter(&c)
+
+	if _, err := router.Run(slack.SlashCommand{Text: `add <@uid> --count 3 "hello  there" world --force`}); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "uid", c.String("USER"))
+	assert.Equal(t, "hello  there world", c.String("TEXT"))
+	assert.Equal(t, 3, c.Int("count"))
+	assert.True(t, c.Bool("force"))
+
+	if _, err := router.Run(slack.SlashCommand{Text: "add <@uid> --force=false"}); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.False(t, c.IsSet("TEXT"))
+	assert.Equal(t, 1, c.Int("count"))
+	assert.False(t, c.Bool("force"))
+}
+
+func TestCommandRunErrors(t *testing.T) {
+	var c *Context
+	router := newTestRouter(&c)
+
+	cases := map[string]string{
+		"missing arg":     "rm",
+		"extra arg":       "rm a b",
+		"bad int":         "add <@uid> --count three",
+		"missing value":   "add <@uid> --count",
+		"unknown flag":    "add <@uid> --nope",
+		"unknown command": "nope",
+		"bad quotes":      `rm "a`,
+	}
+
+	for name, text := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := router.Run(slack.SlashCommand{Text: text})
+			assertSlackMessageError(t, err)
+		})
+	}
+
+	_, err := router.Run(slack.SlashCommand{Text: "rm"})
+	expected := "Argument ID is required\nUsage: `/test rm ID`\nUse `/test rm help` for more information"
+	assert.Equal(t, expected, assertSlackMessageError(t, err).Text)
+}
+
+func TestCommandHelp(t *testing.T) {
+	var c *Context
+	router := newTestRouter(&c)
+
+	for _, text := range []string{"", "help"} {
+		msg, err := router.Run(slack.SlashCommand{Text: text})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := "*/test* - test the router\n" +
+			"Usage: `/test COMMAND`\n" +
+			"Commands:\n" +
+			"`/test add [--count COUNT] [--force] USER [TEXT...]` - add something\n" +
+			"`/test rm ID` - remove something\n" +
+			"Use `/test COMMAND help` for more information about a command"
+
+		assert.Equal(t, expected, msg.Text)
+		assert.Equal(t, expected, router.Schema().Help)
+	}
+
+	msg, err := router.Run(slack.SlashCommand{Text: "add help"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "*/test add* - add something\n" +
+		"Usage: `/test add [--count COUNT] [--force] USER [TEXT...]`\n" +
+		"`TEXT`: Some text\n" +
+		"`--count`: How many (default: 1)\n" +
+		"`--force`: Do it anyway\n"
+
+	assert.Equal(t, expected, msg.Text)
+	assert.Nil(t, c)
+}
@@ -4,11 +4,26 @@ import (
 	"github.com/nlopes/slack"
 )
 
+// A CommandSchema describes how the SlashCommandController handles a slash command and its interactions.
+// Most schemas are created with Command.Schema, which validates the command's input and generates its Help.
 type CommandSchema struct {
-	Name     string
-	Help     string
-	Run      func(slack.SlashCommand) (*slack.Message, error)
+	Name string
+	Help string
+	Run  func(slack.SlashCommand) (*slack.Message, error)
+
+	// Callback handles interactions with the attachments of messages returned by Run or Callback.
+	// Callback ids are namespaced with the command's Name while they are displayed, and expire after
+	// the callback-expiry setting; Callback receives the ids without the namespace.
 	Callback func(slack.AttachmentActionCallback) (*slack.Message, error)
-}
 
-// todo: Validate() func?
+	// BlockAction handles interactions with Block Kit elements whose action id is in BlockActionIDs.
+	// The returned message replaces the message that contained the element.
+	BlockActionIDs []string
+	BlockAction    func(slack.InteractionCallback) (*slack.Message, error)
+
+	// ViewSubmission validates submitted modals whose callback id is the command's Name.
+	// Returning a *ViewValidationError displays its errors in the modal instead.
+	// Otherwise, the modal is closed and the returned func is run in the background;
+	// the message it returns is posted to the response url in the modal's ViewMetadata.
+	ViewSubmission func(ViewSubmission) (func() (*slack.Message, error), error)
+}
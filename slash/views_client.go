@@ -0,0 +1,91 @@
+package slash
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// SlackAPIEndpoint is the base url of the slack web api
+const SlackAPIEndpoint = "https://slack.com/api/"
+
+// ViewsClient opens modals in response to slash commands and interactions
+type ViewsClient interface {
+	OpenView(triggerID string, view *ModalView) error
+}
+
+// SlackViewsClient calls the slack views api using a bot token
+type SlackViewsClient struct {
+	token    string
+	endpoint string
+	client   *http.Client
+}
+
+// NewSlackViewsClient creates a new SlackViewsClient object.
+// The endpoint is usually SlackAPIEndpoint.
+func NewSlackViewsClient(token, endpoint string) *SlackViewsClient {
+	return &SlackViewsClient{
+		token:    token,
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		client:   http.DefaultClient,
+	}
+}
+
+// OpenView opens the modal for the user that triggered the interaction.
+// Trigger ids expire 3 seconds after slack sends them.
+func (s *SlackViewsClient) OpenView(triggerID string, view *ModalView) error {
+	body := struct {
+		TriggerID string     `json:"trigger_id"`
+		View      *ModalView `json:"view"`
+	}{
+		TriggerID: triggerID,
+		View:      view,
+	}
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", s.endpoint+"/views.open", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+s.token)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Slack responded with status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		OK               bool   `json:"ok"`
+		Error            string `json:"error"`
+		ResponseMetadata struct {
+			Messages []string `json:"messages"`
+		} `json:"response_metadata"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+
+	if !result.OK {
+		if messages := result.ResponseMetadata.Messages; len(messages) > 0 {
+			return fmt.Errorf("Failed to open view: %s (%s)", result.Error, strings.Join(messages, ", "))
+		}
+
+		return fmt.Errorf("Failed to open view: %s", result.Error)
+	}
+
+	return nil
+}
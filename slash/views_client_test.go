@@ -0,0 +1,55 @@
+package slash
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlackViewsClientOpenView(t *testing.T) {
+	var body struct {
+		TriggerID string          `json:"trigger_id"`
+		View      json.RawMessage `json:"view"`
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/views.open", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+
+	client := NewSlackViewsClient("token", server.URL+"/")
+	if err := client.OpenView("trigger_id", NewModalView("/test", "Test")); err != nil {
+		t.Fatal(err)
+	}
+
+	var view ModalView
+	if err := json.Unmarshal(body.View, &view); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "trigger_id", body.TriggerID)
+	assert.Equal(t, "modal", view.Type)
+	assert.Equal(t, "/test", view.CallbackID)
+}
+
+func TestSlackViewsClientOpenViewError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok": false, "error": "expired_trigger_id"}`))
+	}))
+	defer server.Close()
+
+	client := NewSlackViewsClient("token", server.URL)
+	err := client.OpenView("trigger_id", NewModalView("/test", "Test"))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "expired_trigger_id")
+	}
+}
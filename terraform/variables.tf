@@ -31,6 +31,10 @@ variable "slack_app_token" {
   description = "Authentication token for the Slack app"
 }
 
+variable "slack_signing_secret" {
+  description = "Signing secret used to verify requests from Slack"
+}
+
 variable "tenor_key" {
   description = "Authentication token for Tenor"
 }
@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"io"
+
+	"github.com/nlopes/slack"
+)
+
+// SlackClient is the subset of slack.Client methods used by iqvbot.
+// Mocks for this interface are generated by running `make mocks`.
+type SlackClient interface {
+	GetFile(downloadURL string, w io.Writer) error
+	GetUserInfo(userID string) (*slack.User, error)
+	GetUsers() ([]slack.User, error)
+	OpenIMChannel(userID string) (bool, bool, string, error)
+	SendMessage(channelID string, options ...slack.MsgOption) (string, string, string, error)
+	UploadFile(params slack.FileUploadParameters) (*slack.File, error)
+}
@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"log"
+	"time"
+)
+
+// UserLocation returns the time zone from the user's slack profile.
+// The fallback is returned if the profile can't be read or doesn't have a valid time zone.
+func UserLocation(client SlackClient, userID string, fallback *time.Location) *time.Location {
+	user, err := client.GetUserInfo(userID)
+	if err != nil {
+		log.Printf("[ERROR] Failed to read the time zone of %s: %v", userID, err)
+		return fallback
+	}
+
+	if user.TZ == "" {
+		return fallback
+	}
+
+	loc, err := time.LoadLocation(user.TZ)
+	if err != nil {
+		log.Printf("[ERROR] User %s has an invalid time zone '%s': %v", userID, user.TZ, err)
+		return fallback
+	}
+
+	return loc
+}